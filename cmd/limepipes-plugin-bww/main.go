@@ -2,40 +2,86 @@ package main
 
 import (
 	"github.com/hashicorp/go-plugin"
+	"github.com/rs/zerolog/log"
 	"github.com/spf13/afero"
 	"github.com/tomvodi/limepipes-plugin-api/plugin/v1/common"
 	"github.com/tomvodi/limepipes-plugin-api/plugin/v1/fileformat"
 	"github.com/tomvodi/limepipes-plugin-api/plugin/v1/grpcplugin"
 	"github.com/tomvodi/limepipes-plugin-bww/internal/bww"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/bww/exporter"
 	"github.com/tomvodi/limepipes-plugin-bww/internal/bww/parser"
 	"github.com/tomvodi/limepipes-plugin-bww/internal/bww/symbolmapper"
 	"github.com/tomvodi/limepipes-plugin-bww/internal/bww/symbolmerger"
 	"github.com/tomvodi/limepipes-plugin-bww/internal/bwwfile"
+	bwwcommon "github.com/tomvodi/limepipes-plugin-bww/internal/common"
 	"github.com/tomvodi/limepipes-plugin-bww/internal/common/helper"
 	"github.com/tomvodi/limepipes-plugin-bww/internal/pluginimplementation"
 	"google.golang.org/grpc"
+	"os"
 )
 
+// parseModeEnv is the environment variable that enables the lenient parse mode
+// when it is set to "lenient", or "lenient-suggestions" to also replace unknown
+// symbols with a confident suggestion. Without it, files are parsed in strict mode.
+const parseModeEnv = "LIMEPIPES_BWW_PARSE_MODE"
+
+// encodingEnv is the environment variable with the IANA name of the encoding of
+// files without byte order mark, like windows-1252 or ISO-8859-1. Without it or
+// with an unknown name, the encoding is detected.
+const encodingEnv = "LIMEPIPES_BWW_ENCODING"
+
 // defaultGRPCServer returns a new gRPC server with the given options.
 // Acts as a factory method for gRPC servers.
 func defaultGRPCServer(opts []grpc.ServerOption) *grpc.Server {
 	return grpc.NewServer(opts...)
 }
 
+func parseModeFromEnv() bwwcommon.ParseMode {
+	switch os.Getenv(parseModeEnv) {
+	case "lenient":
+		return bwwcommon.LenientParsing
+	case "lenient-suggestions":
+		return bwwcommon.LenientParsingWithSuggestions
+	default:
+		return bwwcommon.StrictParsing
+	}
+}
+
+func decoderFromEnv() *bwwfile.Decoder {
+	name := os.Getenv(encodingEnv)
+	if name == "" {
+		return bwwfile.NewDecoder(nil)
+	}
+
+	enc, err := bwwfile.EncodingByName(name)
+	if err != nil {
+		log.Warn().Err(err).Msgf("unknown encoding %s in %s, detecting the encoding instead", name, encodingEnv)
+		return bwwfile.NewDecoder(nil)
+	}
+
+	return bwwfile.NewDecoder(enc)
+}
+
 func main() {
-	tok := bwwfile.NewTokenizer()
+	mode := parseModeFromEnv()
+	tok := bwwfile.NewTokenizer(mode)
 	tokConv := bwwfile.NewTokenConverter()
 	sp := bwwfile.NewStructureParser(
 		tok,
 		tokConv,
+		decoderFromEnv(),
 	)
-	symmap := symbolmapper.New()
+	symmap, err := symbolmapper.New()
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed creating symbol mapper")
+	}
 	merger := symbolmerger.NewCollectedMerger()
-	fsconv := bww.NewConverter(symmap, merger)
+	fsconv := bww.NewConverter(symmap, merger, mode)
 	impl := pluginimplementation.NewPluginImplementation(
 		afero.NewOsFs(),
 		parser.New(sp, fsconv),
 		helper.NewTuneFixer(),
+		exporter.New(symmap),
 	)
 
 	plugin.Serve(&plugin.ServeConfig{
@@ -6,35 +6,46 @@ import (
 	"errors"
 	"fmt"
 	"github.com/rs/zerolog/log"
+	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/barline"
 	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/measure"
 	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/symbols"
 	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/tune"
 	"github.com/tomvodi/limepipes-plugin-bww/internal/common"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/diagnostics"
 	"github.com/tomvodi/limepipes-plugin-bww/internal/filestructure"
 	"github.com/tomvodi/limepipes-plugin-bww/internal/interfaces"
+	"slices"
 )
 
 type Converter struct {
 	mapper interfaces.SymbolMapper
 	merger interfaces.SymbolMerger
+	mode   common.ParseMode
 }
 
 func (c *Converter) Convert(
 	fst *filestructure.Tune,
-) (*tune.Tune, error) {
+) (*tune.Tune, *common.TuneSource, error) {
 	t := &tune.Tune{}
 	fillTuneWithHeader(t, fst.Header)
 
+	ts := newTuneState(fst.Staves)
+	ts.setHeaderStyles(fst.Header)
+	var diags diagnostics.List
 	for _, m := range fst.Measures {
 		meas := &measure.Measure{}
-		err := c.fillMeasure(meas, m)
-		if err != nil {
-			return nil, err
+		diags.Add(c.fillMeasure(meas, m, ts))
+		if ts.endsStaff(m) {
+			ts.setLineBreak()
 		}
 		t.Measures = append(t.Measures, meas)
 	}
 
-	return t, nil
+	if err := diags.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	return t, ts.source, nil
 }
 
 func fillTuneWithHeader(
@@ -96,26 +107,172 @@ func fillTuneComments(
 func (c *Converter) fillMeasure(
 	dest *measure.Measure,
 	src *filestructure.Measure,
+	ts *tuneState,
 ) error {
+	ts.startMeasure(src.Span)
 	fillInlineTextAndComments(dest, src)
-	c.setMeasureBarlines(dest, src)
+	ts.addInlineTextStyles(len(src.InlineTexts), src.InlineTextStyles)
+	ts.addInlineTextStyles(len(src.StaffInlineTexts), src.StaffInlineTextStyles)
+	fillStructureMessages(dest, src)
+
+	var diags diagnostics.List
+	diags.Add(c.setMeasureBarlines(dest, src))
+	diags.Add(c.setMeasureNavigation(dest, src, ts))
 
-	for _, s := range src.Symbols {
-		err := c.addSymbolToMeasure(dest, s)
+	syms := src.Symbols
+	if ts.startsStaff(src) {
+		syms = c.setKeySignature(dest, syms, ts)
+	}
+
+	for _, s := range syms {
+		err := c.addSymbolToMeasure(dest, s, ts)
 		if err != nil {
-			return err
+			diags.Add(c.handleSymbolError(dest, s, err))
+		}
+	}
+	ts.endMeasure()
+
+	return diags.Err()
+}
+
+// setKeySignature sets the accidentals at the start of a staff before the first
+// note as key signature of the staff, so they aren't merged into the first note.
+// Only the last accidental belongs to the note that follows it, if it has the pitch
+// of the note or is the only accidental, like in & sharplg LG_4. Accidentals that
+// repeat the key signature of a previous staff are always its key signature.
+// It returns the symbols without the key signature. Time signatures in front of the
+// accidentals are kept and the texts of the accidentals are added to the measure.
+func (c *Converter) setKeySignature(
+	dest *measure.Measure,
+	syms []*filestructure.MusicSymbol,
+	ts *tuneState,
+) []*filestructure.MusicSymbol {
+	start := 0
+	for start < len(syms) && c.mapper.IsTimeSignature(syms[start].Text) {
+		start++
+	}
+
+	var accs []*symbols.Note
+	end := start
+	for ; end < len(syms); end++ {
+		acc := c.accidentalOf(syms[end])
+		if acc == nil {
+			break
 		}
+		accs = append(accs, acc)
+	}
+
+	if end < len(syms) && c.accidentalBelongsToNote(accs, syms[end], ts.keySig) {
+		end--
+		accs = accs[:len(accs)-1]
+	}
+	if len(accs) == 0 {
+		return syms
 	}
 
+	ks := &common.KeySignature{Accidentals: accs}
+	for _, s := range syms[start:end] {
+		ks.Spans = append(ks.Spans, s.Span())
+		addSymbolTextsToMeasure(dest, s)
+		ts.addInlineTextStyles(len(s.InlineTexts), s.InlineTextStyles)
+	}
+	ts.setKeySignature(ks)
+
+	return append(slices.Clone(syms[:start]), syms[end:]...)
+}
+
+// accidentalBelongsToNote returns true if the last of the accidentals at the start
+// of a staff belongs to the next symbol instead of the key signature. That is the case
+// if the next symbol is a melody note with the pitch of the accidental or the accidental
+// is the only one, but not if the accidentals repeat the previous key signature prev.
+func (c *Converter) accidentalBelongsToNote(
+	accs []*symbols.Note,
+	next *filestructure.MusicSymbol,
+	prev *common.KeySignature,
+) bool {
+	if len(accs) == 0 || prev.HasAccidentals(accs) || next.IsTempoChange() {
+		return false
+	}
+
+	sym, err := c.mapper.SymbolForToken(next.Text)
+	if err != nil || !sym.IsValidNote() {
+		return false
+	}
+
+	return len(accs) == 1 || accs[len(accs)-1].Pitch == sym.Note.Pitch
+}
+
+// accidentalOf returns the accidental of a symbol that is only an accidental
+// like sharpf. It returns nil for all other symbols.
+func (c *Converter) accidentalOf(
+	s *filestructure.MusicSymbol,
+) *symbols.Note {
+	if s.IsTempoChange() {
+		return nil
+	}
+
+	sym, err := c.mapper.SymbolForToken(s.Text)
+	if err != nil || !sym.IsOnlyAccidental() {
+		return nil
+	}
+
+	return sym.Note
+}
+
+// addSymbolTextsToMeasure adds the inline texts and comments of a symbol
+// that isn't added to the measure to the measure itself.
+func addSymbolTextsToMeasure(
+	dest *measure.Measure,
+	s *filestructure.MusicSymbol,
+) {
+	addInlineTexts(dest, toStringSlice[filestructure.InlineText](s.InlineTexts))
+	addComments(dest, toStringSlice[filestructure.InlineComment](s.Comments))
+}
+
+// fillStructureMessages adds the messages of the problems that were repaired
+// in the file structure to the measure.
+func fillStructureMessages(
+	dest *measure.Measure,
+	src *filestructure.Measure,
+) {
+	for _, msg := range src.ParserMessages {
+		dest.ParserMessages = append(dest.ParserMessages, &measure.ParserMessage{
+			Symbol:   msg.Symbol,
+			Severity: measure.Severity_Warning,
+			Text:     msg.Text,
+		})
+	}
+}
+
+// handleSymbolError returns the error of a symbol that couldn't be added to the
+// measure. In lenient mode, the symbol is skipped and the error is added as
+// parser message to the measure instead.
+func (c *Converter) handleSymbolError(
+	dest *measure.Measure,
+	s *filestructure.MusicSymbol,
+	err error,
+) error {
+	if !c.mode.IsLenient() {
+		return err
+	}
+
+	dest.ParserMessages = append(dest.ParserMessages, &measure.ParserMessage{
+		Symbol:   s.Text,
+		Severity: measure.Severity_Error,
+		Text:     err.Error(),
+		Fix:      measure.Fix_SkipSymbol,
+	})
+
 	return nil
 }
 
-// addSymbolToMeasure adds a symbol to the measure. If the symbol is a time signature,
-// if it is a time signature symbol, it sets that to the measure.
-// If the symbol
+// addSymbolToMeasure adds a symbol to the measure, or sets it as the time
+// signature of the measure if it is a time signature symbol.
+// A tie in the old format is set at the surrounding notes.
 func (c *Converter) addSymbolToMeasure(
 	dest *measure.Measure,
 	s *filestructure.MusicSymbol,
+	ts *tuneState,
 ) error {
 	timeSigHandled, err := c.setPossibleTimeSignature(dest, s)
 	if err != nil {
@@ -125,7 +282,12 @@ func (c *Converter) addSymbolToMeasure(
 		return nil
 	}
 
-	sym, err := c.getConvertedSymbol(s)
+	if c.isOldTie(s.Text, ts.ties) {
+		ts.ties.addOldTie(dest, s.Text)
+		return nil
+	}
+
+	sym, err := c.convertOrCorrectSymbol(dest, s)
 	if errors.Is(err, common.ErrSymbolSkip) {
 		return nil
 	}
@@ -134,14 +296,43 @@ func (c *Converter) addSymbolToMeasure(
 		return err
 	}
 
+	merged := c.appendSymbol(dest, sym)
+	textStyles := alignedStyles(len(s.InlineTexts), s.InlineTextStyles)
+	ts.addSymbol(s.Span(), c.mapper.NoteFlagForToken(s.Text), textStyles, merged)
+	ts.ties.update(dest)
+
+	return nil
+}
+
+// appendSymbol merges the symbol into the last symbol of the measure
+// if they belong together, otherwise it appends it to the measure.
+// It returns true if the symbol was merged.
+func (c *Converter) appendSymbol(
+	dest *measure.Measure,
+	sym *symbols.Symbol,
+) bool {
 	prevSym := dest.LastSymbol()
 	if prevSym != nil && c.merger.MergeSymbols(prevSym, sym) {
-		return nil
+		return true
 	}
 
 	dest.Symbols = append(dest.Symbols, sym)
+	return false
+}
 
-	return nil
+// isOldTie returns true if the token is a tie in the old format. As the old
+// format tie of E is also the tie end of the new format, a token that has
+// a symbol is only a tie in the old format if there is no open tie to end.
+func (c *Converter) isOldTie(
+	token string,
+	ties *tieState,
+) bool {
+	if !c.mapper.IsOldTie(token) {
+		return false
+	}
+
+	_, err := c.mapper.SymbolForToken(token)
+	return err != nil || !ties.open
 }
 
 // setPossibleTimeSignature checks if the symbol is a time signature and sets it
@@ -156,12 +347,44 @@ func (c *Converter) setPossibleTimeSignature(
 
 	ts, err := c.mapper.TimeSigForToken(s.Text)
 	if err != nil {
-		return false, err
+		return false, diagnostics.At(err, s.Pos.Line, s.Pos.Column)
 	}
 	dest.Time = ts
 	return true, nil
 }
 
+// convertOrCorrectSymbol converts the symbol. If the symbol is unknown and the
+// parse mode applies suggestions, the symbol is converted with a confident
+// suggestion instead and a parser message about the replacement is added.
+func (c *Converter) convertOrCorrectSymbol(
+	dest *measure.Measure,
+	s *filestructure.MusicSymbol,
+) (*symbols.Symbol, error) {
+	sym, err := c.getConvertedSymbol(s)
+	var d *diagnostics.Diagnostic
+	if err == nil || !c.mode.AppliesSuggestions() ||
+		!errors.As(err, &d) || d.Replacement == "" {
+		return sym, err
+	}
+
+	corrected := *s
+	corrected.Text = d.Replacement
+	sym, cErr := c.getConvertedSymbol(&corrected)
+	if cErr != nil {
+		return nil, err
+	}
+
+	msg := fmt.Sprintf("%s, replaced with %s", d.Error(), d.Replacement)
+	log.Warn().Msg(msg)
+	dest.ParserMessages = append(dest.ParserMessages, &measure.ParserMessage{
+		Symbol:   s.Text,
+		Severity: measure.Severity_Warning,
+		Text:     msg,
+	})
+
+	return sym, nil
+}
+
 func (c *Converter) getConvertedSymbol(
 	s *filestructure.MusicSymbol,
 ) (*symbols.Symbol, error) {
@@ -197,34 +420,102 @@ func fillInlineTextAndComments(
 func (c *Converter) setMeasureBarlines(
 	dest *measure.Measure,
 	src *filestructure.Measure,
-) {
+) error {
 	if src.LeftBarline != "" {
 		bl, err := c.mapper.BarlineForToken(string(src.LeftBarline))
-		// there should be only common.ErrSymbolNotFound possible
-		if errors.Is(err, common.ErrSymbolNotFound) {
-			log.Fatal().Msgf(
-				"barline %s not found",
-				src.LeftBarline,
-			)
+		if err != nil {
+			return err
 		}
 
 		dest.LeftBarline = bl
 	}
 
 	if src.RightBarline == "" {
-		return
+		return nil
 	}
 
 	bl, err := c.mapper.BarlineForToken(string(src.RightBarline))
-	// there should be only common.ErrSymbolNotFound possible
-	if errors.Is(err, common.ErrSymbolNotFound) {
-		log.Fatal().Msgf(
-			"barline %s not found",
-			src.RightBarline,
-		)
+	if err != nil {
+		return err
 	}
 
 	dest.RightBarline = bl
+
+	return nil
+}
+
+// setMeasureNavigation sets the navigation marks of the measure as time of
+// its barlines, a segno at the left and all others at the right barline.
+func (c *Converter) setMeasureNavigation(
+	dest *measure.Measure,
+	src *filestructure.Measure,
+	ts *tuneState,
+) error {
+	if src.LeftNavigation != "" {
+		bl, err := c.barlineWithNavigation(dest, dest.LeftBarline, src.LeftNavigation)
+		if err != nil {
+			return err
+		}
+		if dest.LeftBarline.GetTime() == barline.Time_Repeat {
+			ts.setReplacedRepeat(common.LeftBarline)
+		}
+		dest.LeftBarline = bl
+	}
+
+	if src.RightNavigation == "" {
+		return nil
+	}
+
+	bl, err := c.barlineWithNavigation(dest, dest.RightBarline, src.RightNavigation)
+	if err != nil {
+		return err
+	}
+	if dest.RightBarline.GetTime() == barline.Time_Repeat {
+		ts.setReplacedRepeat(common.RightBarline)
+	}
+	dest.RightBarline = bl
+
+	return nil
+}
+
+// barlineWithNavigation returns a new barline of the type of the given barline
+// with the navigation as time. As a barline has only one time, the music model
+// can't hold a repeat of the given barline together with the navigation. This is
+// an error in strict mode. In lenient mode, the navigation replaces the repeat
+// with a parser message and the caller keeps the repeat in the tune source,
+// so that the export can write both again.
+func (c *Converter) barlineWithNavigation(
+	dest *measure.Measure,
+	bl *barline.Barline,
+	nav filestructure.Navigation,
+) (*barline.Barline, error) {
+	t, err := c.mapper.NavigationForToken(string(nav))
+	if err != nil {
+		return nil, err
+	}
+
+	if bl.GetTime() == barline.Time_Repeat {
+		if !c.mode.IsLenient() {
+			return nil, &diagnostics.Diagnostic{
+				Code:     diagnostics.CodeNavigationOnRepeat,
+				Severity: measure.Severity_Error,
+				Message:  fmt.Sprintf("%s can't be kept together with the repeat of the barline", nav),
+				Text:     string(nav),
+				Fix:      "parse the file in lenient mode to keep the repeat in the tune source",
+			}
+		}
+
+		dest.ParserMessages = append(dest.ParserMessages, &measure.ParserMessage{
+			Symbol:   string(nav),
+			Severity: measure.Severity_Warning,
+			Text:     fmt.Sprintf("%s replaces the repeat of the barline", nav),
+		})
+	}
+
+	return &barline.Barline{
+		Type: bl.GetType(),
+		Time: t,
+	}, nil
 }
 
 type MeasureText interface {
@@ -290,20 +581,17 @@ func (c *Converter) convertSymbol(
 	}
 
 	if ms.Text == "" {
-		return nil, fmt.Errorf("empty symbol text for %+v", ms)
+		d := &diagnostics.Diagnostic{
+			Code:     diagnostics.CodeEmptySymbol,
+			Severity: measure.Severity_Error,
+			Message:  "empty symbol text",
+		}
+		return nil, d.At(ms.Pos.Line, ms.Pos.Column)
 	}
 
 	sym, err := c.mapper.SymbolForToken(ms.Text)
-	if errors.Is(err, common.ErrSymbolNotFound) {
-		return nil, fmt.Errorf(
-			"symbol %s not found: line %d, column %d",
-			ms.Text,
-			ms.Pos.Line,
-			ms.Pos.Column,
-		)
-	}
 	if err != nil {
-		return nil, err
+		return nil, diagnostics.At(err, ms.Pos.Line, ms.Pos.Column)
 	}
 
 	return sym, nil
@@ -312,9 +600,11 @@ func (c *Converter) convertSymbol(
 func NewConverter(
 	mapper interfaces.SymbolMapper,
 	merger interfaces.SymbolMerger,
+	mode common.ParseMode,
 ) *Converter {
 	return &Converter{
 		mapper: mapper,
 		merger: merger,
+		mode:   mode,
 	}
 }
@@ -0,0 +1,307 @@
+package bww_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/length"
+	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/measure"
+	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/pitch"
+	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/symbols"
+	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/symbols/accidental"
+	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/tune"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/bww"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/common"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/diagnostics"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/filestructure"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/interfaces/mocks"
+)
+
+var _ = Describe("Converter", func() {
+	var mapper *mocks.SymbolMapper
+	var merger *mocks.SymbolMerger
+	var conv *bww.Converter
+	var fst *filestructure.Tune
+	var t *tune.Tune
+	var src *common.TuneSource
+	var err error
+
+	BeforeEach(func() {
+		mapper = mocks.NewSymbolMapper(GinkgoT())
+		merger = mocks.NewSymbolMerger(GinkgoT())
+		conv = bww.NewConverter(mapper, merger, common.StrictParsing)
+		fst = &filestructure.Tune{
+			Header: &filestructure.TuneHeader{Title: "Tune"},
+			Measures: []*filestructure.Measure{
+				{LeftBarline: "I!x"},
+			},
+		}
+	})
+
+	JustBeforeEach(func() {
+		t, src, err = conv.Convert(fst)
+	})
+
+	When("having a barline that isn't in the tables", func() {
+		BeforeEach(func() {
+			mapper.EXPECT().BarlineForToken("I!x").Return(nil, &diagnostics.Diagnostic{
+				Code:     diagnostics.CodeUnknownBarline,
+				Severity: measure.Severity_Error,
+				Message:  "barline I!x not found",
+				Text:     "I!x",
+				Err:      common.ErrSymbolNotFound,
+			})
+		})
+
+		It("should return an error", func() {
+			Expect(t).Should(BeNil())
+			Expect(src).Should(BeNil())
+			Expect(err).Should(MatchError(common.ErrSymbolNotFound))
+			Expect(diagnostics.FromError(err)[0].Code).Should(Equal(diagnostics.CodeUnknownBarline))
+		})
+	})
+
+	When("having a symbol that is merged into the previous symbol", func() {
+		BeforeEach(func() {
+			fst.Measures = []*filestructure.Measure{
+				{
+					Symbols: []*filestructure.MusicSymbol{
+						{Pos: filestructure.Position{Line: 4, Column: 2}, Text: "gg"},
+						{Pos: filestructure.Position{Line: 4, Column: 5}, Text: "LA_4"},
+						{Pos: filestructure.Position{Line: 4, Column: 10}, Text: "B_4"},
+					},
+					Span: filestructure.Span{
+						Start: filestructure.Position{Line: 4, Column: 2},
+						End:   filestructure.Position{Line: 4, Column: 15},
+					},
+				},
+			}
+			mapper.EXPECT().IsTimeSignature(mock.Anything).Return(false)
+			mapper.EXPECT().IsOldTie(mock.Anything).Return(false)
+			mapper.EXPECT().SymbolForToken(mock.Anything).Return(&symbols.Symbol{}, nil)
+			mapper.EXPECT().NoteFlagForToken(mock.Anything).Return(common.NoFlag)
+			merger.EXPECT().MergeSymbols(mock.Anything, mock.Anything).Return(true).Once()
+			merger.EXPECT().MergeSymbols(mock.Anything, mock.Anything).Return(false).Once()
+		})
+
+		It("should return the spans of the measure and the symbols", func() {
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(t.Measures[0].Symbols).Should(HaveLen(2))
+			Expect(src).Should(Equal(&common.TuneSource{
+				Measures: []*common.MeasureSource{
+					{
+						Span: filestructure.Span{
+							Start: filestructure.Position{Line: 4, Column: 2},
+							End:   filestructure.Position{Line: 4, Column: 15},
+						},
+						Symbols: []filestructure.Span{
+							{
+								Start: filestructure.Position{Line: 4, Column: 2},
+								End:   filestructure.Position{Line: 4, Column: 9},
+							},
+							{
+								Start: filestructure.Position{Line: 4, Column: 10},
+								End:   filestructure.Position{Line: 4, Column: 13},
+							},
+						},
+						Flags:            []common.NoteFlag{common.NoFlag, common.NoFlag},
+						Beams:            []common.Beam{common.NoBeam, common.NoBeam},
+						SymbolTextStyles: [][]*filestructure.TextStyle{nil, nil},
+					},
+				},
+			}))
+		})
+	})
+
+	When("having texts with styles", func() {
+		var titleStyle *filestructure.TextStyle
+		var inlineStyle *filestructure.TextStyle
+
+		BeforeEach(func() {
+			titleStyle = &filestructure.TextStyle{Alignment: filestructure.AlignLeft, FontFace: "Arial"}
+			inlineStyle = &filestructure.TextStyle{Alignment: filestructure.AlignRight, FontFace: "Courier New"}
+			fst.Header = &filestructure.TuneHeader{
+				Title:      "Tune",
+				TitleStyle: titleStyle,
+				Footer:     []filestructure.TuneFooter{"footer 1", "footer 2"},
+				FooterStyles: []*filestructure.TextStyle{
+					inlineStyle,
+				},
+			}
+			fst.Measures = []*filestructure.Measure{
+				{
+					InlineTexts:           []filestructure.InlineText{"measure"},
+					InlineTextStyles:      []*filestructure.TextStyle{inlineStyle},
+					StaffInlineTexts:      []filestructure.StaffInline{"staff"},
+					StaffInlineTextStyles: []*filestructure.TextStyle{titleStyle},
+					Symbols: []*filestructure.MusicSymbol{
+						{
+							Text:             "LA_4",
+							InlineTexts:      []filestructure.InlineText{"symbol"},
+							InlineTextStyles: []*filestructure.TextStyle{inlineStyle},
+						},
+					},
+				},
+			}
+			mapper.EXPECT().IsTimeSignature(mock.Anything).Return(false)
+			mapper.EXPECT().IsOldTie(mock.Anything).Return(false)
+			mapper.EXPECT().SymbolForToken(mock.Anything).Return(&symbols.Symbol{}, nil)
+			mapper.EXPECT().NoteFlagForToken(mock.Anything).Return(common.NoFlag)
+		})
+
+		It("should keep the styles of the texts in the source", func() {
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(src.Header).Should(Equal(common.HeaderStyles{
+				Title:  titleStyle,
+				Footer: []*filestructure.TextStyle{inlineStyle, nil},
+			}))
+			Expect(t.Measures[0].InlineTexts).Should(Equal([]string{"measure", "staff"}))
+			Expect(src.Measures[0].InlineTextStyles).Should(Equal(
+				[]*filestructure.TextStyle{inlineStyle, titleStyle}))
+			Expect(src.Measures[0].SymbolTextStyles).Should(Equal(
+				[][]*filestructure.TextStyle{{inlineStyle}}))
+		})
+	})
+
+	When("having melody notes with beam flags", func() {
+		BeforeEach(func() {
+			fst.Measures = []*filestructure.Measure{
+				{
+					Symbols: []*filestructure.MusicSymbol{
+						{Text: "gg"},
+						{Text: "LAr_8"},
+						{Text: "Br_8"},
+						{Text: "Cl_8"},
+						{Text: "E_4"},
+					},
+				},
+			}
+			mapper.EXPECT().IsTimeSignature(mock.Anything).Return(false)
+			mapper.EXPECT().IsOldTie(mock.Anything).Return(false)
+			mapper.EXPECT().SymbolForToken(mock.Anything).Return(&symbols.Symbol{}, nil)
+			mapper.EXPECT().NoteFlagForToken("gg").Return(common.NoFlag)
+			mapper.EXPECT().NoteFlagForToken("LAr_8").Return(common.FlagRight)
+			mapper.EXPECT().NoteFlagForToken("Br_8").Return(common.FlagRight)
+			mapper.EXPECT().NoteFlagForToken("Cl_8").Return(common.FlagLeft)
+			mapper.EXPECT().NoteFlagForToken("E_4").Return(common.NoFlag)
+			merger.EXPECT().MergeSymbols(mock.Anything, mock.Anything).Return(true).Once()
+			merger.EXPECT().MergeSymbols(mock.Anything, mock.Anything).Return(false)
+		})
+
+		It("should keep the flags and derive the beam groups of the measure", func() {
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(t.Measures[0].Symbols).Should(HaveLen(4))
+			Expect(src.Measures[0].Flags).Should(Equal([]common.NoteFlag{
+				common.FlagRight, common.FlagRight, common.FlagLeft, common.NoFlag,
+			}))
+			Expect(src.Measures[0].Beams).Should(Equal([]common.Beam{
+				common.BeamStart, common.BeamContinue, common.BeamEnd, common.NoBeam,
+			}))
+			Expect(src.Beam(0, 1)).Should(Equal(common.BeamContinue))
+			Expect(src.NoteFlag(0, 0)).Should(Equal(common.FlagRight))
+			Expect(src.NoteFlag(0, 4)).Should(Equal(common.NoFlag))
+		})
+	})
+
+	When("having measures in two staves", func() {
+		BeforeEach(func() {
+			fst.Measures = []*filestructure.Measure{{}, {}, {}}
+			fst.Staves = []*filestructure.Staff{
+				{Measures: fst.Measures[:2]},
+				{Measures: fst.Measures[2:]},
+			}
+		})
+
+		It("should mark the last measure of each staff with a line break", func() {
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(t.Measures).Should(HaveLen(3))
+			Expect(src.LineBreak(0)).Should(BeFalse())
+			Expect(src.LineBreak(1)).Should(BeTrue())
+			Expect(src.LineBreak(2)).Should(BeTrue())
+		})
+	})
+
+	When("having accidentals at the start of a staff that are followed by a barline", func() {
+		BeforeEach(func() {
+			fst.Measures = []*filestructure.Measure{
+				{
+					Symbols: []*filestructure.MusicSymbol{
+						{Pos: filestructure.Position{Line: 4, Column: 2}, Text: "sharpf"},
+						{
+							Pos:      filestructure.Position{Line: 4, Column: 9},
+							Text:     "sharpc",
+							Comments: []filestructure.InlineComment{"key"},
+						},
+					},
+				},
+				{
+					Symbols: []*filestructure.MusicSymbol{
+						{Pos: filestructure.Position{Line: 4, Column: 18}, Text: "LA_4"},
+					},
+				},
+			}
+			fst.Staves = []*filestructure.Staff{{Measures: fst.Measures}}
+			mapper.EXPECT().IsTimeSignature(mock.Anything).Return(false)
+			mapper.EXPECT().IsOldTie(mock.Anything).Return(false)
+			mapper.EXPECT().SymbolForToken("sharpf").Return(&symbols.Symbol{
+				Note: &symbols.Note{Pitch: pitch.Pitch_F, Accidental: accidental.Accidental_Sharp},
+			}, nil)
+			mapper.EXPECT().SymbolForToken("sharpc").Return(&symbols.Symbol{
+				Note: &symbols.Note{Pitch: pitch.Pitch_C, Accidental: accidental.Accidental_Sharp},
+			}, nil)
+			mapper.EXPECT().SymbolForToken("LA_4").Return(&symbols.Symbol{
+				Note: &symbols.Note{Pitch: pitch.Pitch_LowA, Length: length.Length_Quarter},
+			}, nil)
+			mapper.EXPECT().NoteFlagForToken("LA_4").Return(common.NoFlag)
+		})
+
+		It("should set them as key signature instead of adding them to the first note", func() {
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(t.Measures[0].Symbols).Should(BeEmpty())
+			Expect(t.Measures[0].Comments).Should(Equal([]string{"key"}))
+			Expect(t.Measures[1].Symbols).Should(HaveLen(1))
+			Expect(t.Measures[1].Symbols[0].Note.Accidental).Should(Equal(accidental.Accidental_NoAccidental))
+
+			ks := src.KeySignature(0)
+			Expect(ks).ShouldNot(BeNil())
+			Expect(ks.Accidentals).Should(HaveLen(2))
+			Expect(ks.Accidentals[0].Pitch).Should(Equal(pitch.Pitch_F))
+			Expect(ks.Accidentals[1].Pitch).Should(Equal(pitch.Pitch_C))
+			Expect(ks.Spans[1]).Should(Equal(filestructure.Span{
+				Start: filestructure.Position{Line: 4, Column: 9},
+				End:   filestructure.Position{Line: 4, Column: 15},
+			}))
+			Expect(src.Measures[0].Symbols).Should(BeEmpty())
+		})
+	})
+
+	When("having an accidental at the start of a staff that is followed by a melody note", func() {
+		BeforeEach(func() {
+			fst.Measures = []*filestructure.Measure{
+				{
+					Symbols: []*filestructure.MusicSymbol{
+						{Pos: filestructure.Position{Line: 4, Column: 2}, Text: "sharpla"},
+						{Pos: filestructure.Position{Line: 4, Column: 10}, Text: "LG_1"},
+					},
+				},
+			}
+			fst.Staves = []*filestructure.Staff{{Measures: fst.Measures}}
+			mapper.EXPECT().IsTimeSignature(mock.Anything).Return(false)
+			mapper.EXPECT().IsOldTie(mock.Anything).Return(false)
+			mapper.EXPECT().SymbolForToken("sharpla").Return(&symbols.Symbol{
+				Note: &symbols.Note{Pitch: pitch.Pitch_LowG, Accidental: accidental.Accidental_Sharp},
+			}, nil)
+			mapper.EXPECT().SymbolForToken("LG_1").Return(&symbols.Symbol{
+				Note: &symbols.Note{Pitch: pitch.Pitch_LowG, Length: length.Length_Whole},
+			}, nil)
+			mapper.EXPECT().NoteFlagForToken(mock.Anything).Return(common.NoFlag)
+			merger.EXPECT().MergeSymbols(mock.Anything, mock.Anything).Return(true).Once()
+		})
+
+		It("should keep the accidental for the note instead of setting a key signature", func() {
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(src.KeySignature(0)).Should(BeNil())
+			Expect(src.Measures[0].Symbols).Should(HaveLen(1))
+		})
+	})
+})
@@ -0,0 +1,597 @@
+// Package exporter writes music model tunes into the Bagpipe Music Writer Gold
+// file format.
+package exporter
+
+import (
+	"fmt"
+	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/barline"
+	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/measure"
+	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/symbols"
+	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/tune"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/common"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/filestructure"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/interfaces"
+	"strings"
+)
+
+// measuresPerStaff is the maximum number of measures written into one staff.
+// A staff may end earlier if a measure has a right barline.
+const measuresPerStaff = 4
+
+// fileHeader is the Bagpipe Player version and the meta data blocks that are
+// written for tunes without a parsed file, with the defaults of Bagpipe Music Writer Gold.
+const fileHeader = `Bagpipe Music Writer Gold:1.0
+MIDINoteMappings,(54,56,58,59,61,63,64,66,68,56,58,60,61,63,65,66,68,70,55,57,59,60,62,64,65,67,69)
+FrequencyMappings,(370,415,466,494,554,622,659,740,831,415,466,523,554,622,699,740,831,932,392,440,494,523,587,659,699,784,880)
+InstrumentMappings,(71,71,45,33,1000,60,70)
+GracenoteDurations,(20,40,30,50,100,200,800,1200,250,250,250,500,200)
+FontSizes,(90,100,100,80,250)
+TuneFormat,(1,0,M,L,500,500,500,500,P,0,0)
+`
+
+// The templates of the text fields are used for texts without a style.
+const (
+	titleTemplate    = "\"%s\",(T,L,0,0,Times New Roman,15,700,0,1,2,0,0,32768)"
+	typeTemplate     = "\"%s\",(Y,C,0,0,Times New Roman,11,400,0,0,18,0,0,0)"
+	composerTemplate = "\"%s\",(M,R,0,0,Times New Roman,11,400,0,0,18,0,0,0)"
+	footerTemplate   = "\"%s\",(F,L,0,0,Times New Roman,10,400,0,0,18,0,0,0)"
+	inlineTemplate   = "\"%s\",(I,L,0,0,Times New Roman,11,400,0,0,18,0,0,0)"
+	commentTemplate  = "\"%s\""
+	styledTemplate   = "\"%s\",(%s,%s)"
+)
+
+// textField is a text field of the bww format with its field type and the
+// template that is used if the text has no style.
+type textField struct {
+	fieldType string
+	template  string
+}
+
+var (
+	titleField    = textField{fieldType: "T", template: titleTemplate}
+	typeField     = textField{fieldType: "Y", template: typeTemplate}
+	composerField = textField{fieldType: "M", template: composerTemplate}
+	footerField   = textField{fieldType: "F", template: footerTemplate}
+	inlineField   = textField{fieldType: "I", template: inlineTemplate}
+)
+
+// format returns the text field with the text and the style. If the style is
+// nil, the template of the field is used.
+func (f textField) format(
+	text string,
+	style *filestructure.TextStyle,
+) string {
+	if style == nil {
+		return fmt.Sprintf(f.template, quotedText(text))
+	}
+
+	return fmt.Sprintf(styledTemplate, quotedText(text), f.fieldType, style.String())
+}
+
+const (
+	staffStart = "&"
+	staffEnd   = "!t"
+)
+
+type Exporter struct {
+	mapper interfaces.SymbolMapper
+}
+
+func (e *Exporter) ExportBwwData(
+	tunes []*tune.Tune,
+) ([]byte, error) {
+	return e.ExportBwwDataWithSources(tunes, nil)
+}
+
+// ExportBwwDataWithSources exports the tunes and writes their staves with the
+// line breaks of the sources of the tunes with the same index, so that a parsed
+// file keeps its staves. Tunes without sources or line breaks are split into
+// staves of measuresPerStaff measures.
+func (e *Exporter) ExportBwwDataWithSources(
+	tunes []*tune.Tune,
+	sources []*common.TuneSource,
+) ([]byte, error) {
+	return e.exportTunes(tunes, sources)
+}
+
+func (e *Exporter) exportTunes(
+	tunes []*tune.Tune,
+	sources []*common.TuneSource,
+) ([]byte, error) {
+	if len(tunes) == 0 {
+		return nil, fmt.Errorf("no tunes to export")
+	}
+	if sources != nil && len(sources) != len(tunes) {
+		return nil, fmt.Errorf("got %d tune sources for %d tunes", len(sources), len(tunes))
+	}
+
+	sb := &strings.Builder{}
+	sb.WriteString(fileHeader)
+
+	for i, t := range tunes {
+		if t == nil {
+			return nil, fmt.Errorf("tune %d is nil", i)
+		}
+
+		src := sourceOfTune(sources, i)
+		sb.WriteString("\n")
+		writeTuneHeader(sb, t, headerStylesOf(src))
+
+		err := e.writeStaves(sb, t.Measures, src)
+		if err != nil {
+			return nil, fmt.Errorf("failed exporting tune %d (%s): %w", i, t.Title, err)
+		}
+	}
+
+	return []byte(sb.String()), nil
+}
+
+func sourceOfTune(
+	sources []*common.TuneSource,
+	tuneIdx int,
+) *common.TuneSource {
+	if tuneIdx >= len(sources) {
+		return nil
+	}
+
+	return sources[tuneIdx]
+}
+
+// headerStylesOf returns the text styles of the tune header of the source.
+// Without a source, all fields have no style.
+func headerStylesOf(src *common.TuneSource) common.HeaderStyles {
+	if src == nil {
+		return common.HeaderStyles{}
+	}
+
+	return src.Header
+}
+
+// writeTuneHeader writes the tune fields that precede the first staff.
+// Comments and inline texts are written before type and composer, so they
+// aren't mistaken as staff comments of the first staff when read again.
+// Type and composer are therefore always written, even if they are empty.
+func writeTuneHeader(
+	sb *strings.Builder,
+	t *tune.Tune,
+	styles common.HeaderStyles,
+) {
+	if t.Title != "" {
+		writeHeaderLine(sb, titleField.format(t.Title, styles.Title))
+	}
+	for _, c := range t.Comments {
+		writeHeaderLine(sb, fmt.Sprintf(commentTemplate, quotedText(c)))
+	}
+	for i, it := range t.InlineTexts {
+		writeHeaderLine(sb, inlineField.format(it, styleAt(styles.InlineTexts, i)))
+	}
+	writeHeaderLine(sb, typeField.format(t.Type, styles.Type))
+	writeHeaderLine(sb, composerField.format(composerWithArranger(t), styles.Composer))
+	for i, f := range t.Footer {
+		writeHeaderLine(sb, footerField.format(f, styleAt(styles.Footer, i)))
+	}
+
+	if t.Tempo > 0 {
+		sb.WriteString(fmt.Sprintf("TuneTempo,%d\n", t.Tempo))
+	}
+
+	sb.WriteString("\n")
+}
+
+func writeHeaderLine(
+	sb *strings.Builder,
+	line string,
+) {
+	sb.WriteString(line)
+	sb.WriteString("\n")
+}
+
+// styleAt returns the style with the index or nil if there is none.
+func styleAt(
+	styles []*filestructure.TextStyle,
+	idx int,
+) *filestructure.TextStyle {
+	if idx >= len(styles) {
+		return nil
+	}
+
+	return styles[idx]
+}
+
+// composerWithArranger returns the composer field with the arranger appended
+// as the bww format doesn't have a separate arranger field.
+func composerWithArranger(t *tune.Tune) string {
+	if t.Arranger == "" {
+		return t.Composer
+	}
+
+	if t.Composer == "" {
+		return fmt.Sprintf("arr. %s", t.Arranger)
+	}
+
+	return fmt.Sprintf("%s, arr. %s", t.Composer, t.Arranger)
+}
+
+// quotedText returns the text so that it can be placed between double quotes.
+// The bww format has no escaping for double quotes so they are replaced by single quotes.
+func quotedText(text string) string {
+	text = strings.ReplaceAll(text, "\"", "'")
+	return strings.ReplaceAll(text, "\n", " ")
+}
+
+// writeStaves writes the measures into staves. If the tune has a source, the staves
+// end at its line breaks and start with its key signatures, and the melody notes
+// get their beam flags.
+func (e *Exporter) writeStaves(
+	sb *strings.Builder,
+	measures []*measure.Measure,
+	src *common.TuneSource,
+) error {
+	measureIdx := 0
+	for _, staff := range splitIntoStaves(measures, newStaffBreaker(src)) {
+		line, err := e.staffLine(staff, src, measureIdx)
+		if err != nil {
+			return err
+		}
+
+		sb.WriteString(line)
+		sb.WriteString("\n")
+		measureIdx += len(staff)
+	}
+
+	return nil
+}
+
+// keySignatureOf returns the key signature of the staff that starts with the
+// measure with the index or nil if there is none.
+func keySignatureOf(
+	src *common.TuneSource,
+	measureIdx int,
+) *common.KeySignature {
+	if src == nil {
+		return nil
+	}
+
+	return src.KeySignature(measureIdx)
+}
+
+// staffBreaker returns true if the staff ends after the measure with the index,
+// where staffLen is the number of measures of the staff up to this measure.
+type staffBreaker func(measureIdx int, staffLen int) bool
+
+// newStaffBreaker returns a staffBreaker that ends the staves at the line breaks
+// of the source. If the source has no line breaks, a staff ends after
+// measuresPerStaff measures.
+func newStaffBreaker(src *common.TuneSource) staffBreaker {
+	if !hasLineBreaks(src) {
+		return func(_ int, staffLen int) bool {
+			return staffLen == measuresPerStaff
+		}
+	}
+
+	return func(measureIdx int, _ int) bool {
+		return src.LineBreak(measureIdx)
+	}
+}
+
+func hasLineBreaks(src *common.TuneSource) bool {
+	if src == nil {
+		return false
+	}
+
+	for _, ms := range src.Measures {
+		if ms.LineBreak {
+			return true
+		}
+	}
+
+	return false
+}
+
+// splitIntoStaves splits the measures into groups where each group is written
+// into a single staff. A staff also ends at a measure with a right barline,
+// as right barlines are only written as staff ends.
+func splitIntoStaves(
+	measures []*measure.Measure,
+	breaker staffBreaker,
+) [][]*measure.Measure {
+	var staves [][]*measure.Measure
+	var curr []*measure.Measure
+
+	for i, m := range measures {
+		curr = append(curr, m)
+		if hasRightBarline(m) || breaker(i, len(curr)) {
+			staves = append(staves, curr)
+			curr = nil
+		}
+	}
+
+	if len(curr) > 0 {
+		staves = append(staves, curr)
+	}
+
+	return staves
+}
+
+func hasRightBarline(m *measure.Measure) bool {
+	return m.RightBarline != nil && !isRegularBarline(m.RightBarline)
+}
+
+func isRegularBarline(bl *barline.Barline) bool {
+	return bl == nil ||
+		(bl.Type == barline.Type_Regular && bl.Time == barline.Time_NoTime)
+}
+
+// staffLine returns the line of a staff whose first measure has the index
+// firstIdx in the tune.
+func (e *Exporter) staffLine(
+	staff []*measure.Measure,
+	src *common.TuneSource,
+	firstIdx int,
+) (string, error) {
+	toks := []string{staffStart}
+
+	ksToks, err := e.keySignatureTokens(keySignatureOf(src, firstIdx))
+	if err != nil {
+		return "", err
+	}
+	toks = append(toks, ksToks...)
+
+	for i, m := range staff {
+		mToks, err := e.measureTokens(m, i == 0, measureSourceOf(src, firstIdx+i))
+		if err != nil {
+			return "", err
+		}
+		toks = append(toks, mToks...)
+	}
+
+	end, err := e.staffEndTokens(
+		staff[len(staff)-1].RightBarline,
+		measureSourceOf(src, firstIdx+len(staff)-1),
+	)
+	if err != nil {
+		return "", err
+	}
+	toks = append(toks, end...)
+
+	return strings.Join(toks, " "), nil
+}
+
+// keySignatureTokens returns the accidental tokens of the key signature
+// that are written right after the staff start.
+func (e *Exporter) keySignatureTokens(
+	ks *common.KeySignature,
+) ([]string, error) {
+	if ks == nil {
+		return nil, nil
+	}
+
+	var toks []string
+	for _, acc := range ks.Accidentals {
+		accToks, err := e.mapper.TokensForSymbol(&symbols.Symbol{Note: acc}, common.NoFlag)
+		if err != nil {
+			return nil, fmt.Errorf("key signature can't be exported: %w", err)
+		}
+		toks = append(toks, accToks...)
+	}
+
+	return toks, nil
+}
+
+// measureSourceOf returns the source of the measure with the index or nil
+// if there is none.
+func measureSourceOf(
+	src *common.TuneSource,
+	measureIdx int,
+) *common.MeasureSource {
+	if src == nil || measureIdx >= len(src.Measures) {
+		return nil
+	}
+
+	return src.Measures[measureIdx]
+}
+
+// measureTokens returns all tokens of a measure except the right barline, which
+// is only written as the staff end. The melody notes get the beam flags and the
+// inline texts the styles of the measure source, if there is one.
+func (e *Exporter) measureTokens(
+	m *measure.Measure,
+	staffStart bool,
+	ms *common.MeasureSource,
+) ([]string, error) {
+	var flags []common.NoteFlag
+	var symbolStyles [][]*filestructure.TextStyle
+	var textStyles []*filestructure.TextStyle
+	leftRepeat := false
+	if ms != nil {
+		flags = ms.Flags
+		symbolStyles = ms.SymbolTextStyles
+		textStyles = ms.InlineTextStyles
+		leftRepeat = ms.LeftRepeat
+	}
+
+	toks, err := e.measureStartTokens(m, staffStart, leftRepeat, textStyles)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, sym := range m.Symbols {
+		flag := common.NoFlag
+		if i < len(flags) {
+			flag = flags[i]
+		}
+		var styles []*filestructure.TextStyle
+		if i < len(symbolStyles) {
+			styles = symbolStyles[i]
+		}
+
+		symToks, err := e.symbolTokens(sym, flag, styles)
+		if err != nil {
+			return nil, err
+		}
+		toks = append(toks, symToks...)
+	}
+
+	return toks, nil
+}
+
+// measureStartTokens returns the left barline, texts and time signature tokens
+// of a measure. A regular left barline is omitted at the start of a staff.
+// The inline texts get the styles with the same index.
+func (e *Exporter) measureStartTokens(
+	m *measure.Measure,
+	staffStart bool,
+	leftRepeat bool,
+	textStyles []*filestructure.TextStyle,
+) ([]string, error) {
+	toks, err := e.leftBarlineTokens(m.LeftBarline, staffStart, leftRepeat)
+	if err != nil {
+		return nil, err
+	}
+
+	// texts have to be placed before the time signature, otherwise they
+	// would be attached to it as symbol texts
+	toks = append(toks, textTokens(m.Comments, m.InlineTexts, textStyles)...)
+
+	if m.Time != nil {
+		ts, err := e.mapper.TokenForTimeSignature(m.Time)
+		if err != nil {
+			return nil, fmt.Errorf("time signature can't be exported: %w", err)
+		}
+		toks = append(toks, ts)
+	}
+
+	return toks, nil
+}
+
+// leftBarlineTokens returns the tokens of the left barline of a measure.
+// A regular left barline is omitted at the start of a staff, a segno is
+// written after the barline. If repeat is true, the barline is written as
+// repeat, which its navigation mark replaced in the measure.
+func (e *Exporter) leftBarlineTokens(
+	bl *barline.Barline,
+	staffStart bool,
+	repeat bool,
+) ([]string, error) {
+	base, nav, err := e.splitNavigation(bl, repeat)
+	if err != nil {
+		return nil, fmt.Errorf("left barline can't be exported: %w", err)
+	}
+
+	var toks []string
+	if !staffStart || !isRegularBarline(base) {
+		tok, err := e.mapper.TokenForBarline(base, common.LeftBarline)
+		if err != nil {
+			return nil, fmt.Errorf("left barline can't be exported: %w", err)
+		}
+		toks = append(toks, tok)
+	}
+
+	if nav != "" {
+		toks = append(toks, nav)
+	}
+
+	return toks, nil
+}
+
+// textTokens returns the tokens for comments and inline texts inside of a staff.
+// The inline texts get the styles with the same index.
+func textTokens(
+	comments []string,
+	inlineTexts []string,
+	styles []*filestructure.TextStyle,
+) []string {
+	var toks []string
+	for _, c := range comments {
+		toks = append(toks, fmt.Sprintf(commentTemplate, quotedText(c)))
+	}
+	for i, it := range inlineTexts {
+		toks = append(toks, inlineField.format(it, styleAt(styles, i)))
+	}
+
+	return toks
+}
+
+// staffEndTokens returns the tokens of the right barline of the last measure
+// in a staff. A fine is written before the staff end, a dalsegno or
+// dacapoalfine after it. The barline is written as repeat if the measure
+// source ms says that its navigation mark replaced a repeat.
+func (e *Exporter) staffEndTokens(
+	bl *barline.Barline,
+	ms *common.MeasureSource,
+) ([]string, error) {
+	base, nav, err := e.splitNavigation(bl, ms != nil && ms.RightRepeat)
+	if err != nil {
+		return nil, fmt.Errorf("right barline can't be exported: %w", err)
+	}
+
+	end, err := e.staffEndToken(base)
+	if err != nil {
+		return nil, err
+	}
+
+	if nav == "" {
+		return []string{end}, nil
+	}
+
+	if bl.Time == barline.Time_Fine {
+		return []string{nav, end}, nil
+	}
+
+	return []string{end, nav}, nil
+}
+
+// staffEndToken returns the token of the right barline of the last measure
+// in a staff. A regular barline is written as staff end.
+func (e *Exporter) staffEndToken(bl *barline.Barline) (string, error) {
+	if isRegularBarline(bl) {
+		return staffEnd, nil
+	}
+
+	tok, err := e.mapper.TokenForBarline(bl, common.RightBarline)
+	if err != nil {
+		return "", fmt.Errorf("right barline can't be exported: %w", err)
+	}
+
+	return tok, nil
+}
+
+// splitNavigation returns the barline without its navigation mark and the
+// token of the navigation mark. If the barline has no navigation mark, the
+// barline is returned unchanged with an empty token. The returned barline is
+// a repeat if the navigation mark replaced the repeat of the barline.
+func (e *Exporter) splitNavigation(
+	bl *barline.Barline,
+	repeat bool,
+) (*barline.Barline, string, error) {
+	if !isNavigation(bl.GetTime()) {
+		return bl, "", nil
+	}
+
+	nav, err := e.mapper.TokenForNavigation(bl.Time)
+	if err != nil {
+		return nil, "", err
+	}
+
+	base := &barline.Barline{Type: bl.Type}
+	if repeat {
+		base.Time = barline.Time_Repeat
+	}
+
+	return base, nav, nil
+}
+
+func isNavigation(t barline.Time) bool {
+	return t == barline.Time_Segno ||
+		t == barline.Time_Dalsegno ||
+		t == barline.Time_Fine ||
+		t == barline.Time_DacapoAlFine
+}
+
+func New(
+	mapper interfaces.SymbolMapper,
+) *Exporter {
+	return &Exporter{
+		mapper: mapper,
+	}
+}
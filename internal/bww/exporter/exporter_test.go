@@ -0,0 +1,415 @@
+package exporter
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/barline"
+	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/helper"
+	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/length"
+	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/measure"
+	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/musicmodel"
+	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/pitch"
+	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/symbols"
+	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/symbols/accidental"
+	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/symbols/embellishment"
+	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/symbols/tie"
+	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/tune"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/bww"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/bww/parser"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/bww/symbolmapper"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/bww/symbolmerger"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/bwwfile"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/common"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/interfaces"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/utils"
+	"os"
+	"strings"
+)
+
+func newTestMapper() *symbolmapper.Mapper {
+	mapper, err := symbolmapper.New()
+	Expect(err).ShouldNot(HaveOccurred())
+
+	return mapper
+}
+
+func newTestParser() interfaces.BwwParser {
+	return newTestParserWithMode(common.StrictParsing)
+}
+
+func newTestParserWithMode(mode common.ParseMode) interfaces.BwwParser {
+	sp := bwwfile.NewStructureParser(
+		bwwfile.NewTokenizer(mode),
+		bwwfile.NewTokenConverter(),
+		bwwfile.NewDecoder(nil),
+	)
+	conv := bww.NewConverter(newTestMapper(), symbolmerger.NewCollectedMerger(), mode)
+
+	return parser.New(sp, conv)
+}
+
+func parseTunes(p interfaces.BwwParser, data []byte) musicmodel.MusicModel {
+	parsedTunes, err := p.ParseBwwData(data)
+	Expect(err).ShouldNot(HaveOccurred())
+
+	muMo := make(musicmodel.MusicModel, 0, len(parsedTunes))
+	for _, pt := range parsedTunes {
+		muMo = append(muMo, pt.Tune)
+	}
+
+	return muMo
+}
+
+var _ = Describe("Exporter", func() {
+	utils.SetupConsoleLogger()
+	var err error
+	var exp *Exporter
+	var tunes []*tune.Tune
+	var data []byte
+
+	BeforeEach(func() {
+		exp = New(newTestMapper())
+	})
+
+	JustBeforeEach(func() {
+		data, err = exp.ExportBwwData(tunes)
+	})
+
+	When("exporting no tunes", func() {
+		BeforeEach(func() {
+			tunes = nil
+		})
+
+		It("should return an error", func() {
+			Expect(err).Should(HaveOccurred())
+		})
+	})
+
+	When("exporting a nil tune", func() {
+		BeforeEach(func() {
+			tunes = []*tune.Tune{nil}
+		})
+
+		It("should return an error", func() {
+			Expect(err).Should(HaveOccurred())
+		})
+	})
+
+	When("exporting a tune with header and a single measure", func() {
+		BeforeEach(func() {
+			tunes = []*tune.Tune{
+				{
+					Title:    "Title \"with\" quotes",
+					Type:     "March",
+					Composer: "Composer",
+					Arranger: "Arranger",
+					Footer:   []string{"Footer"},
+					Tempo:    80,
+					Measures: []*measure.Measure{
+						{
+							LeftBarline: &barline.Barline{
+								Type: barline.Type_Heavy,
+								Time: barline.Time_Repeat,
+							},
+							RightBarline: &barline.Barline{
+								Type: barline.Type_Heavy,
+								Time: barline.Time_Repeat,
+							},
+							Time: &measure.TimeSignature{Beats: 2, BeatType: 4},
+							Symbols: []*symbols.Symbol{
+								{
+									Note: &symbols.Note{
+										Pitch:      pitch.Pitch_D,
+										Length:     length.Length_Eighth,
+										Dots:       1,
+										Accidental: accidental.Accidental_Sharp,
+										Tie:        tie.Tie_Start,
+										Embellishment: &embellishment.Embellishment{
+											Type: embellishment.Type_Doubling,
+										},
+									},
+								},
+								{
+									Note: &symbols.Note{
+										Pitch:  pitch.Pitch_D,
+										Length: length.Length_Sixteenth,
+										Tie:    tie.Tie_End,
+									},
+								},
+							},
+						},
+					},
+				},
+			}
+		})
+
+		It("should write the tune in bww format", func() {
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(string(data)).Should(HavePrefix("Bagpipe Music Writer Gold:1.0\n"))
+			Expect(string(data)).Should(ContainSubstring(
+				"\"Title 'with' quotes\",(T,L,0,0,Times New Roman,15,700,0,1,2,0,0,32768)\n"))
+			Expect(string(data)).Should(ContainSubstring(
+				"\"March\",(Y,C,0,0,Times New Roman,11,400,0,0,18,0,0,0)\n"))
+			Expect(string(data)).Should(ContainSubstring(
+				"\"Composer, arr. Arranger\",(M,R,0,0,Times New Roman,11,400,0,0,18,0,0,0)\n"))
+			Expect(string(data)).Should(ContainSubstring(
+				"\"Footer\",(F,L,0,0,Times New Roman,10,400,0,0,18,0,0,0)\n"))
+			Expect(string(data)).Should(ContainSubstring("TuneTempo,80\n"))
+			Expect(string(data)).Should(HaveSuffix(
+				"& I!'' 2_4 sharpd dbd ^ts D_8 'd D_16 ^te ''!I\n"))
+		})
+	})
+
+	When("exporting more measures than fit into a staff", func() {
+		BeforeEach(func() {
+			tunes = []*tune.Tune{{Title: "Title"}}
+			for i := 0; i < measuresPerStaff+1; i++ {
+				tunes[0].Measures = append(tunes[0].Measures, &measure.Measure{
+					Symbols: []*symbols.Symbol{
+						{
+							Note: &symbols.Note{
+								Pitch:  pitch.Pitch_LowA,
+								Length: length.Length_Quarter,
+							},
+						},
+					},
+				})
+			}
+		})
+
+		It("should split the measures into multiple staves", func() {
+			Expect(err).ShouldNot(HaveOccurred())
+			lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+			Expect(lines[len(lines)-2]).Should(Equal("& LA_4 ! LA_4 ! LA_4 ! LA_4 !t"))
+			Expect(lines[len(lines)-1]).Should(Equal("& LA_4 !t"))
+		})
+	})
+
+	When("exporting measures with navigation marks", func() {
+		BeforeEach(func() {
+			la := func() []*symbols.Symbol {
+				return []*symbols.Symbol{
+					{
+						Note: &symbols.Note{
+							Pitch:  pitch.Pitch_LowA,
+							Length: length.Length_Quarter,
+						},
+					},
+				}
+			}
+			tunes = []*tune.Tune{
+				{
+					Title: "Title",
+					Measures: []*measure.Measure{
+						{
+							LeftBarline: &barline.Barline{Time: barline.Time_Segno},
+							Symbols:     la(),
+						},
+						{
+							RightBarline: &barline.Barline{Time: barline.Time_Fine},
+							Symbols:      la(),
+						},
+						{
+							Symbols: la(),
+						},
+						{
+							LeftBarline: &barline.Barline{
+								Type: barline.Type_Heavy,
+								Time: barline.Time_Segno,
+							},
+							RightBarline: &barline.Barline{
+								Type: barline.Type_Heavy,
+								Time: barline.Time_Dalsegno,
+							},
+							Symbols: la(),
+						},
+					},
+				},
+			}
+		})
+
+		It("should write the navigation marks next to the barlines", func() {
+			Expect(err).ShouldNot(HaveOccurred())
+			lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+			Expect(lines[len(lines)-2]).Should(Equal("& segno LA_4 ! LA_4 fine !t"))
+			Expect(lines[len(lines)-1]).Should(Equal("& LA_4 I! segno LA_4 !I dalsegno"))
+		})
+
+		It("should parse the exported navigation marks to the same tune", func() {
+			reparsed := parseTunes(newTestParser(), data)
+			Expect(reparsed).Should(BeComparableTo(
+				musicmodel.MusicModel(tunes), helper.MusicModelCompareOptions))
+		})
+	})
+
+	When("exporting parsed tunes with the sources of their staves", func() {
+		var fileData []byte
+
+		BeforeEach(func() {
+			fileData, err = os.ReadFile("../parser/testfiles/staves.bww")
+			Expect(err).ShouldNot(HaveOccurred())
+		})
+
+		It("should write the staves of the parsed file", func() {
+			pf, err := newTestParser().ParseBwwFile(fileData)
+			Expect(err).ShouldNot(HaveOccurred())
+			tunes = []*tune.Tune{pf.Tunes[0].Tune}
+
+			data, err = exp.ExportBwwDataWithSources(tunes, pf.Sources)
+			Expect(err).ShouldNot(HaveOccurred())
+			lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+			Expect(lines[len(lines)-2]).Should(Equal("& 4_4 LA_4 ! B_4 ! C_4 !t"))
+			Expect(lines[len(lines)-1]).Should(Equal("& D_4 ! E_4 ! F_4 ! HG_4 ! HA_4 !t"))
+		})
+
+		It("should write the key signatures at the start of the staves", func() {
+			ksData, err := os.ReadFile("../parser/testfiles/key_signatures.bww")
+			Expect(err).ShouldNot(HaveOccurred())
+			pf, err := newTestParser().ParseBwwFile(ksData)
+			Expect(err).ShouldNot(HaveOccurred())
+			tunes = []*tune.Tune{pf.Tunes[0].Tune}
+
+			data, err = exp.ExportBwwDataWithSources(tunes, pf.Sources)
+			Expect(err).ShouldNot(HaveOccurred())
+			lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+			Expect(lines[len(lines)-3:]).Should(Equal([]string{
+				"& sharpf sharpc 4_4 LA_4 ! B_4 !t",
+				"& sharpf sharpc C_4 ! sharpc C_4 !t",
+				"& sharplg LG_4 !t",
+			}))
+		})
+
+		It("should write the key signatures of staves without time signature", func() {
+			ksData, err := os.ReadFile("../parser/testfiles/key_signature_staves.bww")
+			Expect(err).ShouldNot(HaveOccurred())
+			pf, err := newTestParser().ParseBwwFile(ksData)
+			Expect(err).ShouldNot(HaveOccurred())
+			tunes = []*tune.Tune{pf.Tunes[0].Tune}
+
+			data, err = exp.ExportBwwDataWithSources(tunes, pf.Sources)
+			Expect(err).ShouldNot(HaveOccurred())
+			lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+			Expect(lines[len(lines)-1]).Should(Equal("& sharpf sharpc E_4 ! F_4 ''!I"))
+		})
+
+		It("should write the repeats that navigation marks replaced in the tune", func() {
+			navData, err := os.ReadFile("../parser/testfiles/segno_dalsegno.bww")
+			Expect(err).ShouldNot(HaveOccurred())
+			pf, err := newTestParserWithMode(common.LenientParsing).ParseBwwFile(navData)
+			Expect(err).ShouldNot(HaveOccurred())
+			tunes = []*tune.Tune{pf.Tunes[0].Tune}
+
+			data, err = exp.ExportBwwDataWithSources(tunes, pf.Sources)
+			Expect(err).ShouldNot(HaveOccurred())
+			lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+			Expect(lines[len(lines)-2:]).Should(Equal([]string{
+				"& segno ! LA_4 !I dalsegno",
+				"& segno ! LA_4 ''!I dalsegno",
+			}))
+		})
+
+		It("should write the beam flags of the melody notes", func() {
+			beamData, err := os.ReadFile("../parser/testfiles/beams.bww")
+			Expect(err).ShouldNot(HaveOccurred())
+			pf, err := newTestParser().ParseBwwFile(beamData)
+			Expect(err).ShouldNot(HaveOccurred())
+			tunes = []*tune.Tune{pf.Tunes[0].Tune}
+
+			data, err = exp.ExportBwwDataWithSources(tunes, pf.Sources)
+			Expect(err).ShouldNot(HaveOccurred())
+			lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+			Expect(lines[len(lines)-2]).Should(Equal("& 6_8 LAr_8 Br_8 Cl_8 ! gg Er_8 'e Cl_16 LA_4 !t"))
+			Expect(lines[len(lines)-1]).Should(Equal("& 4_4 Cr_16 El_16 Fr_16 HGl_16 D_4 ! LA_2 !t"))
+		})
+
+		It("should write the texts with their styles", func() {
+			styleData, err := os.ReadFile("../parser/testfiles/tune_with_inline_comments.bww")
+			Expect(err).ShouldNot(HaveOccurred())
+			pf, err := newTestParser().ParseBwwFile(styleData)
+			Expect(err).ShouldNot(HaveOccurred())
+			tunes = []*tune.Tune{pf.Tunes[0].Tune}
+
+			data, err = exp.ExportBwwDataWithSources(tunes, pf.Sources)
+			Expect(err).ShouldNot(HaveOccurred())
+			lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+			Expect(lines).Should(ContainElements(
+				`"Tune Title",(T,L,0,0,Times New Roman,15,700,0,1,2,0,0,32768)`,
+				`"tune inline text",(I,R,0,0,Courier New,12,400,255,0,49,0,0,0)`,
+			))
+			Expect(lines[len(lines)-1]).Should(Equal(
+				`& "staff 2 comment" "staff 2 inline text",(I,R,0,0,Courier New,12,400,255,0,49,0,0,0) D_4 !t`))
+		})
+
+		It("should return an error if the number of sources doesn't match", func() {
+			tunes = []*tune.Tune{{Title: "Title"}}
+			_, err = exp.ExportBwwDataWithSources(tunes, []*common.TuneSource{{}, {}})
+			Expect(err).Should(HaveOccurred())
+		})
+	})
+
+	When("exporting a time signature that bww doesn't support", func() {
+		BeforeEach(func() {
+			tunes = []*tune.Tune{
+				{
+					Title: "Title",
+					Measures: []*measure.Measure{
+						{
+							Time: &measure.TimeSignature{Beats: 13, BeatType: 3},
+						},
+					},
+				},
+			}
+		})
+
+		It("should return an error", func() {
+			Expect(err).Should(HaveOccurred())
+		})
+	})
+
+	DescribeTable("exporting parsed tunes and parsing them again",
+		func(testFile string) {
+			bwwParser := newTestParser()
+			fileData, rerr := os.ReadFile(testFile)
+			Expect(rerr).ShouldNot(HaveOccurred())
+			parsed := parseTunes(bwwParser, fileData)
+
+			exported, eerr := exp.ExportBwwData(parsed)
+			Expect(eerr).ShouldNot(HaveOccurred())
+			reparsed := parseTunes(bwwParser, exported)
+
+			Expect(reparsed).Should(BeComparableTo(parsed, helper.MusicModelCompareOptions))
+		},
+		Entry("all melody notes", "../parser/testfiles/all_melody_notes.bww"),
+		Entry("all symbols", "../parser/testfiles/all_symbols.bww"),
+		Entry("dots", "../parser/testfiles/dots.bww"),
+		Entry("cadences", "../parser/testfiles/cadences.bww"),
+		Entry("doublings", "../parser/testfiles/doublings.bww"),
+		Entry("fermatas", "../parser/testfiles/fermatas.bww"),
+		Entry("grips", "../parser/testfiles/grips.bww"),
+		Entry("irregular groups", "../parser/testfiles/irregular_groups.bww"),
+		Entry("peles", "../parser/testfiles/peles.bww"),
+		Entry("pio crunluaths", "../parser/testfiles/pio_crunluaths.bww"),
+		Entry("pio darodo", "../parser/testfiles/pio_darodo.bww"),
+		Entry("pio echo beats", "../parser/testfiles/pio_echo_beats.bww"),
+		Entry("pio grips", "../parser/testfiles/pio_grips.bww"),
+		Entry("pio lemluaths", "../parser/testfiles/pio_lemluaths.bww"),
+		Entry("pio misc", "../parser/testfiles/pio_misc.bww"),
+		Entry("pio taorluaths", "../parser/testfiles/pio_taorluaths.bww"),
+		Entry("pio throws and doublings", "../parser/testfiles/pio_throws_and_doublings.bww"),
+		Entry("pio triplings", "../parser/testfiles/pio_triplings.bww"),
+		Entry("rests", "../parser/testfiles/rests.bww"),
+		Entry("single graces", "../parser/testfiles/single_graces.bww"),
+		Entry("strikes", "../parser/testfiles/strikes.bww"),
+		Entry("taorluaths", "../parser/testfiles/taorluaths.bww"),
+		Entry("throwds", "../parser/testfiles/throwds.bww"),
+		Entry("ties", "../parser/testfiles/ties.bww"),
+		Entry("ties old", "../parser/testfiles/ties_old.bww"),
+		Entry("time lines", "../parser/testfiles/time_lines.bww"),
+		Entry("time signatures", "../parser/testfiles/time_signatures.bww"),
+		Entry("triple strikes", "../parser/testfiles/triple_strikes.bww"),
+		Entry("tune with inline comments", "../parser/testfiles/tune_with_inline_comments.bww"),
+		Entry("tune with repeats", "../parser/testfiles/tune_with_repeats.bww"),
+		Entry("tune with symbol comment", "../parser/testfiles/tune_with_symbol_comment.bww"),
+		Entry("two tunes", "../parser/testfiles/two_tunes.bww"),
+	)
+})
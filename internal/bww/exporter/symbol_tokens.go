@@ -0,0 +1,53 @@
+package exporter
+
+import (
+	"fmt"
+	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/symbols"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/common"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/filestructure"
+)
+
+// symbolTokens returns the bww tokens for a music model symbol including its
+// comments and inline texts. A melody note is written with the beam flag and
+// the inline texts with the styles with the same index.
+func (e *Exporter) symbolTokens(
+	sym *symbols.Symbol,
+	flag common.NoteFlag,
+	textStyles []*filestructure.TextStyle,
+) ([]string, error) {
+	if sym == nil {
+		return nil, nil
+	}
+
+	toks, err := e.symbolValueTokens(sym, flag)
+	if err != nil {
+		return nil, err
+	}
+
+	toks = append(toks, textTokens(sym.Comments, sym.InlineTexts, textStyles)...)
+
+	return toks, nil
+}
+
+// symbolValueTokens returns the tokens of the symbol without its texts.
+// Tempo changes are written as TuneTempo, all other symbols are looked up
+// in the symbol mapper.
+func (e *Exporter) symbolValueTokens(
+	sym *symbols.Symbol,
+	flag common.NoteFlag,
+) ([]string, error) {
+	if sym.TempoChange != nil {
+		return []string{fmt.Sprintf("TuneTempo,%d", *sym.TempoChange)}, nil
+	}
+
+	if sym.Note == nil && sym.Rest == nil && sym.Tuplet == nil && sym.Timeline == nil {
+		return nil, nil
+	}
+
+	toks, err := e.mapper.TokensForSymbol(sym, flag)
+	if err != nil {
+		return nil, fmt.Errorf("symbol %s can't be exported: %w", sym.String(), err)
+	}
+
+	return toks, nil
+}
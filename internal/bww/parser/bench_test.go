@@ -0,0 +1,135 @@
+package parser
+
+import (
+	"bytes"
+	"github.com/rs/zerolog"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/common"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// collectionTunes is the number of tunes of the generated collection.
+const collectionTunes = 500
+
+// writeCollection writes a file with many tunes like a big tune collection
+// and returns its path.
+func writeCollection(b *testing.B) string {
+	data, err := os.ReadFile("./testfiles/all_symbols.bww")
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	// all_symbols.bww has the preamble in its first 7 lines
+	lines := bytes.SplitAfterN(data, []byte("\n"), 8)
+	coll := bytes.Join(lines[:7], nil)
+	tunes := append(bytes.TrimRight(lines[7], "\n"), '\n')
+	for range collectionTunes {
+		coll = append(coll, tunes...)
+	}
+
+	path := filepath.Join(b.TempDir(), "collection.bww")
+	if err := os.WriteFile(path, coll, 0o600); err != nil {
+		b.Fatal(err)
+	}
+
+	return path
+}
+
+// liveHeap returns the bytes of the heap that are still in use after a garbage collection.
+func liveHeap() uint64 {
+	runtime.GC()
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	return ms.HeapAlloc
+}
+
+func newBenchParser(b *testing.B) *Parser {
+	zerolog.SetGlobalLevel(zerolog.ErrorLevel)
+	return newFuzzParser(b, common.StrictParsing)
+}
+
+func parseWholeFile(
+	b *testing.B,
+	p *Parser,
+	path string,
+) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	pf, err := p.ParseBwwFile(data)
+	if err != nil {
+		b.Fatal(err)
+	}
+	if len(pf.Tunes) != collectionTunes*2 {
+		b.Fatalf("parsed %d tunes", len(pf.Tunes))
+	}
+}
+
+// readTunes reads the tunes of the file one by one. The sample function is
+// called for every tune.
+func readTunes(
+	b *testing.B,
+	p *Parser,
+	path string,
+	sample func(),
+) {
+	f, err := os.Open(path)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer f.Close()
+
+	n := 0
+	for pt, err := range p.ParseBwwReader(f) {
+		if err != nil {
+			b.Fatal(err)
+		}
+		runtime.KeepAlive(pt)
+		sample()
+		n++
+	}
+	if n != collectionTunes*2 {
+		b.Fatalf("read %d tunes", n)
+	}
+}
+
+func BenchmarkParseBwwFile(b *testing.B) {
+	p := newBenchParser(b)
+	path := writeCollection(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for range b.N {
+		parseWholeFile(b, p, path)
+	}
+
+	b.StopTimer()
+	base := liveHeap()
+	data, _ := os.ReadFile(path)
+	pf, _ := p.ParseBwwFile(data)
+	b.ReportMetric(float64(liveHeap()-base), "live-B")
+	runtime.KeepAlive(pf)
+}
+
+func BenchmarkParseBwwReader(b *testing.B) {
+	p := newBenchParser(b)
+	path := writeCollection(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for range b.N {
+		readTunes(b, p, path, func() {})
+	}
+
+	b.StopTimer()
+	base := liveHeap()
+	var peak uint64
+	readTunes(b, p, path, func() {
+		peak = max(peak, liveHeap()-base)
+	})
+	b.ReportMetric(float64(peak), "live-B")
+}
@@ -0,0 +1,93 @@
+package parser
+
+import (
+	"github.com/rs/zerolog"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/bww"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/bww/symbolmapper"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/bww/symbolmerger"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/bwwfile"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/common"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func newFuzzParser(
+	tb testing.TB,
+	mode common.ParseMode,
+) *Parser {
+	mapper, err := symbolmapper.New()
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	sp := bwwfile.NewStructureParser(
+		bwwfile.NewTokenizer(mode),
+		bwwfile.NewTokenConverter(),
+		bwwfile.NewDecoder(nil),
+	)
+	return New(sp, bww.NewConverter(mapper, symbolmerger.NewCollectedMerger(), mode))
+}
+
+// checkSources checks that every measure and symbol of the parsed tunes has a source span.
+func checkSources(
+	t *testing.T,
+	pf *common.ParsedFile,
+) {
+	if len(pf.Sources) != len(pf.Tunes) {
+		t.Fatalf("%d tune sources for %d tunes", len(pf.Sources), len(pf.Tunes))
+	}
+
+	for i, pt := range pf.Tunes {
+		ms := pf.Sources[i].Measures
+		if len(ms) != len(pt.Tune.Measures) {
+			t.Fatalf("tune %d has %d measure sources for %d measures", i, len(ms), len(pt.Tune.Measures))
+		}
+
+		for j, m := range pt.Tune.Measures {
+			if len(ms[j].Symbols) != len(m.Symbols) {
+				t.Fatalf("measure %d of tune %d has %d symbol spans for %d symbols",
+					j, i, len(ms[j].Symbols), len(m.Symbols))
+			}
+		}
+	}
+}
+
+func FuzzParseBwwData(f *testing.F) {
+	zerolog.SetGlobalLevel(zerolog.ErrorLevel)
+	files, err := filepath.Glob("./testfiles/*.bww")
+	if err != nil {
+		f.Fatal(err)
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(data, false)
+		f.Add(data, true)
+	}
+
+	strict := newFuzzParser(f, common.StrictParsing)
+	lenient := newFuzzParser(f, common.LenientParsing)
+
+	f.Fuzz(func(t *testing.T, data []byte, isLenient bool) {
+		p := strict
+		if isLenient {
+			p = lenient
+		}
+
+		pf, err := p.ParseBwwFile(data)
+		if err != nil {
+			return
+		}
+
+		checkSources(t, pf)
+		for i, pt := range pf.Tunes {
+			_, err := p.ParseBwwData(pt.TuneFileData)
+			if err != nil {
+				t.Fatalf("tune file data of tune %d doesn't parse again: %v\n%s", i, err, pt.TuneFileData)
+			}
+		}
+	})
+}
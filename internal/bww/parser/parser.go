@@ -2,7 +2,12 @@ package parser
 
 import (
 	"github.com/tomvodi/limepipes-plugin-api/plugin/v1/messages"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/common"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/diagnostics"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/filestructure"
 	"github.com/tomvodi/limepipes-plugin-bww/internal/interfaces"
+	"io"
+	"iter"
 )
 
 type Parser struct {
@@ -13,25 +18,100 @@ type Parser struct {
 func (p *Parser) ParseBwwData(
 	data []byte,
 ) ([]*messages.ParsedTune, error) {
-	bd, err := p.structureParser.ParseDocumentStructure(data)
+	pf, err := p.ParseBwwFile(data)
 	if err != nil {
 		return nil, err
 	}
 
-	var pt []*messages.ParsedTune
+	return pf.Tunes, nil
+}
+
+// ParseBwwFile parses the data of a bww file into its tunes and the
+// file related definitions like the meta data blocks.
+// The problems of all tunes are returned together as diagnostics.List.
+// Problems that don't stop parsing, like an invalid meta data block that was
+// skipped, are returned as warnings of the parsed file, or in front of the
+// problems of the tunes if the file can't be parsed.
+// If the file structure is built despite problems in the file, the tunes are still
+// converted, so that their problems are returned in the same list.
+func (p *Parser) ParseBwwFile(
+	data []byte,
+) (*common.ParsedFile, error) {
+	bd, err := p.structureParser.ParseDocumentStructure(data)
+	if bd == nil {
+		return nil, err
+	}
+
+	pf := &common.ParsedFile{
+		BagpipePlayerVersion: bd.BagpipePlayerVersion,
+		MetaData:             bd.MetaData,
+		Warnings:             bd.Warnings,
+	}
+	var diags diagnostics.List
+	diags.Add(err)
 	for _, def := range bd.TuneDefs {
-		ct, err := p.gConverter.Convert(def.Tune)
+		ct, src, err := p.gConverter.Convert(def.Tune)
 		if err != nil {
-			return nil, err
+			diags.Add(err)
+			continue
 		}
 
-		pt = append(pt, &messages.ParsedTune{
+		pf.Tunes = append(pf.Tunes, &messages.ParsedTune{
 			Tune:         ct,
 			TuneFileData: def.Data,
 		})
+		pf.Sources = append(pf.Sources, src)
+	}
+
+	if len(diags) > 0 {
+		return nil, append(pf.Warnings, diags...)
+	}
+
+	return pf, nil
+}
+
+// ParseBwwReader reads a bww file from r and yields its tunes one at a time.
+// In contrast to ParseBwwFile, only a single tune of the file is kept in memory,
+// so that big collections with many tunes can be parsed. The problems of a tune
+// are yielded as diagnostics.List and parsing continues with the next tune.
+// Like with ParseBwwFile, the tunes are not fixed by the tune fixer, ParseReader
+// of the plugin does this for every yielded tune. The warnings of the file, like
+// skipped meta data blocks or misplaced staff ends, are only returned by ParseBwwFile.
+func (p *Parser) ParseBwwReader(
+	r io.Reader,
+) iter.Seq2[*messages.ParsedTune, error] {
+	return func(yield func(*messages.ParsedTune, error) bool) {
+		for def, err := range p.structureParser.ParseTunes(r) {
+			var pt *messages.ParsedTune
+			diags := diagnostics.FromError(err)
+			if def != nil {
+				var tuneErr error
+				pt, tuneErr = p.parseTuneDefinition(def)
+				diags.Add(tuneErr)
+			}
+
+			if len(diags) > 0 {
+				pt, err = nil, diags
+			}
+			if !yield(pt, err) {
+				return
+			}
+		}
+	}
+}
+
+func (p *Parser) parseTuneDefinition(
+	def *filestructure.TuneDefinition,
+) (*messages.ParsedTune, error) {
+	ct, _, err := p.gConverter.Convert(def.Tune)
+	if err != nil {
+		return nil, err
 	}
 
-	return pt, nil
+	return &messages.ParsedTune{
+		Tune:         ct,
+		TuneFileData: def.Data,
+	}, nil
 }
 
 func New(
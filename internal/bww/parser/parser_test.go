@@ -1,22 +1,32 @@
 package parser
 
 import (
+	"bytes"
+	"fmt"
 	"github.com/goccy/go-yaml"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/helper"
 	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/measure"
 	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/musicmodel"
+	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/pitch"
+	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/symbols"
+	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/symbols/accidental"
+	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/symbols/tie"
 	"github.com/tomvodi/limepipes-plugin-api/plugin/v1/messages"
 	"github.com/tomvodi/limepipes-plugin-bww/internal/bww"
 	"github.com/tomvodi/limepipes-plugin-bww/internal/bww/symbolmapper"
 	"github.com/tomvodi/limepipes-plugin-bww/internal/bww/symbolmerger"
 	"github.com/tomvodi/limepipes-plugin-bww/internal/bwwfile"
 	"github.com/tomvodi/limepipes-plugin-bww/internal/common"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/diagnostics"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/filestructure"
 	"github.com/tomvodi/limepipes-plugin-bww/internal/interfaces"
 	"github.com/tomvodi/limepipes-plugin-bww/internal/utils"
 	"io"
 	"os"
+	"path/filepath"
+	"strings"
 )
 
 func dataFromFile(filePath string) []byte {
@@ -47,6 +57,20 @@ func importFromYaml(filePath string) musicmodel.MusicModel {
 	return muMo
 }
 
+// lineSpan returns the span from the start to the end column in the line.
+func lineSpan(line, start, end int) filestructure.Span {
+	return filestructure.Span{
+		Start: filestructure.Position{Line: line, Column: start},
+		End:   filestructure.Position{Line: line, Column: end},
+	}
+}
+
+// existingSpan returns the span of a model element that has to exist.
+func existingSpan(span filestructure.Span, ok bool) filestructure.Span {
+	Expect(ok).Should(BeTrue())
+	return span
+}
+
 func nilAllMeasureMessages(muMo musicmodel.MusicModel) {
 	for _, tune := range muMo {
 		for _, m := range tune.Measures {
@@ -55,6 +79,21 @@ func nilAllMeasureMessages(muMo musicmodel.MusicModel) {
 	}
 }
 
+func newParser(mode common.ParseMode) interfaces.BwwParser {
+	tok := bwwfile.NewTokenizer(mode)
+	tokConv := bwwfile.NewTokenConverter()
+	sp := bwwfile.NewStructureParser(
+		tok,
+		tokConv,
+		bwwfile.NewDecoder(nil),
+	)
+	symmap, err := symbolmapper.New()
+	Expect(err).ShouldNot(HaveOccurred())
+	merger := symbolmerger.NewCollectedMerger()
+	fsconv := bww.NewConverter(symmap, merger, mode)
+	return New(sp, fsconv)
+}
+
 var _ = Describe("BWW Parser", func() {
 	utils.SetupConsoleLogger()
 	var err error
@@ -66,16 +105,7 @@ var _ = Describe("BWW Parser", func() {
 	var testFileExpect string
 
 	BeforeEach(func() {
-		tok := bwwfile.NewTokenizer()
-		tokConv := bwwfile.NewTokenConverter()
-		sp := bwwfile.NewStructureParser(
-			tok,
-			tokConv,
-		)
-		symmap := symbolmapper.New()
-		merger := symbolmerger.NewCollectedMerger()
-		fsconv := bww.NewConverter(symmap, merger)
-		parser = New(sp, fsconv)
+		parser = newParser(common.StrictParsing)
 		musicTunesBww = make(musicmodel.MusicModel, 0)
 	})
 
@@ -432,7 +462,6 @@ var _ = Describe("BWW Parser", func() {
 
 	When("having ties in old format with error messages", func() {
 		BeforeEach(func() {
-			Skip("Parser does not support old format styles")
 			testFile = "./testfiles/ties_old_with_errors.bww"
 			testFileExpect = "./testfiles/ties_old_with_errors.yaml"
 		})
@@ -447,7 +476,7 @@ var _ = Describe("BWW Parser", func() {
 				Equal(&measure.ParserMessage{
 					Symbol:   "^tla",
 					Severity: measure.Severity_Warning,
-					Text:     "tie in old format (^tla) must follow a note and can't be the first symbol in a measure",
+					Text:     "tie in old format (^tla) must follow a note and can't be the first symbol of the tune",
 					Fix:      measure.Fix_SkipSymbol,
 				}))
 			Expect(musicTunesBww[0].Measures[1].ParserMessages[0]).Should(
@@ -465,9 +494,11 @@ var _ = Describe("BWW Parser", func() {
 
 	When("having ties in (old format)", func() {
 		BeforeEach(func() {
-			Skip("Parser does not support old format styles")
 			testFile = "./testfiles/ties_old.bww"
 			testFileExpect = "./testfiles/ties_old.yaml"
+		})
+
+		JustBeforeEach(func() {
 			//exportToYaml(musicTunesBww, "./testfiles/ties_old.yaml")
 		})
 
@@ -476,6 +507,13 @@ var _ = Describe("BWW Parser", func() {
 			Expect(musicTunesBww).Should(
 				BeComparableTo(musicTunesExpect, helper.MusicModelCompareOptions))
 		})
+
+		It("should tie a tie at the start of a measure to the last note of the previous measure", func() {
+			measures := musicTunesBww[0].Measures
+			Expect(measures[0].Symbols[0].Note.Tie).Should(Equal(tie.Tie_Start))
+			Expect(measures[1].Symbols[0].Note.Tie).Should(Equal(tie.Tie_End))
+			Expect(measures[1].ParserMessages).Should(BeEmpty())
+		})
 	})
 
 	When("having irregular groups", func() {
@@ -692,9 +730,10 @@ var _ = Describe("BWW Parser", func() {
 			//exportToYaml(musicTunesBww, "./testfiles/tune_with_time_line_end_after_staff_end.yaml")
 		})
 
-		It("should return an error", func() {
-			Expect(err).Should(HaveOccurred())
-			Expect(err.Error()).Should(ContainSubstring("staff end token is not at the end of line 5"))
+		It("should add the time line end to the last measure of the staff", func() {
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(musicTunesBww).Should(
+				BeComparableTo(musicTunesExpect, helper.MusicModelCompareOptions))
 		})
 	})
 
@@ -736,13 +775,16 @@ var _ = Describe("BWW Parser", func() {
 		BeforeEach(func() {
 			testFile = "./testfiles/cadences.bww"
 			testFileExpect = "./testfiles/cadences.yaml"
+		})
+
+		JustBeforeEach(func() {
 			//exportToYaml(musicTunesBww, "./testfiles/cadences.yaml")
 		})
 
 		It("should have parsed file correctly", func() {
-			Expect(err).Should(Equal(common.ErrPiobNotSupported))
-			//Expect(musicTunesBww).Should(
-			//	BeComparableTo(musicTunesExpect, helper.MusicModelCompareOptions))
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(musicTunesBww).Should(
+				BeComparableTo(musicTunesExpect, helper.MusicModelCompareOptions))
 		})
 	})
 
@@ -750,13 +792,16 @@ var _ = Describe("BWW Parser", func() {
 		BeforeEach(func() {
 			testFile = "./testfiles/pio_grips.bww"
 			testFileExpect = "./testfiles/pio_grips.yaml"
+		})
+
+		JustBeforeEach(func() {
 			//exportToYaml(musicTunesBww, "./testfiles/pio_grips.yaml")
 		})
 
 		It("should have parsed file correctly", func() {
-			Expect(err).Should(Equal(common.ErrPiobNotSupported))
-			//Expect(musicTunesBww).Should(
-			//	BeComparableTo(musicTunesExpect, helper.MusicModelCompareOptions))
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(musicTunesBww).Should(
+				BeComparableTo(musicTunesExpect, helper.MusicModelCompareOptions))
 		})
 	})
 
@@ -764,13 +809,16 @@ var _ = Describe("BWW Parser", func() {
 		BeforeEach(func() {
 			testFile = "./testfiles/pio_echo_beats.bww"
 			testFileExpect = "./testfiles/pio_echo_beats.yaml"
+		})
+
+		JustBeforeEach(func() {
 			//exportToYaml(musicTunesBww, "./testfiles/pio_echo_beats.yaml")
 		})
 
 		It("should have parsed file correctly", func() {
-			Expect(err).Should(Equal(common.ErrPiobNotSupported))
-			//Expect(musicTunesBww).Should(
-			//	BeComparableTo(musicTunesExpect, helper.MusicModelCompareOptions))
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(musicTunesBww).Should(
+				BeComparableTo(musicTunesExpect, helper.MusicModelCompareOptions))
 		})
 	})
 
@@ -778,13 +826,16 @@ var _ = Describe("BWW Parser", func() {
 		BeforeEach(func() {
 			testFile = "./testfiles/pio_darodo.bww"
 			testFileExpect = "./testfiles/pio_darodo.yaml"
+		})
+
+		JustBeforeEach(func() {
 			//exportToYaml(musicTunesBww, "./testfiles/pio_darodo.yaml")
 		})
 
 		It("should have parsed file correctly", func() {
-			Expect(err).Should(Equal(common.ErrPiobNotSupported))
-			//Expect(musicTunesBww).Should(
-			//	BeComparableTo(musicTunesExpect, helper.MusicModelCompareOptions))
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(musicTunesBww).Should(
+				BeComparableTo(musicTunesExpect, helper.MusicModelCompareOptions))
 		})
 	})
 
@@ -792,13 +843,16 @@ var _ = Describe("BWW Parser", func() {
 		BeforeEach(func() {
 			testFile = "./testfiles/pio_lemluaths.bww"
 			testFileExpect = "./testfiles/pio_lemluaths.yaml"
+		})
+
+		JustBeforeEach(func() {
 			//exportToYaml(musicTunesBww, "./testfiles/pio_lemluaths.yaml")
 		})
 
 		It("should have parsed file correctly", func() {
-			Expect(err).Should(Equal(common.ErrPiobNotSupported))
-			//Expect(musicTunesBww).Should(
-			//	BeComparableTo(musicTunesExpect, helper.MusicModelCompareOptions))
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(musicTunesBww).Should(
+				BeComparableTo(musicTunesExpect, helper.MusicModelCompareOptions))
 		})
 	})
 
@@ -806,13 +860,16 @@ var _ = Describe("BWW Parser", func() {
 		BeforeEach(func() {
 			testFile = "./testfiles/pio_taorluaths.bww"
 			testFileExpect = "./testfiles/pio_taorluaths.yaml"
+		})
+
+		JustBeforeEach(func() {
 			//exportToYaml(musicTunesBww, "./testfiles/pio_taorluaths.yaml")
 		})
 
 		It("should have parsed file correctly", func() {
-			Expect(err).Should(Equal(common.ErrPiobNotSupported))
-			//Expect(musicTunesBww).Should(
-			//	BeComparableTo(musicTunesExpect, helper.MusicModelCompareOptions))
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(musicTunesBww).Should(
+				BeComparableTo(musicTunesExpect, helper.MusicModelCompareOptions))
 		})
 	})
 
@@ -820,13 +877,16 @@ var _ = Describe("BWW Parser", func() {
 		BeforeEach(func() {
 			testFile = "./testfiles/pio_crunluaths.bww"
 			testFileExpect = "./testfiles/pio_crunluaths.yaml"
+		})
+
+		JustBeforeEach(func() {
 			//exportToYaml(musicTunesBww, "./testfiles/pio_crunluaths.yaml")
 		})
 
 		It("should have parsed file correctly", func() {
-			Expect(err).Should(Equal(common.ErrPiobNotSupported))
-			//Expect(musicTunesBww).Should(
-			//	BeComparableTo(musicTunesExpect, helper.MusicModelCompareOptions))
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(musicTunesBww).Should(
+				BeComparableTo(musicTunesExpect, helper.MusicModelCompareOptions))
 		})
 	})
 
@@ -834,13 +894,16 @@ var _ = Describe("BWW Parser", func() {
 		BeforeEach(func() {
 			testFile = "./testfiles/pio_triplings.bww"
 			testFileExpect = "./testfiles/pio_triplings.yaml"
+		})
+
+		JustBeforeEach(func() {
 			//exportToYaml(musicTunesBww, "./testfiles/pio_triplings.yaml")
 		})
 
 		It("should have parsed file correctly", func() {
-			Expect(err).Should(Equal(common.ErrPiobNotSupported))
-			//Expect(musicTunesBww).Should(
-			//	BeComparableTo(musicTunesExpect, helper.MusicModelCompareOptions))
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(musicTunesBww).Should(
+				BeComparableTo(musicTunesExpect, helper.MusicModelCompareOptions))
 		})
 	})
 
@@ -848,13 +911,16 @@ var _ = Describe("BWW Parser", func() {
 		BeforeEach(func() {
 			testFile = "./testfiles/pio_misc.bww"
 			testFileExpect = "./testfiles/pio_misc.yaml"
+		})
+
+		JustBeforeEach(func() {
 			//exportToYaml(musicTunesBww, "./testfiles/pio_misc.yaml")
 		})
 
 		It("should have parsed file correctly", func() {
-			Expect(err).Should(Equal(common.ErrPiobNotSupported))
-			//Expect(musicTunesBww).Should(
-			//	BeComparableTo(musicTunesExpect, helper.MusicModelCompareOptions))
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(musicTunesBww).Should(
+				BeComparableTo(musicTunesExpect, helper.MusicModelCompareOptions))
 		})
 	})
 
@@ -862,13 +928,48 @@ var _ = Describe("BWW Parser", func() {
 		BeforeEach(func() {
 			testFile = "./testfiles/segno_dalsegno.bww"
 			testFileExpect = "./testfiles/segno_dalsegno.yaml"
+			// a navigation mark on a repeat barline is only accepted in lenient mode
+			parser = newParser(common.LenientParsing)
+		})
+
+		JustBeforeEach(func() {
 			//exportToYaml(musicTunesBww, "./testfiles/segno_dalsegno.yaml")
 		})
 
 		It("should have parsed file correctly", func() {
-			Expect(err).Should(Equal(common.ErrPiobNotSupported))
-			//Expect(musicTunesBww).Should(
-			//	BeComparableTo(musicTunesExpect, helper.MusicModelCompareOptions))
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(musicTunesBww[0].Measures[3].ParserMessages).Should(
+				Equal([]*measure.ParserMessage{
+					{
+						Symbol:   "dalsegno",
+						Severity: measure.Severity_Warning,
+						Text:     "dalsegno replaces the repeat of the barline",
+					},
+				}))
+			nilAllMeasureMessages(musicTunesBww)
+			Expect(musicTunesBww).Should(
+				BeComparableTo(musicTunesExpect, helper.MusicModelCompareOptions))
+		})
+
+		It("should keep the replaced repeat in the sources", func() {
+			pf, err := parser.ParseBwwFile(dataFromFile(testFile))
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(pf.Sources[0].Measures[3].RightRepeat).Should(BeTrue())
+			Expect(pf.Sources[0].Measures[1].RightRepeat).Should(BeFalse())
+		})
+
+		When("parsing in strict mode", func() {
+			BeforeEach(func() {
+				parser = newParser(common.StrictParsing)
+			})
+
+			It("should return an error for the navigation on the repeat", func() {
+				Expect(parsedTunes).Should(BeNil())
+				diags := diagnostics.FromError(err)
+				Expect(diags).Should(HaveLen(1))
+				Expect(diags[0].Code).Should(Equal(diagnostics.CodeNavigationOnRepeat))
+				Expect(diags[0].Text).Should(Equal("dalsegno"))
+			})
 		})
 	})
 
@@ -876,13 +977,48 @@ var _ = Describe("BWW Parser", func() {
 		BeforeEach(func() {
 			testFile = "./testfiles/fine_dacapoalfine.bww"
 			testFileExpect = "./testfiles/fine_dacapoalfine.yaml"
+			// a navigation mark on a repeat barline is only accepted in lenient mode
+			parser = newParser(common.LenientParsing)
+		})
+
+		JustBeforeEach(func() {
 			//exportToYaml(musicTunesBww, "./testfiles/fine_dacapoalfine.yaml")
 		})
 
 		It("should have parsed file correctly", func() {
-			Expect(err).Should(Equal(common.ErrPiobNotSupported))
-			//Expect(musicTunesBww).Should(
-			//	BeComparableTo(musicTunesExpect, helper.MusicModelCompareOptions))
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(musicTunesBww[0].Measures[3].ParserMessages).Should(
+				Equal([]*measure.ParserMessage{
+					{
+						Symbol:   "dacapoalfine",
+						Severity: measure.Severity_Warning,
+						Text:     "dacapoalfine replaces the repeat of the barline",
+					},
+				}))
+			nilAllMeasureMessages(musicTunesBww)
+			Expect(musicTunesBww).Should(
+				BeComparableTo(musicTunesExpect, helper.MusicModelCompareOptions))
+		})
+
+		It("should keep the replaced repeat in the sources", func() {
+			pf, err := parser.ParseBwwFile(dataFromFile(testFile))
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(pf.Sources[0].Measures[3].RightRepeat).Should(BeTrue())
+			Expect(pf.Sources[0].Measures[1].RightRepeat).Should(BeFalse())
+		})
+
+		When("parsing in strict mode", func() {
+			BeforeEach(func() {
+				parser = newParser(common.StrictParsing)
+			})
+
+			It("should return an error for the navigation on the repeat", func() {
+				Expect(parsedTunes).Should(BeNil())
+				diags := diagnostics.FromError(err)
+				Expect(diags).Should(HaveLen(1))
+				Expect(diags[0].Code).Should(Equal(diagnostics.CodeNavigationOnRepeat))
+				Expect(diags[0].Text).Should(Equal("dacapoalfine"))
+			})
 		})
 	})
 
@@ -926,16 +1062,486 @@ var _ = Describe("BWW Parser", func() {
 			Expect(err).ShouldNot(HaveOccurred())
 			Expect(musicTunesBww).To(HaveLen(2))
 		})
+
+		It("should return the meta data of the file", func() {
+			pf, err := parser.ParseBwwFile(dataFromFile(testFile))
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(pf.BagpipePlayerVersion).Should(BeEquivalentTo("Bagpipe Reader:1.0"))
+			Expect(pf.MetaData.MIDINoteMappings.Natural).Should(Equal(
+				filestructure.PitchValues{55, 57, 59, 60, 62, 64, 65, 67, 69}))
+			Expect(pf.MetaData.FrequencyMappings.Flat).Should(Equal(
+				filestructure.PitchValues{415, 466, 523, 554, 622, 699, 740, 831, 932}))
+			Expect(pf.MetaData.InstrumentMappings).Should(Equal(
+				&filestructure.InstrumentMappings{71, 71, 46, 46, 2100, 60, 70}))
+			Expect(pf.MetaData.GracenoteDurations).Should(Equal(
+				&filestructure.GracenoteDurations{35, 40, 30, 50, 100, 200, 800, 1200, 250, 250, 250, 500, 200}))
+			Expect(pf.MetaData.FontSizes).Should(Equal(
+				&filestructure.FontSizes{56, 100, 120, 75, 265}))
+			Expect(pf.MetaData.TuneFormat).Should(Equal(
+				&filestructure.TuneFormat{
+					LayoutFlags: [2]bool{true, true},
+					LayoutMode:  "M",
+					PageSize:    "L",
+					Margins:     [4]int{500, 500, 500, 550},
+					Orientation: filestructure.OrientationPortrait,
+				}))
+		})
+
+		It("should have the tunes exactly as they are in the file", func() {
+			fileData := string(dataFromFile(testFile))
+			Expect(parsedTunes).To(HaveLen(2))
+			tune1 := string(parsedTunes[0].TuneFileData)
+			Expect(fileData).Should(HavePrefix(tune1))
+			tune2 := fileData[len(tune1):]
+			Expect(string(parsedTunes[1].TuneFileData)).Should(HaveSuffix(tune2))
+			Expect(tune2).Should(HavePrefix(`"Next tune after some staves"`))
+		})
+
+		It("should write the meta data into the tune file data", func() {
+			metaData := strings.Join(strings.Split(string(dataFromFile(testFile)), "\n")[:7], "\n")
+			for _, pt := range parsedTunes {
+				Expect(string(pt.TuneFileData)).Should(HavePrefix(metaData))
+			}
+		})
 	})
 
 	When("parsing the file with all piobaireached symbols in it", func() {
 		BeforeEach(func() {
 			testFile = "./testfiles/all_piobaireached_symbols.bww"
+			// the file has navigation marks on repeat barlines, which are only accepted in lenient mode
+			parser = newParser(common.LenientParsing)
 		})
 
 		It("should succeed", func() {
-			Expect(err).Should(Equal(common.ErrPiobNotSupported))
-			//Expect(musicTunesBww).To(HaveLen(11))
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(musicTunesBww).To(HaveLen(11))
+		})
+	})
+
+	When("parsing a Windows-1252 file with accents in the tune header", func() {
+		BeforeEach(func() {
+			testFile = "./testfiles/windows_1252.bww"
+		})
+
+		It("should decode the texts", func() {
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(musicTunesBww).Should(HaveLen(1))
+			Expect(musicTunesBww[0].Title).Should(Equal("Máiri’s Wedding"))
+			Expect(musicTunesBww[0].Composer).Should(Equal("Seán Ó Riada"))
+		})
+
+		It("should return the tune file data as UTF-8", func() {
+			Expect(string(parsedTunes[0].TuneFileData)).Should(ContainSubstring(`"Máiri’s Wedding"`))
+		})
+
+		It("should parse a file with lone \\r line endings", func() {
+			data := bytes.ReplaceAll(dataFromFile(testFile), []byte("\r\n"), []byte("\r"))
+			tunes, err := parser.ParseBwwData(data)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(tunes).Should(HaveLen(1))
+			Expect(tunes[0].Tune.Title).Should(Equal("Máiri’s Wedding"))
+			Expect(tunes[0].Tune).Should(BeComparableTo(musicTunesBww[0], helper.MusicModelCompareOptions))
+		})
+	})
+
+	When("parsing a file with an invalid meta data block", func() {
+		It("should return the skipped block as warning", func() {
+			pf, err := parser.ParseBwwFile([]byte(`Bagpipe Reader:1.0
+FontSizes,(90,100,100,80)
+"Tune Title",(T,L,0,0,Times New Roman,15,700,0,1,2,0,0,32768)
+& LA_4 !t
+`))
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(pf.Tunes).Should(HaveLen(1))
+			Expect(pf.MetaData.FontSizes).Should(BeNil())
+			Expect(pf.Warnings).Should(HaveLen(1))
+			Expect(pf.Warnings[0].Code).Should(Equal(diagnostics.CodeInvalidMetaData))
+			Expect(pf.Warnings[0].Severity).Should(Equal(measure.Severity_Warning))
+			Expect(pf.Warnings[0].Start).Should(Equal(diagnostics.Position{Line: 1}))
+			Expect(pf.Warnings[0].Message).Should(ContainSubstring("expected 5 parameters but got 4"))
+		})
+
+		It("should return the skipped block in front of the problems of the tunes", func() {
+			_, err := parser.ParseBwwFile([]byte(`Bagpipe Reader:1.0
+FontSizes,(90,100,100,80)
+"Tune Title",(T,L,0,0,Times New Roman,15,700,0,1,2,0,0,32768)
+& LA_4 unknownsym !t
+`))
+			Expect(err).Should(HaveOccurred())
+			diags := diagnostics.FromError(err)
+			Expect(len(diags)).Should(BeNumerically(">", 1))
+			Expect(diags[0].Code).Should(Equal(diagnostics.CodeInvalidMetaData))
+			Expect(diags[1].Severity).Should(Equal(measure.Severity_Error))
+		})
+	})
+
+	When("parsing a file for the source spans of its measures and symbols", func() {
+		var pf *common.ParsedFile
+
+		BeforeEach(func() {
+			testFile = "./testfiles/source_spans.bww"
+		})
+
+		JustBeforeEach(func() {
+			pf, err = parser.ParseBwwFile(dataFromFile(testFile))
+		})
+
+		It("should return the span of every measure", func() {
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(pf.Sources).Should(HaveLen(len(pf.Tunes)))
+			Expect(existingSpan(pf.MeasureSpan(0, 0))).Should(Equal(lineSpan(4, 2, 17)))
+			Expect(existingSpan(pf.MeasureSpan(0, 1))).Should(Equal(lineSpan(4, 18, 30)))
+			Expect(existingSpan(pf.MeasureSpan(1, 0))).Should(Equal(lineSpan(8, 2, 8)))
+		})
+
+		It("should return the span of every symbol with the merged tokens", func() {
+			Expect(pf.Tunes[0].Tune.Measures[0].Symbols).Should(HaveLen(2))
+			Expect(existingSpan(pf.SymbolSpan(0, 0, 0))).Should(Equal(lineSpan(4, 6, 13)))
+			Expect(existingSpan(pf.SymbolSpan(0, 0, 1))).Should(Equal(lineSpan(4, 14, 17)))
+			Expect(existingSpan(pf.SymbolSpan(0, 1, 0))).Should(Equal(lineSpan(4, 20, 27)))
+			Expect(existingSpan(pf.SymbolSpan(1, 0, 0))).Should(Equal(lineSpan(8, 2, 5)))
+		})
+
+		It("should return false for an index that doesn't exist", func() {
+			_, ok := pf.SymbolSpan(0, 0, 2)
+			Expect(ok).Should(BeFalse())
+			_, ok = pf.MeasureSpan(2, 0)
+			Expect(ok).Should(BeFalse())
+		})
+	})
+
+	When("parsing a file with staves of different lengths", func() {
+		var pf *common.ParsedFile
+
+		BeforeEach(func() {
+			testFile = "./testfiles/staves.bww"
+		})
+
+		JustBeforeEach(func() {
+			pf, err = parser.ParseBwwFile(dataFromFile(testFile))
+		})
+
+		It("should mark the last measure of every staff with a line break", func() {
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(pf.Tunes[0].Tune.Measures).Should(HaveLen(8))
+			for i := range pf.Tunes[0].Tune.Measures {
+				Expect(pf.LineBreak(0, i)).Should(Equal(i == 2 || i == 7), "measure %d", i)
+			}
+		})
+
+		It("should return false for an index that doesn't exist", func() {
+			Expect(pf.LineBreak(0, 8)).Should(BeFalse())
+			Expect(pf.LineBreak(1, 0)).Should(BeFalse())
+		})
+	})
+
+	When("parsing a file with key signatures at the start of the staves", func() {
+		var pf *common.ParsedFile
+
+		BeforeEach(func() {
+			testFile = "./testfiles/key_signatures.bww"
+		})
+
+		JustBeforeEach(func() {
+			pf, err = parser.ParseBwwFile(dataFromFile(testFile))
+		})
+
+		It("should keep the key signature of every staff in the sources", func() {
+			Expect(err).ShouldNot(HaveOccurred())
+			for _, measureIdx := range []int{0, 2} {
+				ks := pf.KeySignature(0, measureIdx)
+				Expect(ks).ShouldNot(BeNil(), "measure %d", measureIdx)
+				Expect(ks.Accidentals).Should(BeComparableTo([]*symbols.Note{
+					{Pitch: pitch.Pitch_F, Accidental: accidental.Accidental_Sharp},
+					{Pitch: pitch.Pitch_C, Accidental: accidental.Accidental_Sharp},
+				}, helper.MusicModelCompareOptions))
+			}
+			Expect(pf.KeySignature(0, 0).Spans).Should(Equal([]filestructure.Span{
+				lineSpan(4, 2, 8),
+				lineSpan(4, 9, 15),
+			}))
+			Expect(pf.KeySignature(0, 1)).Should(BeNil())
+			Expect(pf.KeySignature(0, 3)).Should(BeNil())
+		})
+
+		It("shouldn't add the key signature to the first note", func() {
+			measures := pf.Tunes[0].Tune.Measures
+			Expect(measures[0].Time).ShouldNot(BeNil())
+			Expect(measures[0].Symbols).Should(HaveLen(1))
+			Expect(measures[0].Symbols[0].Note.Accidental).Should(Equal(accidental.Accidental_NoAccidental))
+			Expect(measures[2].Symbols).Should(HaveLen(1))
+			Expect(measures[2].Symbols[0].Note.Accidental).Should(Equal(accidental.Accidental_NoAccidental))
+			Expect(measures[3].Symbols[0].Note.Accidental).Should(Equal(accidental.Accidental_Sharp))
+			Expect(existingSpan(pf.SymbolSpan(0, 0, 0))).Should(Equal(lineSpan(4, 20, 24)))
+		})
+
+		It("should keep a single accidental with the pitch of the first note for the note", func() {
+			measures := pf.Tunes[0].Tune.Measures
+			Expect(pf.KeySignature(0, 4)).Should(BeNil())
+			Expect(measures[4].Symbols).Should(HaveLen(1))
+			Expect(measures[4].Symbols[0].Note.Pitch).Should(Equal(pitch.Pitch_LowG))
+			Expect(measures[4].Symbols[0].Note.Accidental).Should(Equal(accidental.Accidental_Sharp))
+		})
+	})
+
+	When("parsing a file with the key signature repeated at the start of a staff without time signature", func() {
+		var pf *common.ParsedFile
+
+		BeforeEach(func() {
+			testFile = "./testfiles/key_signature_staves.bww"
+		})
+
+		JustBeforeEach(func() {
+			pf, err = parser.ParseBwwFile(dataFromFile(testFile))
+		})
+
+		It("should keep the accidentals as key signature of the staff", func() {
+			Expect(err).ShouldNot(HaveOccurred())
+			measures := pf.Tunes[0].Tune.Measures
+			Expect(measures).Should(HaveLen(5))
+			Expect(pf.KeySignature(0, 3)).ShouldNot(BeNil())
+			Expect(pf.KeySignature(0, 3).Accidentals).Should(BeComparableTo(
+				pf.KeySignature(0, 0).Accidentals, helper.MusicModelCompareOptions,
+			))
+			Expect(measures[3].Symbols).Should(HaveLen(1))
+			Expect(measures[3].Symbols[0].Note.Pitch).Should(Equal(pitch.Pitch_E))
+			Expect(measures[3].Symbols[0].Note.Accidental).Should(Equal(accidental.Accidental_NoAccidental))
+		})
+	})
+
+	When("parsing a file with beamed melody notes", func() {
+		var pf *common.ParsedFile
+
+		BeforeEach(func() {
+			testFile = "./testfiles/beams.bww"
+		})
+
+		JustBeforeEach(func() {
+			pf, err = parser.ParseBwwFile(dataFromFile(testFile))
+		})
+
+		It("should keep the flags of the melody notes in the sources", func() {
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(pf.NoteFlag(0, 0, 0)).Should(Equal(common.FlagRight))
+			Expect(pf.NoteFlag(0, 0, 2)).Should(Equal(common.FlagLeft))
+			Expect(pf.NoteFlag(0, 1, 0)).Should(Equal(common.FlagRight))
+			Expect(pf.NoteFlag(0, 1, 2)).Should(Equal(common.NoFlag))
+			Expect(existingSpan(pf.SymbolSpan(0, 1, 0))).Should(Equal(lineSpan(4, 24, 34)))
+		})
+
+		It("should derive the beam groups of every measure", func() {
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(pf.Sources[0].Measures[0].Beams).Should(Equal([]common.Beam{
+				common.BeamStart, common.BeamContinue, common.BeamEnd,
+			}))
+			Expect(pf.Sources[0].Measures[1].Beams).Should(Equal([]common.Beam{
+				common.BeamStart, common.BeamEnd, common.NoBeam,
+			}))
+			Expect(pf.Sources[0].Measures[2].Beams).Should(Equal([]common.Beam{
+				common.BeamStart, common.BeamEnd, common.BeamStart, common.BeamEnd, common.NoBeam,
+			}))
+			Expect(pf.Beam(0, 3, 0)).Should(Equal(common.NoBeam))
+			Expect(pf.Beam(1, 0, 0)).Should(Equal(common.NoBeam))
+		})
+	})
+
+	When("parsing a file with an unknown symbol and a misplaced staff end", func() {
+		BeforeEach(func() {
+			testFile = "./testfiles/tunes_with_errors.bww"
+		})
+
+		It("should return an error", func() {
+			Expect(err).Should(HaveOccurred())
+			Expect(parsedTunes).Should(BeNil())
+		})
+
+		When("parsing in lenient mode", func() {
+			BeforeEach(func() {
+				parser = newParser(common.LenientParsing)
+			})
+
+			It("should parse all tunes", func() {
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(musicTunesBww).Should(HaveLen(2))
+				Expect(musicTunesBww[0].Measures).Should(HaveLen(5))
+				Expect(musicTunesBww[1].Measures).Should(HaveLen(1))
+			})
+
+			It("should skip the unknown symbol with a parser message", func() {
+				m := musicTunesBww[0].Measures[0]
+				Expect(m.Symbols).Should(HaveLen(1))
+				Expect(m.Symbols[0].IsValidNote()).Should(BeTrue())
+				Expect(m.ParserMessages).Should(BeComparableTo([]*measure.ParserMessage{
+					{
+						Symbol:   "XY_4",
+						Severity: measure.Severity_Error,
+						Text:     "line 4, column 11: symbol XY_4 not found",
+						Fix:      measure.Fix_SkipSymbol,
+					},
+				}, helper.MusicModelCompareOptions))
+			})
+
+			It("should place the symbols after the misplaced staff end in a new measure", func() {
+				m := musicTunesBww[0].Measures[2]
+				Expect(m.Symbols).Should(HaveLen(1))
+				Expect(m.ParserMessages).Should(BeComparableTo([]*measure.ParserMessage{
+					{
+						Symbol:   "!t",
+						Severity: measure.Severity_Warning,
+						Text: "line 5, column 6: staff end !t is not at the end of the line, " +
+							"the following symbols are placed in a new measure",
+					},
+				}, helper.MusicModelCompareOptions))
+				Expect(musicTunesBww[0].Measures[3].Symbols).Should(HaveLen(1))
+				Expect(musicTunesBww[0].Measures[3].ParserMessages).Should(BeEmpty())
+			})
+		})
+	})
+
+	When("parsing a file with unknown symbols in several tunes", func() {
+		BeforeEach(func() {
+			testFile = "./testfiles/tunes_with_unknown_symbols.bww"
+		})
+
+		It("should return a diagnostic for every unknown symbol", func() {
+			Expect(parsedTunes).Should(BeNil())
+			diags := diagnostics.FromError(err)
+			Expect(diags).Should(HaveLen(3))
+			for i, d := range diags {
+				Expect(d.Code).Should(Equal(diagnostics.CodeUnknownSymbol))
+				Expect(d.Severity).Should(Equal(measure.Severity_Error))
+				Expect(d).Should(MatchError(common.ErrSymbolNotFound), "diagnostic %d", i)
+			}
+			Expect(diags[0].Text).Should(Equal("LA4"))
+			Expect(diags[0].Start).Should(Equal(diagnostics.Position{Line: 4, Column: 6}))
+			Expect(diags[0].End).Should(Equal(diagnostics.Position{Line: 4, Column: 9}))
+			Expect(diags[1].Text).Should(Equal("gstx"))
+			Expect(diags[1].Start).Should(Equal(diagnostics.Position{Line: 4, Column: 16}))
+			Expect(diags[2].Text).Should(Equal("dbla_"))
+			Expect(diags[2].Start).Should(Equal(diagnostics.Position{Line: 8, Column: 10}))
+		})
+
+		It("should suggest known symbols for the unknown symbols", func() {
+			diags := diagnostics.FromError(err)
+			Expect(diags).Should(HaveLen(3))
+			Expect(diags[0].Suggestions).Should(HaveExactElements("LA_4", "HA_4", "LA_1"))
+			Expect(diags[0].Fix).Should(Equal("did you mean LA_4 or HA_4 or LA_1?"))
+			Expect(diags[0].Replacement).Should(Equal("LA_4"))
+			Expect(diags[1].Suggestions).Should(ContainElement("gstb"))
+			Expect(diags[1].Replacement).Should(BeEmpty())
+			Expect(diags[2].Suggestions).Should(HaveExactElements("dbla", "dbha", "dblg"))
+			Expect(diags[2].Replacement).Should(Equal("dbla"))
+		})
+
+		When("parsing in lenient mode with suggestions", func() {
+			BeforeEach(func() {
+				parser = newParser(common.LenientParsingWithSuggestions)
+			})
+
+			It("should replace the symbols with a confident suggestion", func() {
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(musicTunesBww).Should(HaveLen(2))
+				m := musicTunesBww[0].Measures[0]
+				Expect(m.Symbols).Should(HaveLen(1))
+				Expect(m.Symbols[0].Note.Pitch).Should(Equal(pitch.Pitch_LowA))
+				Expect(m.ParserMessages).Should(BeComparableTo([]*measure.ParserMessage{
+					{
+						Symbol:   "LA4",
+						Severity: measure.Severity_Warning,
+						Text:     "line 4, column 6: symbol LA4 not found, replaced with LA_4",
+					},
+				}, helper.MusicModelCompareOptions))
+			})
+
+			It("should skip a symbol without a confident suggestion", func() {
+				m := musicTunesBww[0].Measures[1]
+				Expect(m.Symbols).Should(HaveLen(2))
+				Expect(m.ParserMessages).Should(HaveLen(1))
+				Expect(m.ParserMessages[0].Symbol).Should(Equal("gstx"))
+				Expect(m.ParserMessages[0].Fix).Should(Equal(measure.Fix_SkipSymbol))
+			})
+		})
+	})
+
+	When("parsing a file with problems in the file and in the symbols", func() {
+		BeforeEach(func() {
+			testFile = "./testfiles/tune_with_all_problems.bww"
+		})
+
+		It("should return the problems of all stages in one list", func() {
+			Expect(parsedTunes).Should(BeNil())
+			diags := diagnostics.FromError(err)
+			var codes []diagnostics.Code
+			var texts []string
+			for _, d := range diags {
+				codes = append(codes, d.Code)
+				texts = append(texts, d.Text)
+			}
+			Expect(codes).Should(Equal([]diagnostics.Code{
+				diagnostics.CodeMisplacedStaffEnd,
+				diagnostics.CodeUnknownLine,
+				diagnostics.CodeUnknownSymbol,
+				diagnostics.CodeUnknownSymbol,
+				diagnostics.CodeUnknownSymbol,
+			}))
+			Expect(texts).Should(Equal([]string{"!t", "unknown line", "LA4", "xyz", "7_9"}))
+			Expect(diags[0].Severity).Should(Equal(measure.Severity_Warning))
+		})
+	})
+
+	When("reading the test files tune by tune", func() {
+		for _, mode := range []common.ParseMode{common.StrictParsing, common.LenientParsing} {
+			It(fmt.Sprintf("should return the same tunes as parsing the whole file in mode %d", mode), func() {
+				parser = newParser(mode)
+				files, err := filepath.Glob("./testfiles/*.bww")
+				Expect(err).ShouldNot(HaveOccurred())
+
+				for _, file := range files {
+					data := dataFromFile(file)
+					tunes, err := parser.ParseBwwData(data)
+
+					var readTunes []*messages.ParsedTune
+					var diags diagnostics.List
+					for pt, err := range parser.ParseBwwReader(bytes.NewReader(data)) {
+						diags.Add(err)
+						if pt != nil {
+							readTunes = append(readTunes, pt)
+						}
+					}
+
+					if err != nil {
+						// the warnings of the file are only returned by ParseBwwData
+						var errs diagnostics.List
+						for _, d := range diagnostics.FromError(err) {
+							if d.Severity == measure.Severity_Error {
+								errs = append(errs, d)
+							}
+						}
+						Expect(diags).Should(ContainElements(errs), file)
+						continue
+					}
+
+					Expect(diags).Should(BeEmpty(), file)
+					Expect(readTunes).Should(HaveLen(len(tunes)), file)
+					for i, pt := range readTunes {
+						Expect(pt.Tune).Should(BeComparableTo(tunes[i].Tune, helper.MusicModelCompareOptions), file)
+						Expect(string(pt.TuneFileData)).Should(Equal(string(tunes[i].TuneFileData)), file)
+					}
+				}
+			})
+		}
+
+		It("should stop reading when the caller stops", func() {
+			data := dataFromFile("./testfiles/all_piobaireached_symbols.bww")
+			var titles []string
+			for pt, err := range parser.ParseBwwReader(bytes.NewReader(data)) {
+				Expect(err).ShouldNot(HaveOccurred())
+				titles = append(titles, pt.Tune.Title)
+				if len(titles) == 2 {
+					break
+				}
+			}
+			Expect(titles).Should(HaveLen(2))
 		})
 	})
 })
@@ -0,0 +1,232 @@
+package parser
+
+import (
+	"fmt"
+	"github.com/google/go-cmp/cmp"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/helper"
+	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/measure"
+	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/symbols"
+	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/tune"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/bww"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/bww/symbolmapper"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/bww/symbolmerger"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/bwwfile"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/common"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/interfaces"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/utils"
+	"google.golang.org/protobuf/proto"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// roundTripIssue is a construct of a tune that is different after parsing
+// the regenerated tune file data of the tune again.
+type roundTripIssue struct {
+	family string
+	file   string
+	detail string
+}
+
+// roundTripReport collects all round trip issues of the test files.
+type roundTripReport struct {
+	issues      []roundTripIssue
+	unparseable []string
+}
+
+func (r *roundTripReport) add(
+	family string,
+	file string,
+	detail string,
+) {
+	r.issues = append(r.issues, roundTripIssue{
+		family: family,
+		file:   file,
+		detail: detail,
+	})
+}
+
+// String returns the issues grouped by their symbol family with the number
+// of occurrences and the files they occur in.
+func (r *roundTripReport) String() string {
+	sb := &strings.Builder{}
+	sb.WriteString("tune file data round trip report\n")
+
+	families := map[string][]roundTripIssue{}
+	for _, i := range r.issues {
+		families[i.family] = append(families[i.family], i)
+	}
+
+	names := make([]string, 0, len(families))
+	for f := range families {
+		names = append(names, f)
+	}
+	slices.Sort(names)
+
+	for _, f := range names {
+		issues := families[f]
+		sb.WriteString(fmt.Sprintf("  %s: %d\n", f, len(issues)))
+		for _, i := range issues {
+			sb.WriteString(fmt.Sprintf("    %s: %s\n", i.file, i.detail))
+		}
+	}
+
+	if len(r.issues) == 0 {
+		sb.WriteString("  all constructs survived\n")
+	}
+
+	for _, f := range r.unparseable {
+		sb.WriteString(fmt.Sprintf("  not parseable: %s\n", f))
+	}
+
+	return sb.String()
+}
+
+func newRoundTripParser() interfaces.BwwParser {
+	sp := bwwfile.NewStructureParser(
+		bwwfile.NewTokenizer(common.StrictParsing),
+		bwwfile.NewTokenConverter(),
+		bwwfile.NewDecoder(nil),
+	)
+	mapper, err := symbolmapper.New()
+	Expect(err).ShouldNot(HaveOccurred())
+	conv := bww.NewConverter(mapper, symbolmerger.NewCollectedMerger(), common.StrictParsing)
+
+	return New(sp, conv)
+}
+
+// checkTuneFileDataRoundTrip parses the file and every regenerated tune file
+// data of it and adds all differences to the report.
+func checkTuneFileDataRoundTrip(
+	p interfaces.BwwParser,
+	file string,
+	report *roundTripReport,
+) {
+	parsedTunes, err := p.ParseBwwData(dataFromFile(file))
+	if err != nil {
+		report.unparseable = append(report.unparseable, fmt.Sprintf("%s (%v)", file, err))
+		return
+	}
+
+	for i, pt := range parsedTunes {
+		tuneFile := fmt.Sprintf("%s tune %d", filepath.Base(file), i)
+		reparsed, err := p.ParseBwwData(pt.TuneFileData)
+		if err != nil {
+			report.add("tune file data", tuneFile, err.Error())
+			continue
+		}
+		if len(reparsed) != 1 {
+			report.add("tune file data", tuneFile,
+				fmt.Sprintf("expected 1 tune but got %d", len(reparsed)))
+			continue
+		}
+
+		compareRoundTripTunes(pt.Tune, reparsed[0].Tune, tuneFile, report)
+	}
+}
+
+func compareRoundTripTunes(
+	orig *tune.Tune,
+	reparsed *tune.Tune,
+	file string,
+	report *roundTripReport,
+) {
+	origHeader := proto.Clone(orig).(*tune.Tune)
+	origHeader.Measures = nil
+	reparsedHeader := proto.Clone(reparsed).(*tune.Tune)
+	reparsedHeader.Measures = nil
+	if d := cmp.Diff(origHeader, reparsedHeader, helper.MusicModelCompareOptions); d != "" {
+		report.add("tune header", file, d)
+	}
+
+	if len(orig.Measures) != len(reparsed.Measures) {
+		report.add("measure count", file, fmt.Sprintf("%d measures instead of %d",
+			len(reparsed.Measures), len(orig.Measures)))
+	}
+
+	for i := range min(len(orig.Measures), len(reparsed.Measures)) {
+		measureFile := fmt.Sprintf("%s measure %d", file, i)
+		compareRoundTripMeasures(orig.Measures[i], reparsed.Measures[i], measureFile, report)
+	}
+}
+
+func compareRoundTripMeasures(
+	orig *measure.Measure,
+	reparsed *measure.Measure,
+	file string,
+	report *roundTripReport,
+) {
+	origFields := proto.Clone(orig).(*measure.Measure)
+	origFields.Symbols = nil
+	reparsedFields := proto.Clone(reparsed).(*measure.Measure)
+	reparsedFields.Symbols = nil
+	if d := cmp.Diff(origFields, reparsedFields, helper.MusicModelCompareOptions); d != "" {
+		report.add("measure", file, d)
+	}
+
+	if len(orig.Symbols) != len(reparsed.Symbols) {
+		report.add("symbol count", file, fmt.Sprintf("%d symbols instead of %d",
+			len(reparsed.Symbols), len(orig.Symbols)))
+		return
+	}
+
+	for i, sym := range orig.Symbols {
+		d := cmp.Diff(sym, reparsed.Symbols[i], helper.MusicModelCompareOptions)
+		if d != "" {
+			report.add(symbolFamily(sym), fmt.Sprintf("%s symbol %d", file, i), d)
+		}
+	}
+}
+
+// symbolFamily returns the name of the family a symbol belongs to,
+// e.g. the embellishment or movement type of a note.
+func symbolFamily(sym *symbols.Symbol) string {
+	switch {
+	case sym.TempoChange != nil:
+		return "tempo change"
+	case sym.Rest != nil:
+		return "rest"
+	case sym.Tuplet != nil:
+		return "tuplet"
+	case sym.Timeline != nil:
+		return "timeline"
+	case sym.Note == nil:
+		return "unknown symbol"
+	}
+
+	return noteFamily(sym.Note)
+}
+
+func noteFamily(n *symbols.Note) string {
+	switch {
+	case n.Embellishment != nil:
+		return "embellishment " + n.Embellishment.Type.String()
+	case n.Movement != nil:
+		return "movement " + n.Movement.Type.String()
+	case n.IsValid():
+		return "melody note"
+	}
+
+	return "note without melody note"
+}
+
+var _ = Describe("Tune file data round trip", func() {
+	utils.SetupConsoleLogger()
+
+	It("should parse the tune file data of all test files to the same tunes", func() {
+		files, err := filepath.Glob("./testfiles/*.bww")
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(files).ShouldNot(BeEmpty())
+
+		p := newRoundTripParser()
+		report := &roundTripReport{}
+		for _, f := range files {
+			checkTuneFileDataRoundTrip(p, f, report)
+		}
+
+		_, _ = fmt.Fprint(GinkgoWriter, report.String())
+		Expect(report.issues).Should(BeEmpty(), report.String())
+	})
+})
@@ -0,0 +1,143 @@
+package bww
+
+import (
+	"github.com/tomvodi/limepipes-plugin-bww/internal/common"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/filestructure"
+)
+
+// tuneState is the state of a tune while it is converted.
+type tuneState struct {
+	ties        *tieState
+	source      *common.TuneSource
+	staffStarts map[*filestructure.Measure]bool // The first measures of the staves
+	staffEnds   map[*filestructure.Measure]bool // The last measures of the staves
+	keySig      *common.KeySignature            // The key signature of the last staff that had one
+}
+
+// startsStaff returns true if the measure is the first one of its staff.
+func (ts *tuneState) startsStaff(m *filestructure.Measure) bool {
+	return ts.staffStarts[m]
+}
+
+// endsStaff returns true if the measure is the last one of its staff, as the
+// music model has no staves and a line break follows it.
+func (ts *tuneState) endsStaff(m *filestructure.Measure) bool {
+	return ts.staffEnds[m]
+}
+
+// startMeasure adds the source of a new measure that is converted next.
+func (ts *tuneState) startMeasure(span filestructure.Span) {
+	ts.source.Measures = append(ts.source.Measures, &common.MeasureSource{
+		Span: span,
+	})
+}
+
+// setLineBreak marks the current measure as the last one of its staff.
+func (ts *tuneState) setLineBreak() {
+	ts.source.Measures[len(ts.source.Measures)-1].LineBreak = true
+}
+
+// setReplacedRepeat marks the barline of the current measure at pos as repeat,
+// which was replaced by a navigation mark in the measure.
+func (ts *tuneState) setReplacedRepeat(pos common.BarlinePosition) {
+	ms := ts.source.Measures[len(ts.source.Measures)-1]
+	if pos == common.LeftBarline {
+		ms.LeftRepeat = true
+		return
+	}
+
+	ms.RightRepeat = true
+}
+
+// setKeySignature sets the key signature of the staff that the current measure starts.
+func (ts *tuneState) setKeySignature(ks *common.KeySignature) {
+	ts.source.Measures[len(ts.source.Measures)-1].KeySignature = ks
+	ts.keySig = ks
+}
+
+// setHeaderStyles sets the text styles of the tune header fields.
+func (ts *tuneState) setHeaderStyles(h *filestructure.TuneHeader) {
+	ts.source.Header = common.HeaderStyles{
+		Title:       h.TitleStyle,
+		Type:        h.TypeStyle,
+		Composer:    h.ComposerStyle,
+		Footer:      alignedStyles(len(h.Footer), h.FooterStyles),
+		InlineTexts: alignedStyles(len(h.InlineTexts), h.InlineTextStyles),
+	}
+}
+
+// addInlineTextStyles adds the styles of textCount inline texts that were
+// added to the current measure.
+func (ts *tuneState) addInlineTextStyles(
+	textCount int,
+	styles []*filestructure.TextStyle,
+) {
+	ms := ts.source.Measures[len(ts.source.Measures)-1]
+	ms.InlineTextStyles = append(ms.InlineTextStyles, alignedStyles(textCount, styles)...)
+}
+
+// addSymbol adds the span, the beam flag and the inline text styles of a symbol
+// that was added to the current measure. If the symbol was merged into the previous
+// symbol of the measure, the span of the previous symbol is extended to the end of
+// the symbol and it gets the flag of the symbol if it has one, e.g. the flag of a
+// melody note that follows its embellishment. The texts of a merged symbol are
+// dropped, and so are their styles.
+func (ts *tuneState) addSymbol(
+	span filestructure.Span,
+	flag common.NoteFlag,
+	textStyles []*filestructure.TextStyle,
+	merged bool,
+) {
+	ms := ts.source.Measures[len(ts.source.Measures)-1]
+	if merged && len(ms.Symbols) > 0 {
+		last := len(ms.Symbols) - 1
+		ms.Symbols[last].End = span.End
+		if flag != common.NoFlag {
+			ms.Flags[last] = flag
+		}
+		return
+	}
+
+	ms.Symbols = append(ms.Symbols, span)
+	ms.Flags = append(ms.Flags, flag)
+	ms.SymbolTextStyles = append(ms.SymbolTextStyles, textStyles)
+}
+
+// alignedStyles returns the styles of textCount texts, so that every text has
+// the style with the same index. Texts without a style get a nil style.
+func alignedStyles(
+	textCount int,
+	styles []*filestructure.TextStyle,
+) []*filestructure.TextStyle {
+	if textCount == 0 {
+		return nil
+	}
+
+	aligned := make([]*filestructure.TextStyle, textCount)
+	copy(aligned, styles)
+
+	return aligned
+}
+
+// endMeasure derives the beam groups of the current measure from the flags of its symbols.
+func (ts *tuneState) endMeasure() {
+	ms := ts.source.Measures[len(ts.source.Measures)-1]
+	ms.Beams = common.BeamGroups(ms.Flags)
+}
+
+func newTuneState(staves []*filestructure.Staff) *tuneState {
+	ts := &tuneState{
+		ties:        &tieState{},
+		source:      &common.TuneSource{},
+		staffStarts: make(map[*filestructure.Measure]bool, len(staves)),
+		staffEnds:   make(map[*filestructure.Measure]bool, len(staves)),
+	}
+	for _, s := range staves {
+		if len(s.Measures) > 0 {
+			ts.staffStarts[s.Measures[0]] = true
+			ts.staffEnds[s.Measures[len(s.Measures)-1]] = true
+		}
+	}
+
+	return ts
+}
@@ -1,22 +1,25 @@
 package symbolmapper
 
-import "fmt"
+import (
+	"fmt"
+	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/pitch"
+	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/symbols/movement"
+)
 
 func init() {
-	cads := []string{
-		"cadged",
-		"cadge",
-		"caded",
-		"cade",
-		"cadaed",
-		"cadae",
-		"cadgf",
-		"cadaf",
+	cads := map[string][]pitch.Pitch{
+		"cadged": {pitch.Pitch_HighG, pitch.Pitch_E, pitch.Pitch_D},
+		"cadge":  {pitch.Pitch_HighG, pitch.Pitch_E},
+		"caded":  {pitch.Pitch_E, pitch.Pitch_D},
+		"cade":   {pitch.Pitch_E},
+		"cadaed": {pitch.Pitch_HighA, pitch.Pitch_E, pitch.Pitch_D},
+		"cadae":  {pitch.Pitch_HighA, pitch.Pitch_E},
+		"cadgf":  {pitch.Pitch_HighG, pitch.Pitch_F},
+		"cadaf":  {pitch.Pitch_HighA, pitch.Pitch_F},
 	}
 
-	for _, s := range cads {
-		cads = append(cads, fmt.Sprintf("f%s", s))
+	for s, pitches := range cads {
+		symbolsMap[s] = newMovement(movement.Type_Cadence, pitches)
+		symbolsMap[fmt.Sprintf("f%s", s)] = newMovement(movement.Type_Cadence, pitches, fermata)
 	}
-
-	piobSymbols = append(piobSymbols, cads...)
 }
@@ -1,6 +1,8 @@
 package symbolmapper
 
 import (
+	"errors"
+	"fmt"
 	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/pitch"
 	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/symbols"
 	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/symbols/embellishment"
@@ -28,6 +30,19 @@ var lowPitchToPitch = map[string]pitch.Pitch{
 	"ha": pitch.Pitch_HighA,
 }
 
+// tableErr collects the errors of invalid definitions in the symbol tables.
+// The tables are filled on package initialization, so the error is returned
+// when a mapper is created.
+var tableErr error
+
+// addTableError adds an error for an invalid argument of a symbol definition.
+func addTableError(
+	fn string,
+	arg any,
+) {
+	tableErr = errors.Join(tableErr, fmt.Errorf("unknown argument %v (%T) to %s", arg, arg, fn))
+}
+
 func newEmbellishment(
 	eType embellishment.Type,
 	args ...any,
@@ -49,13 +64,30 @@ func newEmbellishment(
 		case embellishment.Weight:
 			sym.Note.Embellishment.Weight = t
 		default:
-			panic("Unknown argument to newEmbellishment")
+			addTableError("newEmbellishment", arg)
 		}
 	}
 
 	return sym
 }
 
+// movementFlag is an argument to newMovement that sets a flag of the movement.
+// The abbreviation of a movement is set with a bool argument.
+type movementFlag uint8
+
+const (
+	breabach movementFlag = iota
+	aMach
+	fermata
+)
+
+// movementPitch is an argument to newMovement that sets the distinct pitch of
+// a movement like the pitch of an echo beat, in contrast to its pitch hints.
+type movementPitch pitch.Pitch
+
+// newMovement returns a symbol with a movement of the given type. The first pitch
+// argument sets the pitch hint, a second one the additional pitch hint.
+// A pitch slice sets the pitches of a cadence.
 func newMovement(
 	mType movement.Type,
 	args ...any,
@@ -74,12 +106,44 @@ func newMovement(
 			sym.Note.Movement.Variant = t
 		case bool:
 			sym.Note.Movement.Abbreviate = t
+		case movementFlag:
+			setMovementFlag(sym.Note.Movement, t)
 		case pitch.Pitch:
-			sym.Note.Movement.PitchHint = t
+			setMovementPitchHint(sym.Note.Movement, t)
+		case movementPitch:
+			sym.Note.Movement.Pitch = pitch.Pitch(t)
+		case []pitch.Pitch:
+			sym.Note.Movement.Pitches = t
 		default:
-			panic("Unknown argument to newMovement")
+			addTableError("newMovement", arg)
 		}
 	}
 
 	return sym
 }
+
+func setMovementFlag(
+	mv *movement.Movement,
+	f movementFlag,
+) {
+	switch f {
+	case breabach:
+		mv.Breabach = true
+	case aMach:
+		mv.AMach = true
+	case fermata:
+		mv.Fermata = true
+	}
+}
+
+func setMovementPitchHint(
+	mv *movement.Movement,
+	p pitch.Pitch,
+) {
+	if mv.PitchHint == pitch.Pitch_NoPitch {
+		mv.PitchHint = p
+		return
+	}
+
+	mv.AdditionalPitchHint = p
+}
@@ -1,8 +1,8 @@
 package symbolmapper
 
+import "github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/barline"
+
 func init() {
-	piobSymbols = append(piobSymbols,
-		"fine",
-		"dacapoalfine",
-	)
+	navigationMap["fine"] = barline.Time_Fine
+	navigationMap["dacapoalfine"] = barline.Time_DacapoAlFine
 }
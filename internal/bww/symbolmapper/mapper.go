@@ -7,16 +7,21 @@ import (
 	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/measure"
 	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/symbols"
 	"github.com/tomvodi/limepipes-plugin-bww/internal/common"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/diagnostics"
+	"maps"
 	"slices"
+	"strings"
 )
 
 var symbolsMap = map[string]*symbols.Symbol{}
-var piobSymbols []string
 var timeSignatureMap = map[string]*measure.TimeSignature{}
 var barlineMap = map[string]*barline.Barline{}
+var navigationMap = map[string]barline.Time{}
 var timeSignatureKeys = []string{}
+var oldTieTokens []string
 
 type Mapper struct {
+	suggester *suggester
 }
 
 func (m *Mapper) BarlineForToken(
@@ -24,12 +29,24 @@ func (m *Mapper) BarlineForToken(
 ) (*barline.Barline, error) {
 	bl, ok := barlineMap[token]
 	if !ok {
-		return nil, common.ErrSymbolNotFound
+		return nil, m.notFound(diagnostics.CodeUnknownBarline, "barline", token)
 	}
 
 	return bl, nil
 }
 
+// NavigationForToken returns the barline time for a navigation token like segno or fine.
+func (m *Mapper) NavigationForToken(
+	token string,
+) (barline.Time, error) {
+	t, ok := navigationMap[token]
+	if !ok {
+		return barline.Time_NoTime, m.notFound(diagnostics.CodeUnknownNavigation, "navigation", token)
+	}
+
+	return t, nil
+}
+
 func (m *Mapper) IsTimeSignature(token string) bool {
 	return slices.Contains(timeSignatureKeys, token)
 }
@@ -37,20 +54,22 @@ func (m *Mapper) IsTimeSignature(token string) bool {
 func (m *Mapper) TimeSigForToken(token string) (*measure.TimeSignature, error) {
 	sig, ok := timeSignatureMap[token]
 	if !ok {
-		return nil, common.ErrSymbolNotFound
+		return nil, m.notFound(diagnostics.CodeUnknownTimeSignature, "time signature", token)
 	}
 
 	return sig, nil
 }
 
+// IsOldTie returns true if the token is a tie in the old format like ^tla.
+// The old format tie of E (^te) is the same token as the tie end of the new format.
+func (m *Mapper) IsOldTie(token string) bool {
+	return slices.Contains(oldTieTokens, token)
+}
+
 func (m *Mapper) SymbolForToken(token string) (*symbols.Symbol, error) {
 	sym, ok := symbolsMap[token]
 	if !ok {
-		if slices.Contains(piobSymbols, token) {
-			return nil, common.ErrPiobNotSupported
-		}
-
-		return nil, common.ErrSymbolNotFound
+		return nil, m.notFound(diagnostics.CodeUnknownSymbol, "symbol", token)
 	}
 	// If nil symbol is found, Symbols should be skipped
 	if sym == nil {
@@ -65,7 +84,54 @@ func (m *Mapper) SymbolForToken(token string) (*symbols.Symbol, error) {
 	return symCopy, nil
 }
 
-func New() *Mapper {
-	m := &Mapper{}
-	return m
+// NoteFlagForToken returns the beam flag of a melody note token like LAr_8
+// or NoFlag if the token isn't a melody note with a flag.
+func (m *Mapper) NoteFlagForToken(token string) common.NoteFlag {
+	return noteFlagMap[token]
+}
+
+// notFound returns the diagnostic for a token that isn't in the tables with the
+// most similar known tokens as suggestions. Its position is set by the caller
+// that knows where the token is in the file.
+func (m *Mapper) notFound(
+	code diagnostics.Code,
+	kind string,
+	token string,
+) *diagnostics.Diagnostic {
+	d := &diagnostics.Diagnostic{
+		Code:     code,
+		Severity: measure.Severity_Error,
+		Message:  fmt.Sprintf("%s %s not found", kind, token),
+		Text:     token,
+		Err:      common.ErrSymbolNotFound,
+	}
+
+	suggestions, confident := m.suggester.Suggest(token)
+	if len(suggestions) == 0 {
+		return d
+	}
+
+	d.Suggestions = suggestions
+	d.Fix = fmt.Sprintf("did you mean %s?", strings.Join(suggestions, " or "))
+	if confident {
+		d.Replacement = suggestions[0]
+	}
+
+	return d
+}
+
+// New returns a new mapper. It returns an error if a symbol table has an invalid definition.
+func New() (*Mapper, error) {
+	if tableErr != nil {
+		return nil, fmt.Errorf("invalid symbol tables: %w", tableErr)
+	}
+
+	m := &Mapper{
+		suggester: newSuggester(
+			slices.Collect(maps.Keys(symbolsMap)),
+			slices.Collect(maps.Keys(barlineMap)),
+			slices.Collect(maps.Keys(timeSignatureMap)),
+		),
+	}
+	return m, nil
 }
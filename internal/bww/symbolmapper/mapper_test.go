@@ -0,0 +1,48 @@
+package symbolmapper
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/symbols/embellishment"
+	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/symbols/movement"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/common"
+)
+
+var _ = Describe("Mapper", func() {
+	It("should be created for the symbol tables", func() {
+		_, err := New()
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	When("a symbol table has an invalid definition", func() {
+		var origTableErr error
+
+		BeforeEach(func() {
+			origTableErr = tableErr
+			DeferCleanup(func() {
+				tableErr = origTableErr
+			})
+			newEmbellishment(embellishment.Type_Doubling, "invalid")
+			newMovement(movement.Type_Cadence, 3)
+		})
+
+		It("should return an error instead of a mapper", func() {
+			m, err := New()
+			Expect(m).Should(BeNil())
+			Expect(err).Should(MatchError(ContainSubstring("unknown argument invalid (string) to newEmbellishment")))
+			Expect(err).Should(MatchError(ContainSubstring("unknown argument 3 (int) to newMovement")))
+		})
+	})
+
+	DescribeTable("NoteFlagForToken",
+		func(token string, expected common.NoteFlag) {
+			m, err := New()
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(m.NoteFlagForToken(token)).Should(Equal(expected))
+		},
+		Entry("a melody note with a beam to the right", "LAr_8", common.FlagRight),
+		Entry("a melody note with a beam to the left", "HGl_32", common.FlagLeft),
+		Entry("a melody note without a flag", "LA_8", common.NoFlag),
+		Entry("a token that isn't a melody note", "gg", common.NoFlag),
+	)
+})
@@ -5,6 +5,7 @@ import (
 	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/length"
 	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/pitch"
 	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/symbols"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/common"
 	"maps"
 )
 
@@ -12,6 +13,13 @@ var pitches = []string{"LG", "LA", "B", "C", "D", "E", "F", "HG", "HA"}
 var lengthesAll = []uint8{1, 2, 4, 8, 16, 32}
 var lengthesFlag = []uint8{8, 16, 32}
 var flags = []string{"l", "r"}
+var flagMap = map[string]common.NoteFlag{
+	"l": common.FlagLeft,
+	"r": common.FlagRight,
+}
+
+// noteFlagMap holds the flag of the melody note tokens with a beam flag like LAr_8.
+var noteFlagMap = map[string]common.NoteFlag{}
 var pitchMap = map[string]pitch.Pitch{
 	"LG": pitch.Pitch_LowG,
 	"LA": pitch.Pitch_LowA,
@@ -83,6 +91,23 @@ func getFlagMelodyNotes() map[string]*symbols.Symbol {
 	return m
 }
 
+// getMelodyNoteFlags returns the flags of the melody note tokens that have one,
+// as the music model has no field for the beam direction of a note.
+func getMelodyNoteFlags() map[string]common.NoteFlag {
+	m := make(map[string]common.NoteFlag)
+	const flagType = "%s%s_%d"
+	for _, p := range pitches {
+		for _, l := range lengthesFlag {
+			for _, f := range flags {
+				m[fmt.Sprintf(flagType, p, f, l)] = flagMap[f]
+			}
+		}
+	}
+
+	return m
+}
+
 func init() {
 	maps.Copy(symbolsMap, newMelodyNotesMap())
+	maps.Copy(noteFlagMap, getMelodyNoteFlags())
 }
@@ -1,25 +1,28 @@
 package symbolmapper
 
+import (
+	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/pitch"
+	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/symbols/movement"
+)
+
 func init() {
-	cl := []string{
-		"crunl",
-		"crunlb",
-		"hcrunlla",
-		"hcrunllg",
-		"hcrunllgla",
-		"pc",
-		"pcb",
-		"phcla",
-		"crunlbrea",
-		"crunlbbrea",
-		"hcrunllabrea",
-		"pcbrea",
-		"pcbbrea",
-		"phclabrea",
-		"pcmb",
-		"pcmc",
-		"pcmd",
-	}
+	symbolsMap["crunl"] = newMovement(movement.Type_Crunluath)
+	symbolsMap["crunlb"] = newMovement(movement.Type_Crunluath, pitch.Pitch_B)
+	symbolsMap["hcrunlla"] = newMovement(movement.Type_Crunluath, movement.Variant_Half, pitch.Pitch_LowA)
+	symbolsMap["hcrunllg"] = newMovement(movement.Type_Crunluath, movement.Variant_Half, pitch.Pitch_LowG)
+	symbolsMap["hcrunllgla"] = newMovement(movement.Type_Crunluath, movement.Variant_Half, pitch.Pitch_LowA, pitch.Pitch_LowG)
+	symbolsMap["pc"] = newMovement(movement.Type_Crunluath, true, pitch.Pitch_C)
+	symbolsMap["pcb"] = newMovement(movement.Type_Crunluath, true, pitch.Pitch_B)
+	symbolsMap["phcla"] = newMovement(movement.Type_Crunluath, true, movement.Variant_Half, pitch.Pitch_LowA)
+
+	symbolsMap["crunlbrea"] = newMovement(movement.Type_Crunluath, breabach)
+	symbolsMap["crunlbbrea"] = newMovement(movement.Type_Crunluath, breabach, pitch.Pitch_B)
+	symbolsMap["hcrunllabrea"] = newMovement(movement.Type_Crunluath, breabach, movement.Variant_Half, pitch.Pitch_LowA)
+	symbolsMap["pcbrea"] = newMovement(movement.Type_Crunluath, true, breabach, pitch.Pitch_C)
+	symbolsMap["pcbbrea"] = newMovement(movement.Type_Crunluath, true, breabach, pitch.Pitch_B)
+	symbolsMap["phclabrea"] = newMovement(movement.Type_Crunluath, true, breabach, movement.Variant_Half, pitch.Pitch_LowA)
 
-	piobSymbols = append(piobSymbols, cl...)
+	symbolsMap["pcmb"] = newMovement(movement.Type_Crunluath, true, aMach, pitch.Pitch_B)
+	symbolsMap["pcmc"] = newMovement(movement.Type_Crunluath, true, aMach, pitch.Pitch_C)
+	symbolsMap["pcmd"] = newMovement(movement.Type_Crunluath, true, aMach, pitch.Pitch_D)
 }
@@ -1,15 +1,18 @@
 package symbolmapper
 
+import (
+	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/symbols/movement"
+)
+
 func init() {
-	dd := []string{
-		"darodo",
-		"darodo16",
-		"hdarodo",
+	dd := map[string]movement.Variant{
+		"darodo":   movement.Variant_NoVariant,
+		"darodo16": movement.Variant_LongLowG,
+		"hdarodo":  movement.Variant_Half,
 	}
 
-	for _, s := range dd {
-		dd = append(dd, "p"+s)
+	for s, v := range dd {
+		symbolsMap[s] = newMovement(movement.Type_Darodo, v)
+		symbolsMap["p"+s] = newMovement(movement.Type_Darodo, true, v)
 	}
-
-	piobSymbols = append(piobSymbols, dd...)
 }
@@ -1,7 +1,11 @@
 package symbolmapper
 
+import (
+	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/symbols/movement"
+)
+
 func init() {
 	for _, p := range lowPitchesLgToHA {
-		piobSymbols = append(piobSymbols, "echo"+p)
+		symbolsMap["echo"+p] = newMovement(movement.Type_EchoBeat, movementPitch(lowPitchToPitch[p]))
 	}
 }
@@ -1,22 +1,25 @@
 package symbolmapper
 
-import "fmt"
+import (
+	"fmt"
+	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/symbols/movement"
+)
 
 func init() {
-	grps := []string{
-		"enbain",
-		"otro",
-		"odro",
-		"adeda",
+	grps := map[string]movement.Type{
+		"enbain": movement.Type_Enbain,
+		"otro":   movement.Type_Otro,
+		"odro":   movement.Type_Odro,
+		"adeda":  movement.Type_Adeda,
 	}
 
-	for _, s := range grps {
-		grps = append(grps, fmt.Sprintf("p%s", s))
-		grps = append(grps, fmt.Sprintf("g%s", s))
-		grps = append(grps, fmt.Sprintf("t%s", s))
+	for s, t := range grps {
+		symbolsMap[s] = newMovement(t)
+		symbolsMap[fmt.Sprintf("p%s", s)] = newMovement(t, true)
+		symbolsMap[fmt.Sprintf("g%s", s)] = newMovement(t, movement.Variant_G)
+		symbolsMap[fmt.Sprintf("t%s", s)] = newMovement(t, movement.Variant_Thumb)
 	}
 
-	grps = append(grps, "pgrp", "deda")
-
-	piobSymbols = append(piobSymbols, grps...)
+	symbolsMap["pgrp"] = newMovement(movement.Type_Grip, true)
+	symbolsMap["deda"] = newMovement(movement.Type_Deda)
 }
@@ -1,21 +1,24 @@
 package symbolmapper
 
+import (
+	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/pitch"
+	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/symbols/movement"
+)
+
 func init() {
-	ll := []string{
-		"lem",
-		"lemb",
-		"hlemla",
-		"hlemlg",
-		"pl",
-		"plg",
-		"phlla",
-		"lembrea",
-		"lembbrea",
-		"hlemlabrea",
-		"plbrea",
-		"plbbrea",
-		"phllabrea",
-	}
+	symbolsMap["lem"] = newMovement(movement.Type_Lemluath)
+	symbolsMap["lemb"] = newMovement(movement.Type_Lemluath, pitch.Pitch_B)
+	symbolsMap["hlemla"] = newMovement(movement.Type_Lemluath, movement.Variant_Half, pitch.Pitch_LowA)
+	symbolsMap["hlemlg"] = newMovement(movement.Type_Lemluath, movement.Variant_Half, pitch.Pitch_LowG)
+	symbolsMap["pl"] = newMovement(movement.Type_Lemluath, true)
+	symbolsMap["plb"] = newMovement(movement.Type_Lemluath, true, pitch.Pitch_B)
+	symbolsMap["plg"] = newMovement(movement.Type_Lemluath, true, pitch.Pitch_LowG)
+	symbolsMap["phlla"] = newMovement(movement.Type_Lemluath, true, movement.Variant_Half, pitch.Pitch_LowA)
 
-	piobSymbols = append(piobSymbols, ll...)
+	symbolsMap["lembrea"] = newMovement(movement.Type_Lemluath, breabach)
+	symbolsMap["lembbrea"] = newMovement(movement.Type_Lemluath, breabach, pitch.Pitch_B)
+	symbolsMap["hlemlabrea"] = newMovement(movement.Type_Lemluath, breabach, movement.Variant_Half, pitch.Pitch_LowA)
+	symbolsMap["plbrea"] = newMovement(movement.Type_Lemluath, true, breabach)
+	symbolsMap["plbbrea"] = newMovement(movement.Type_Lemluath, true, breabach, pitch.Pitch_B)
+	symbolsMap["phllabrea"] = newMovement(movement.Type_Lemluath, true, breabach, movement.Variant_Half, pitch.Pitch_LowA)
 }
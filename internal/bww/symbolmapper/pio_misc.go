@@ -1,11 +1,13 @@
 package symbolmapper
 
+import (
+	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/symbols/movement"
+)
+
 func init() {
-	piobSymbols = append(piobSymbols,
-		"hiharin",
-		"rodin",
-		"chelalho",
-		"din",
-		"phiharin",
-	)
+	symbolsMap["hiharin"] = newMovement(movement.Type_Hiharin)
+	symbolsMap["rodin"] = newMovement(movement.Type_Rodin)
+	symbolsMap["chelalho"] = newMovement(movement.Type_Chelalho)
+	symbolsMap["din"] = newMovement(movement.Type_Din)
+	symbolsMap["phiharin"] = newMovement(movement.Type_Hiharin, true)
 }
@@ -1,22 +1,25 @@
 package symbolmapper
 
+import (
+	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/pitch"
+	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/symbols/movement"
+)
+
 func init() {
-	tl := []string{
-		"htarla",
-		"htarlg",
-		"pt",
-		"ptb",
-		"phtla",
-		"tarbrea",
-		"tarbbrea",
-		"htarlabrea",
-		"ptbrea",
-		"ptbbrea",
-		"phtlabrea",
-		"ptmb",
-		"ptmc",
-		"ptmd",
-	}
+	symbolsMap["htarla"] = newMovement(movement.Type_Taorluath, movement.Variant_Half, pitch.Pitch_LowA)
+	symbolsMap["htarlg"] = newMovement(movement.Type_Taorluath, movement.Variant_Half, pitch.Pitch_LowG)
+	symbolsMap["pt"] = newMovement(movement.Type_Taorluath, true)
+	symbolsMap["ptb"] = newMovement(movement.Type_Taorluath, true, pitch.Pitch_B)
+	symbolsMap["phtla"] = newMovement(movement.Type_Taorluath, true, movement.Variant_Half, pitch.Pitch_LowA)
+
+	symbolsMap["tarbrea"] = newMovement(movement.Type_Taorluath, breabach)
+	symbolsMap["tarbbrea"] = newMovement(movement.Type_Taorluath, breabach, pitch.Pitch_B)
+	symbolsMap["htarlabrea"] = newMovement(movement.Type_Taorluath, breabach, movement.Variant_Half, pitch.Pitch_LowA)
+	symbolsMap["ptbrea"] = newMovement(movement.Type_Taorluath, true, breabach)
+	symbolsMap["ptbbrea"] = newMovement(movement.Type_Taorluath, true, breabach, pitch.Pitch_B)
+	symbolsMap["phtlabrea"] = newMovement(movement.Type_Taorluath, true, breabach, movement.Variant_Half, pitch.Pitch_LowA)
 
-	piobSymbols = append(piobSymbols, tl...)
+	symbolsMap["ptmb"] = newMovement(movement.Type_Taorluath, true, aMach, pitch.Pitch_B)
+	symbolsMap["ptmc"] = newMovement(movement.Type_Taorluath, true, aMach, pitch.Pitch_C)
+	symbolsMap["ptmd"] = newMovement(movement.Type_Taorluath, true, aMach, pitch.Pitch_D)
 }
@@ -1,9 +1,14 @@
 package symbolmapper
 
+import (
+	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/symbols/movement"
+)
+
 func init() {
 	for _, p := range lowPitchesLgToC {
-		piobSymbols = append(piobSymbols, "ptrip"+p)
-		piobSymbols = append(piobSymbols, "pttrip"+p)
-		piobSymbols = append(piobSymbols, "phtrip"+p)
+		mp := movementPitch(lowPitchToPitch[p])
+		symbolsMap["ptrip"+p] = newMovement(movement.Type_Tripling, true, mp)
+		symbolsMap["pttrip"+p] = newMovement(movement.Type_Tripling, true, movement.Variant_Thumb, mp)
+		symbolsMap["phtrip"+p] = newMovement(movement.Type_Tripling, true, movement.Variant_Half, mp)
 	}
 }
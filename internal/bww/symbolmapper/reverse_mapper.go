@@ -0,0 +1,479 @@
+package symbolmapper
+
+import (
+	"cmp"
+	"fmt"
+	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/barline"
+	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/measure"
+	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/pitch"
+	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/symbols"
+	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/symbols/accidental"
+	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/symbols/tie"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/common"
+	"google.golang.org/protobuf/proto"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// tokenEntry is a token of the symbol tables together with the information
+// that is encoded in the token but not in the symbol it maps to.
+type tokenEntry struct {
+	token string
+	// melodyPitch is the pitch of the melody note that is encoded as suffix
+	// in tokens like dots, fermatas or doublings.
+	melodyPitch pitch.Pitch
+	flag        common.NoteFlag
+}
+
+// tokenFamily contains all tokens that map to the same symbol ordered by preference.
+type tokenFamily struct {
+	entries []tokenEntry
+	// pitchDependent is true if the token has to be chosen by the pitch
+	// of the melody note.
+	pitchDependent bool
+}
+
+// reverseTables are generated from the symbol, barline and time signature
+// tables and map a symbol back to its tokens.
+type reverseTables struct {
+	symbols  map[string]*tokenFamily
+	barlines map[string][]string
+	timeSigs map[string][]string
+}
+
+// reverseLookup builds the reverse tables on first use, as all tables have to be
+// filled by the init functions beforehand.
+var reverseLookup = sync.OnceValues(newReverseTables)
+
+func (m *Mapper) TokensForSymbol(
+	sym *symbols.Symbol,
+	flag common.NoteFlag,
+) ([]string, error) {
+	rt, err := reverseLookup()
+	if err != nil {
+		return nil, err
+	}
+
+	if sym.Note != nil {
+		return rt.tokensForNote(sym.Note, flag)
+	}
+
+	part := symbolWithoutTexts(sym)
+	if part == nil {
+		return nil, common.ErrSymbolNotFound
+	}
+
+	tok, err := rt.tokenForSymbol(part, pitch.Pitch_NoPitch, common.NoFlag)
+	if err != nil {
+		return nil, err
+	}
+
+	return []string{tok}, nil
+}
+
+func (m *Mapper) TokenForBarline(
+	bl *barline.Barline,
+	pos common.BarlinePosition,
+) (string, error) {
+	rt, err := reverseLookup()
+	if err != nil {
+		return "", err
+	}
+
+	key, err := barlineKey(bl)
+	if err != nil {
+		return "", err
+	}
+
+	for _, tok := range rt.barlines[key] {
+		if tokenFitsBarlinePosition(tok, pos) {
+			return tok, nil
+		}
+	}
+
+	return "", fmt.Errorf("%w: no token for barline %v", common.ErrSymbolNotFound, bl)
+}
+
+// TokenForNavigation returns the navigation token for a barline time. Barline
+// times that aren't navigation marks, like repeats, have no token.
+func (m *Mapper) TokenForNavigation(
+	t barline.Time,
+) (string, error) {
+	// the tokens are sorted, so that the same token is chosen if several
+	// tokens map to the same barline time
+	for _, tok := range slices.SortedFunc(maps.Keys(navigationMap), compareTokens) {
+		if navigationMap[tok] == t {
+			return tok, nil
+		}
+	}
+
+	return "", fmt.Errorf("%w: no navigation token for barline time %s",
+		common.ErrSymbolNotFound, t.String())
+}
+
+func (m *Mapper) TokenForTimeSignature(
+	ts *measure.TimeSignature,
+) (string, error) {
+	rt, err := reverseLookup()
+	if err != nil {
+		return "", err
+	}
+
+	key, err := messageKey(ts)
+	if err != nil {
+		return "", err
+	}
+
+	toks := rt.timeSigs[key]
+	if len(toks) == 0 {
+		return "", fmt.Errorf("%w: no token for time signature %s",
+			common.ErrSymbolNotFound, ts.DisplayString())
+	}
+
+	// prefer the numeric token over the common time tokens C and C_
+	numeric := fmt.Sprintf("%d_%d", ts.Beats, ts.BeatType)
+	if slices.Contains(toks, numeric) {
+		return numeric, nil
+	}
+
+	return toks[0], nil
+}
+
+func newReverseTables() (*reverseTables, error) {
+	rt := &reverseTables{
+		symbols:  map[string]*tokenFamily{},
+		barlines: map[string][]string{},
+		timeSigs: map[string][]string{},
+	}
+
+	err := rt.addSymbols()
+	if err != nil {
+		return nil, err
+	}
+
+	err = rt.addBarlines()
+	if err != nil {
+		return nil, err
+	}
+
+	err = rt.addTimeSignatures()
+	if err != nil {
+		return nil, err
+	}
+
+	return rt, nil
+}
+
+func (rt *reverseTables) addSymbols() error {
+	for _, tok := range slices.SortedFunc(maps.Keys(symbolsMap), compareTokens) {
+		sym := symbolsMap[tok]
+		// skipped symbols have no music model representation
+		if sym == nil {
+			continue
+		}
+
+		key, err := messageKey(sym)
+		if err != nil {
+			return err
+		}
+
+		fam, ok := rt.symbols[key]
+		if !ok {
+			fam = &tokenFamily{}
+			rt.symbols[key] = fam
+		}
+		fam.entries = append(fam.entries, newTokenEntry(tok, sym))
+	}
+
+	for _, fam := range rt.symbols {
+		fam.pitchDependent = hasDifferentMelodyPitches(fam.entries)
+	}
+
+	return nil
+}
+
+func (rt *reverseTables) addBarlines() error {
+	for _, tok := range slices.SortedFunc(maps.Keys(barlineMap), compareTokens) {
+		key, err := barlineKey(barlineMap[tok])
+		if err != nil {
+			return err
+		}
+		rt.barlines[key] = append(rt.barlines[key], tok)
+	}
+
+	return nil
+}
+
+func (rt *reverseTables) addTimeSignatures() error {
+	for _, tok := range slices.SortedFunc(maps.Keys(timeSignatureMap), compareTokens) {
+		key, err := messageKey(timeSignatureMap[tok])
+		if err != nil {
+			return err
+		}
+		rt.timeSigs[key] = append(rt.timeSigs[key], tok)
+	}
+
+	return nil
+}
+
+// tokensForNote returns the tokens of a merged note in the order they have to
+// appear in a bww file: accidental, embellishment or movement, tie start,
+// melody note, dots, fermata, abbreviated piobaireachd movement and tie end.
+// The tie start has to precede the melody note as it is merged with the following note.
+func (rt *reverseTables) tokensForNote(
+	n *symbols.Note,
+	flag common.NoteFlag,
+) ([]string, error) {
+	parts := append(notePrefixParts(n), noteSuffixParts(n)...)
+	toks := make([]string, 0, len(parts))
+
+	for _, part := range parts {
+		partFlag := common.NoFlag
+		if part.Note.IsValid() {
+			partFlag = flag
+		}
+
+		tok, err := rt.tokenForSymbol(part, n.Pitch, partFlag)
+		if err != nil {
+			return nil, err
+		}
+		toks = append(toks, tok)
+	}
+
+	return toks, nil
+}
+
+func (rt *reverseTables) tokenForSymbol(
+	sym *symbols.Symbol,
+	melodyPitch pitch.Pitch,
+	flag common.NoteFlag,
+) (string, error) {
+	key, err := messageKey(sym)
+	if err != nil {
+		return "", err
+	}
+
+	fam, ok := rt.symbols[key]
+	if !ok {
+		return "", fmt.Errorf("%w: no token for %v", common.ErrSymbolNotFound, sym)
+	}
+
+	tok, ok := fam.token(melodyPitch, flag)
+	if !ok {
+		return "", fmt.Errorf("%w: no token without flag for %v", common.ErrSymbolNotFound, sym)
+	}
+
+	return tok, nil
+}
+
+// token returns the preferred token of the family. If the tokens depend on
+// the pitch of the melody note and there is no token for the given pitch,
+// the preferred token is returned. It returns false if no token fits the
+// flag and the family has no token without a flag.
+func (f *tokenFamily) token(
+	melodyPitch pitch.Pitch,
+	flag common.NoteFlag,
+) (string, bool) {
+	idx := -1
+	if flag != common.NoFlag {
+		idx = slices.IndexFunc(f.entries, func(e tokenEntry) bool {
+			return e.flag == flag
+		})
+	}
+
+	if idx == -1 && f.pitchDependent {
+		idx = slices.IndexFunc(f.entries, func(e tokenEntry) bool {
+			return e.flag == common.NoFlag && e.melodyPitch == melodyPitch
+		})
+	}
+
+	if idx == -1 {
+		idx = slices.IndexFunc(f.entries, func(e tokenEntry) bool {
+			return e.flag == common.NoFlag
+		})
+	}
+	if idx == -1 {
+		return "", false
+	}
+
+	return f.entries[idx].token, true
+}
+
+// notePrefixParts returns the symbols of the tokens that precede a melody note.
+func notePrefixParts(n *symbols.Note) []*symbols.Symbol {
+	var parts []*symbols.Symbol
+
+	if n.Accidental != accidental.Accidental_NoAccidental {
+		parts = append(parts, noteSymbol(&symbols.Note{
+			Accidental: n.Accidental,
+			Pitch:      n.Pitch,
+		}))
+	}
+
+	if n.Embellishment != nil {
+		parts = append(parts, noteSymbol(&symbols.Note{Embellishment: n.Embellishment}))
+	}
+
+	if n.Movement != nil && !common.IsTrailingMovement(n.Movement) {
+		parts = append(parts, noteSymbol(&symbols.Note{Movement: n.Movement}))
+	}
+
+	if n.Tie == tie.Tie_Start {
+		parts = append(parts, noteSymbol(&symbols.Note{Tie: tie.Tie_Start}))
+	}
+
+	return parts
+}
+
+// noteSuffixParts returns the symbols of the melody note and the tokens that
+// follow it.
+func noteSuffixParts(n *symbols.Note) []*symbols.Symbol {
+	var parts []*symbols.Symbol
+
+	if n.IsValid() {
+		parts = append(parts, noteSymbol(&symbols.Note{
+			Pitch:  n.Pitch,
+			Length: n.Length,
+		}))
+	}
+
+	if n.Dots > 0 {
+		parts = append(parts, noteSymbol(&symbols.Note{Dots: n.Dots}))
+	}
+
+	if n.Fermata {
+		parts = append(parts, noteSymbol(&symbols.Note{Fermata: true}))
+	}
+
+	if common.IsTrailingMovement(n.Movement) {
+		parts = append(parts, noteSymbol(&symbols.Note{Movement: n.Movement}))
+	}
+
+	if n.Tie == tie.Tie_End {
+		parts = append(parts, noteSymbol(&symbols.Note{Tie: tie.Tie_End}))
+	}
+
+	return parts
+}
+
+func noteSymbol(n *symbols.Note) *symbols.Symbol {
+	return &symbols.Symbol{
+		Note: n,
+	}
+}
+
+// symbolWithoutTexts returns the rest, tuplet or timeline of the symbol without
+// the comments and inline texts, so that it can be looked up in the reverse tables.
+func symbolWithoutTexts(sym *symbols.Symbol) *symbols.Symbol {
+	switch {
+	case sym.Rest != nil:
+		return &symbols.Symbol{Rest: sym.Rest}
+	case sym.Tuplet != nil:
+		return &symbols.Symbol{Tuplet: sym.Tuplet}
+	case sym.Timeline != nil:
+		return &symbols.Symbol{Timeline: sym.Timeline}
+	}
+
+	return nil
+}
+
+func newTokenEntry(
+	token string,
+	sym *symbols.Symbol,
+) tokenEntry {
+	if sym.Note.IsValid() {
+		return tokenEntry{
+			token: token,
+			flag:  melodyNoteFlag(token),
+		}
+	}
+
+	return tokenEntry{
+		token:       token,
+		melodyPitch: melodyPitchSuffix(token),
+	}
+}
+
+// melodyNoteFlag returns the flag of a melody note token like LAl_8.
+func melodyNoteFlag(token string) common.NoteFlag {
+	pitchAndFlag, _, _ := strings.Cut(token, "_")
+
+	switch {
+	case strings.HasSuffix(pitchAndFlag, "l"):
+		return common.FlagLeft
+	case strings.HasSuffix(pitchAndFlag, "r"):
+		return common.FlagRight
+	}
+
+	return common.NoFlag
+}
+
+// melodyPitchSuffix returns the pitch of the lower case pitch suffix of a token
+// like dbla or 'hg. If the token has no pitch suffix, NoPitch is returned.
+func melodyPitchSuffix(token string) pitch.Pitch {
+	suffix := ""
+	for _, lp := range lowPitchesLgToHA {
+		if strings.HasSuffix(token, lp) && len(lp) > len(suffix) {
+			suffix = lp
+		}
+	}
+
+	return lowPitchToPitch[suffix]
+}
+
+func hasDifferentMelodyPitches(entries []tokenEntry) bool {
+	var found []pitch.Pitch
+	for _, e := range entries {
+		if e.melodyPitch != pitch.Pitch_NoPitch && !slices.Contains(found, e.melodyPitch) {
+			found = append(found, e.melodyPitch)
+		}
+	}
+
+	return len(found) > 1
+}
+
+// tokenFitsBarlinePosition returns true if the barline token can be placed
+// at the given position of a measure. Barlines that begin with a heavy line
+// only fit at the start of a measure, barlines that end with one only at the end.
+func tokenFitsBarlinePosition(
+	token string,
+	pos common.BarlinePosition,
+) bool {
+	if pos == common.LeftBarline {
+		return !strings.HasSuffix(token, "I")
+	}
+
+	return !strings.HasPrefix(token, "I")
+}
+
+// barlineKey returns the lookup key of the barline. A nil barline is
+// a regular barline.
+func barlineKey(bl *barline.Barline) (string, error) {
+	if bl == nil {
+		bl = &barline.Barline{}
+	}
+
+	return messageKey(bl)
+}
+
+// messageKey returns a key for a music model message that is the same
+// for all messages with equal content.
+func messageKey(m proto.Message) (string, error) {
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(m)
+	if err != nil {
+		return "", fmt.Errorf("failed creating lookup key for %v: %v", m, err)
+	}
+
+	return string(data), nil
+}
+
+// compareTokens orders tokens by preference, so shorter tokens come first.
+func compareTokens(a, b string) int {
+	if c := cmp.Compare(len(a), len(b)); c != 0 {
+		return c
+	}
+
+	return strings.Compare(a, b)
+}
@@ -0,0 +1,232 @@
+package symbolmapper
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/barline"
+	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/length"
+	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/measure"
+	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/pitch"
+	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/symbols"
+	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/symbols/accidental"
+	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/symbols/embellishment"
+	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/symbols/movement"
+	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/symbols/tie"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/common"
+	"google.golang.org/protobuf/proto"
+	"strings"
+)
+
+var _ = Describe("Reverse mapping", func() {
+	var mapper *Mapper
+
+	BeforeEach(func() {
+		var err error
+		mapper, err = New()
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	It("should map every symbol of the symbol table back to an equal symbol", func() {
+		for token, sym := range symbolsMap {
+			if sym == nil {
+				continue
+			}
+
+			toks, err := mapper.TokensForSymbol(sym, melodyNoteFlag(token))
+			Expect(err).ShouldNot(HaveOccurred(), token)
+			Expect(toks).Should(HaveLen(1), token)
+			Expect(proto.Equal(symbolsMap[toks[0]], sym)).Should(BeTrue(),
+				"token %s was mapped back to %s", token, toks[0])
+		}
+	})
+
+	It("should map every melody note back to the token with the same flag", func() {
+		for token, sym := range symbolsMap {
+			if sym == nil || !sym.IsValidNote() {
+				continue
+			}
+
+			toks, err := mapper.TokensForSymbol(sym, melodyNoteFlag(token))
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(toks).Should(Equal([]string{token}))
+		}
+	})
+
+	It("should map every barline back to a token for the same position", func() {
+		for token, bl := range barlineMap {
+			pos := common.RightBarline
+			if strings.HasPrefix(token, "I") {
+				pos = common.LeftBarline
+			}
+
+			tok, err := mapper.TokenForBarline(bl, pos)
+			Expect(err).ShouldNot(HaveOccurred(), token)
+			Expect(tok).Should(Equal(token))
+		}
+	})
+
+	It("should map every time signature back to an equal time signature", func() {
+		for token, ts := range timeSignatureMap {
+			tok, err := mapper.TokenForTimeSignature(ts)
+			Expect(err).ShouldNot(HaveOccurred(), token)
+			Expect(proto.Equal(timeSignatureMap[tok], ts)).Should(BeTrue(), token)
+		}
+	})
+
+	It("should prefer numeric time signatures over common time", func() {
+		tok, err := mapper.TokenForTimeSignature(&measure.TimeSignature{
+			Beats:    4,
+			BeatType: 4,
+		})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(tok).Should(Equal("4_4"))
+	})
+
+	It("should return an error for an unknown time signature", func() {
+		_, err := mapper.TokenForTimeSignature(&measure.TimeSignature{
+			Beats:    13,
+			BeatType: 3,
+		})
+		Expect(err).Should(MatchError(common.ErrSymbolNotFound))
+	})
+
+	It("should return no token if a family only has tokens with another flag", func() {
+		fam := &tokenFamily{
+			entries: []tokenEntry{{token: "LAr_8", flag: common.FlagRight}},
+		}
+		_, ok := fam.token(pitch.Pitch_NoPitch, common.FlagLeft)
+		Expect(ok).Should(BeFalse())
+		tok, ok := fam.token(pitch.Pitch_NoPitch, common.FlagRight)
+		Expect(ok).Should(BeTrue())
+		Expect(tok).Should(Equal("LAr_8"))
+	})
+
+	It("should return an error for a barline that has no token", func() {
+		_, err := mapper.TokenForBarline(&barline.Barline{
+			Time: barline.Time_Segno,
+		}, common.LeftBarline)
+		Expect(err).Should(MatchError(common.ErrSymbolNotFound))
+	})
+
+	It("should map every navigation back to its token", func() {
+		for token, t := range navigationMap {
+			tok, err := mapper.TokenForNavigation(t)
+			Expect(err).ShouldNot(HaveOccurred(), token)
+			Expect(tok).Should(Equal(token))
+		}
+	})
+
+	It("should return an error for a barline time that isn't a navigation", func() {
+		_, err := mapper.TokenForNavigation(barline.Time_Repeat)
+		Expect(err).Should(MatchError(common.ErrSymbolNotFound))
+	})
+
+	When("having a merged note", func() {
+		var sym *symbols.Symbol
+
+		BeforeEach(func() {
+			sym = &symbols.Symbol{
+				Note: &symbols.Note{
+					Pitch:      pitch.Pitch_E,
+					Length:     length.Length_Eighth,
+					Dots:       1,
+					Accidental: accidental.Accidental_Flat,
+					Fermata:    true,
+					Tie:        tie.Tie_Start,
+					Embellishment: &embellishment.Embellishment{
+						Type: embellishment.Type_Doubling,
+					},
+				},
+				Comments: []string{"comment"},
+			}
+		})
+
+		It("should return all tokens in order", func() {
+			toks, err := mapper.TokensForSymbol(sym, common.FlagRight)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(toks).Should(Equal([]string{
+				"flate", "dbe", "^ts", "Er_8", "'e", "fermate",
+			}))
+		})
+
+		It("should ignore a flag for a note length without flags", func() {
+			sym.Note.Length = length.Length_Quarter
+			sym.Note.Tie = tie.Tie_End
+			toks, err := mapper.TokensForSymbol(sym, common.FlagLeft)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(toks).Should(Equal([]string{
+				"flate", "dbe", "E_4", "'e", "fermate", "^te",
+			}))
+		})
+	})
+
+	When("having a movement for a melody note", func() {
+		It("should return the movement token before the melody note", func() {
+			toks, err := mapper.TokensForSymbol(&symbols.Symbol{
+				Note: &symbols.Note{
+					Pitch:  pitch.Pitch_LowA,
+					Length: length.Length_Quarter,
+					Movement: &movement.Movement{
+						Type:       movement.Type_Edre,
+						Abbreviate: true,
+						PitchHint:  pitch.Pitch_B,
+					},
+				},
+			}, common.NoFlag)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(toks).Should(Equal([]string{"pedreb", "LA_4"}))
+		})
+	})
+
+	When("having an abbreviated piobaireachd taorluath for a melody note", func() {
+		It("should return the movement token after the melody note", func() {
+			toks, err := mapper.TokensForSymbol(&symbols.Symbol{
+				Note: &symbols.Note{
+					Pitch:   pitch.Pitch_D,
+					Length:  length.Length_Quarter,
+					Fermata: true,
+					Movement: &movement.Movement{
+						Type:       movement.Type_Taorluath,
+						Abbreviate: true,
+						Breabach:   true,
+						PitchHint:  pitch.Pitch_B,
+					},
+				},
+			}, common.NoFlag)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(toks).Should(Equal([]string{"D_4", "fermatd", "ptbbrea"}))
+		})
+	})
+
+	When("having an embellishment without a melody note", func() {
+		It("should return the preferred embellishment token", func() {
+			toks, err := mapper.TokensForSymbol(&symbols.Symbol{
+				Note: &symbols.Note{
+					Embellishment: &embellishment.Embellishment{
+						Type:    embellishment.Type_Grip,
+						Variant: embellishment.Variant_G,
+					},
+				},
+			}, common.NoFlag)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(toks).Should(HaveLen(1))
+			Expect(toks[0]).Should(HavePrefix("ggrp"))
+		})
+	})
+
+	When("having a symbol that isn't in the tables", func() {
+		It("should return an error", func() {
+			_, err := mapper.TokensForSymbol(&symbols.Symbol{
+				Note: &symbols.Note{
+					Pitch:  pitch.Pitch_LowA,
+					Length: length.Length_Quarter,
+					Embellishment: &embellishment.Embellishment{
+						Type:  embellishment.Type_Doubling,
+						Pitch: pitch.Pitch_C,
+					},
+				},
+			}, common.NoFlag)
+			Expect(err).Should(MatchError(common.ErrSymbolNotFound))
+		})
+	})
+})
@@ -1,8 +1,8 @@
 package symbolmapper
 
+import "github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/barline"
+
 func init() {
-	piobSymbols = append(piobSymbols,
-		"segno",
-		"dalsegno",
-	)
+	navigationMap["segno"] = barline.Time_Segno
+	navigationMap["dalsegno"] = barline.Time_Dalsegno
 }
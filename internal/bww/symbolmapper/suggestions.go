@@ -0,0 +1,150 @@
+package symbolmapper
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+)
+
+// maxSuggestions is the maximum number of suggestions for an unknown token.
+const maxSuggestions = 3
+
+// suggester suggests known tokens for an unknown token. The tokens are compared
+// without case and underscores, as the case and the underscore of tokens like
+// LA_4 or dbla are often mixed up in hand-edited files.
+type suggester struct {
+	tokens     []string
+	normalized []string
+}
+
+type suggestion struct {
+	token    string
+	distance int // the edit distance of the normalized tokens
+	rawDist  int // the edit distance of the tokens as they are
+}
+
+// Suggest returns the known tokens that are most similar to the unknown token,
+// the best first. A suggestion is confident, if it is the only known token that
+// differs from the unknown token only by case and underscores.
+func (s *suggester) Suggest(
+	token string,
+) (suggestions []string, confident bool) {
+	normToken := normalizeToken(token)
+	maxDist := maxDistance(normToken)
+
+	var candidates []suggestion
+	for i, known := range s.tokens {
+		if known == token {
+			continue
+		}
+
+		dist := editDistance(normToken, s.normalized[i])
+		if dist > maxDist {
+			continue
+		}
+
+		candidates = append(candidates, suggestion{
+			token:    known,
+			distance: dist,
+			rawDist:  editDistance(token, known),
+		})
+	}
+
+	slices.SortFunc(candidates, compareSuggestions)
+	for i := 0; i < len(candidates) && i < maxSuggestions; i++ {
+		suggestions = append(suggestions, candidates[i].token)
+	}
+
+	confident = len(candidates) > 0 && candidates[0].distance == 0 &&
+		(len(candidates) == 1 || candidates[1].distance > 0)
+
+	return suggestions, confident
+}
+
+func compareSuggestions(a, b suggestion) int {
+	return cmp.Or(
+		cmp.Compare(a.distance, b.distance),
+		cmp.Compare(a.rawDist, b.rawDist),
+		strings.Compare(a.token, b.token),
+	)
+}
+
+// maxDistance returns the maximum edit distance of a suggestion for the token.
+// Short tokens only allow one edit, as otherwise nearly every token would match.
+func maxDistance(normToken string) int {
+	if len(normToken) <= 4 {
+		return 1
+	}
+
+	return 2
+}
+
+func normalizeToken(token string) string {
+	return strings.ToLower(strings.ReplaceAll(token, "_", ""))
+}
+
+// editDistance returns the optimal string alignment distance of a and b,
+// i.e. the number of insertions, deletions, substitutions and transpositions
+// of adjacent characters to get from a to b.
+func editDistance(a, b string) int {
+	al := &alignment{
+		a:        a,
+		b:        b,
+		prevPrev: make([]int, len(b)+1),
+		prev:     make([]int, len(b)+1),
+		curr:     make([]int, len(b)+1),
+	}
+	for j := range al.prev {
+		al.prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		al.curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			al.curr[j] = al.cost(i, j)
+		}
+		al.prevPrev, al.prev, al.curr = al.prev, al.curr, al.prevPrev
+	}
+
+	return al.prev[len(b)]
+}
+
+// alignment holds the rows of the distances of a[:i-2], a[:i-1] and a[:i]
+// to all prefixes of b while calculating the edit distance.
+type alignment struct {
+	a, b     string
+	prevPrev []int
+	prev     []int
+	curr     []int
+}
+
+// cost returns the distance of a[:i] and b[:j].
+func (al *alignment) cost(i, j int) int {
+	subst := 1
+	if al.a[i-1] == al.b[j-1] {
+		subst = 0
+	}
+
+	dist := min(al.prev[j]+1, al.curr[j-1]+1, al.prev[j-1]+subst)
+	if i > 1 && j > 1 && al.a[i-1] == al.b[j-2] && al.a[i-2] == al.b[j-1] {
+		dist = min(dist, al.prevPrev[j-2]+1)
+	}
+
+	return dist
+}
+
+func newSuggester(tables ...[]string) *suggester {
+	s := &suggester{}
+	for _, t := range tables {
+		s.tokens = append(s.tokens, t...)
+	}
+	slices.Sort(s.tokens)
+	s.tokens = slices.Compact(s.tokens)
+
+	s.normalized = make([]string, len(s.tokens))
+	for i, t := range s.tokens {
+		s.normalized[i] = normalizeToken(t)
+	}
+
+	return s
+}
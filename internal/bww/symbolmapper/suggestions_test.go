@@ -0,0 +1,47 @@
+package symbolmapper
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Suggester", func() {
+	var s *suggester
+
+	BeforeEach(func() {
+		s = newSuggester(
+			[]string{"LA_4", "LA_8", "HA_4", "dbla", "dbha", "gstb", "gstc"},
+			[]string{"!", "!t"},
+			[]string{"LA_4"},
+		)
+	})
+
+	DescribeTable("suggestions for an unknown token",
+		func(token string, expected []string, confident bool) {
+			suggestions, conf := s.Suggest(token)
+			Expect(suggestions).Should(Equal(expected))
+			Expect(conf).Should(Equal(confident))
+		},
+		Entry("missing underscore", "LA4", []string{"LA_4", "HA_4", "LA_8"}, true),
+		Entry("wrong case", "la_4", []string{"LA_4", "HA_4", "LA_8"}, true),
+		Entry("trailing underscore", "dbla_", []string{"dbla", "dbha"}, true),
+		Entry("similar tokens", "gstx", []string{"gstb", "gstc"}, false),
+		Entry("no similar token", "xyz", nil, false),
+	)
+
+	It("should not suggest the token itself", func() {
+		suggestions, _ := s.Suggest("LA_4")
+		Expect(suggestions).ShouldNot(ContainElement("LA_4"))
+	})
+
+	DescribeTable("edit distance",
+		func(a, b string, expected int) {
+			Expect(editDistance(a, b)).Should(Equal(expected))
+		},
+		Entry("equal", "dbla", "dbla", 0),
+		Entry("empty", "", "gstb", 4),
+		Entry("substitution", "gstx", "gstb", 1),
+		Entry("transposition", "dlba", "dbla", 1),
+		Entry("insertion and deletion", "LA4", "A_4", 2),
+	)
+})
@@ -0,0 +1,13 @@
+package symbolmapper
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestSymbolmapper(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Symbolmapper Suite")
+}
@@ -20,4 +20,9 @@ func init() {
 
 	symbolsMap["^ts"] = tieStart
 	symbolsMap["^te"] = tieEnd
+
+	// ties in the old format are placed between the two tied notes
+	for _, p := range lowPitchesLgToHA {
+		oldTieTokens = append(oldTieTokens, "^t"+p)
+	}
 }
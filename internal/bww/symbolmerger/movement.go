@@ -1,6 +1,9 @@
 package symbolmerger
 
-import "github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/symbols"
+import (
+	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/symbols"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/common"
+)
 
 type movementMerger struct {
 }
@@ -16,6 +19,15 @@ func (x *movementMerger) MergeSymbols(
 		return true
 	}
 
+	// abbreviated piobaireachd movements like pt are merged with the previous
+	// note, as long as the note doesn't already have a movement
+	if left.IsValidNote() && left.Note.Movement == nil &&
+		right.IsOnlyMovement() && common.IsTrailingMovement(right.Note.Movement) {
+		left.Note.Movement = right.Note.Movement
+
+		return true
+	}
+
 	return false
 }
 
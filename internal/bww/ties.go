@@ -0,0 +1,69 @@
+package bww
+
+import (
+	"fmt"
+	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/measure"
+	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/symbols"
+	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/symbols/tie"
+)
+
+// tieState tracks the ties of a tune while it is converted. It is needed for
+// ties in the old format like LA_4 ^tla LA_4, where the tie symbol is placed
+// between the two tied notes and the tied notes may be in different measures.
+type tieState struct {
+	lastSymbol *symbols.Symbol // the last symbol of the tune so far
+	open       bool            // a tie is started and not yet ended
+	pendingEnd bool            // the next note ends a tie in the old format
+}
+
+// addOldTie starts a tie at the previous note and lets the next note end it.
+// The previous note may be in a previous measure, so a tie at the start of a
+// measure ties the last note of the measure before. If there is no previous
+// note with pitch and length, the tie is skipped and a parser message is added
+// to the measure.
+func (ts *tieState) addOldTie(
+	dest *measure.Measure,
+	token string,
+) {
+	if ts.lastSymbol == nil {
+		dest.ParserMessages = append(dest.ParserMessages, &measure.ParserMessage{
+			Symbol:   token,
+			Severity: measure.Severity_Warning,
+			Text: fmt.Sprintf("tie in old format (%s) must follow a note and "+
+				"can't be the first symbol of the tune", token),
+			Fix: measure.Fix_SkipSymbol,
+		})
+		return
+	}
+
+	if !ts.lastSymbol.IsValidNote() {
+		dest.ParserMessages = append(dest.ParserMessages, &measure.ParserMessage{
+			Symbol:   token,
+			Severity: measure.Severity_Error,
+			Text:     fmt.Sprintf("tie in old format (%s) must follow a note with pitch and length", token),
+			Fix:      measure.Fix_SkipSymbol,
+		})
+		return
+	}
+
+	ts.lastSymbol.Note.Tie = tie.Tie_Start
+	ts.pendingEnd = true
+}
+
+// update has to be called after a symbol was added to the measure. It ends
+// a pending tie in the old format at the added note.
+func (ts *tieState) update(dest *measure.Measure) {
+	ts.lastSymbol = dest.LastSymbol()
+	if ts.pendingEnd && ts.lastSymbol.IsValidNote() {
+		ts.lastSymbol.Note.Tie = tie.Tie_End
+		ts.pendingEnd = false
+	}
+
+	// a tie start without a note also starts a tie as it is merged with the next note
+	switch ts.lastSymbol.GetNote().GetTie() {
+	case tie.Tie_Start:
+		ts.open = true
+	case tie.Tie_End:
+		ts.open = false
+	}
+}
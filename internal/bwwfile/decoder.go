@@ -0,0 +1,181 @@
+package bwwfile
+
+import (
+	"bufio"
+	"fmt"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/ianaindex"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+	"io"
+	"unicode/utf8"
+)
+
+// sniffSize is the number of bytes at the start of a file that are used to
+// detect the encoding of a file that is read from an io.Reader.
+const sniffSize = 64 * 1024
+
+// Decoder converts the data of bww files into UTF-8 with \n or \r\n line endings.
+// Files with a byte order mark are decoded as UTF-8 or UTF-16 accordingly. Without one,
+// the encoding is the override encoding or, if there is none, it is detected:
+// UTF-16 without byte order mark is detected by the zero bytes of the ASCII characters
+// and data that isn't valid UTF-8 is decoded as Windows-1252, as Bagpipe Music Writer is a
+// Windows program. Lone \r line endings of classic Mac files are converted into \n.
+type Decoder struct {
+	override encoding.Encoding
+}
+
+// Decode returns the data converted into UTF-8.
+func (d *Decoder) Decode(data []byte) ([]byte, error) {
+	enc := d.encoding(data, true)
+	out, _, err := transform.Bytes(decodingTransformer(enc), data)
+	if err != nil {
+		return nil, fmt.Errorf("failed decoding file data: %w", err)
+	}
+
+	return out, nil
+}
+
+// NewReader returns a reader that converts the data of r into UTF-8.
+// The encoding is detected from the start of the data.
+func (d *Decoder) NewReader(r io.Reader) io.Reader {
+	br := bufio.NewReaderSize(r, sniffSize)
+	prefix, err := br.Peek(sniffSize)
+	enc := d.encoding(prefix, err != nil)
+
+	return transform.NewReader(br, decodingTransformer(enc))
+}
+
+func (d *Decoder) encoding(
+	data []byte,
+	complete bool,
+) encoding.Encoding {
+	if d.override != nil {
+		return d.override
+	}
+
+	return detectEncoding(data, complete)
+}
+
+// detectEncoding returns the encoding of data without byte order mark. If the data
+// isn't complete, i.e. only the start of a file, an incomplete UTF-8 character at the
+// end of it is ignored.
+func detectEncoding(
+	data []byte,
+	complete bool,
+) encoding.Encoding {
+	if len(data) >= 2 && data[0] != 0 && data[1] == 0 {
+		return unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM)
+	}
+	if len(data) >= 2 && data[0] == 0 && data[1] != 0 {
+		return unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM)
+	}
+
+	if !complete {
+		data = trimIncompleteRune(data)
+	}
+	if utf8.Valid(data) {
+		return unicode.UTF8
+	}
+
+	return charmap.Windows1252
+}
+
+// trimIncompleteRune removes a UTF-8 character at the end of data that
+// misses some of its bytes.
+func trimIncompleteRune(data []byte) []byte {
+	for i := 1; i < utf8.UTFMax && i <= len(data); i++ {
+		start := len(data) - i
+		if !utf8.RuneStart(data[start]) {
+			continue
+		}
+
+		if !utf8.FullRune(data[start:]) {
+			return data[:start]
+		}
+
+		break
+	}
+
+	return data
+}
+
+// decodingTransformer returns the transformer that decodes data of the encoding
+// into UTF-8. A byte order mark overrides the encoding and is removed.
+func decodingTransformer(enc encoding.Encoding) transform.Transformer {
+	return transform.Chain(
+		unicode.BOMOverride(enc.NewDecoder()),
+		&lineEndingTransformer{},
+	)
+}
+
+// lineEndingTransformer converts lone \r line endings into \n. The \r\n line
+// endings are kept.
+type lineEndingTransformer struct {
+	transform.NopResetter
+}
+
+func (t *lineEndingTransformer) Transform(
+	dst, src []byte,
+	atEOF bool,
+) (int, int, error) {
+	nDst, nSrc := 0, 0
+	for nSrc < len(src) {
+		if nDst >= len(dst) {
+			return nDst, nSrc, transform.ErrShortDst
+		}
+
+		c, ok := lineEndingByte(src[nSrc:], atEOF)
+		if !ok {
+			return nDst, nSrc, transform.ErrShortSrc
+		}
+
+		dst[nDst] = c
+		nDst++
+		nSrc++
+	}
+
+	return nDst, nSrc, nil
+}
+
+// lineEndingByte returns the first byte of src with a lone \r converted into \n.
+// It returns false if the next byte is needed to know if a \r is a \r\n.
+func lineEndingByte(
+	src []byte,
+	atEOF bool,
+) (byte, bool) {
+	if src[0] != '\r' {
+		return src[0], true
+	}
+	if len(src) == 1 {
+		return '\n', atEOF
+	}
+	if src[1] == '\n' {
+		return '\r', true
+	}
+
+	return '\n', true
+}
+
+// EncodingByName returns the encoding with the IANA name, like windows-1252,
+// ISO-8859-1 or UTF-16LE.
+func EncodingByName(name string) (encoding.Encoding, error) {
+	enc, err := ianaindex.IANA.Encoding(name)
+	if err != nil {
+		return nil, err
+	}
+	if enc == nil {
+		return nil, fmt.Errorf("encoding %s is not supported", name)
+	}
+
+	return enc, nil
+}
+
+// NewDecoder returns a decoder that decodes data without byte order mark with
+// the override encoding. If it is nil, the encoding is detected.
+func NewDecoder(override encoding.Encoding) *Decoder {
+	return &Decoder{
+		override: override,
+	}
+}
@@ -0,0 +1,88 @@
+package bwwfile
+
+import (
+	"bytes"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+	"io"
+	"strings"
+	"testing/iotest"
+)
+
+const encodedTitle = "\"Máiri’s Wedding\",(T,L,0,0,Times New Roman,16,700,0,0,18,0,0,0)\n"
+
+func encode(
+	enc encoding.Encoding,
+	s string,
+) []byte {
+	data, err := enc.NewEncoder().Bytes([]byte(s))
+	Expect(err).ShouldNot(HaveOccurred())
+	return data
+}
+
+var _ = Describe("Decoder", func() {
+	DescribeTable("decoding data",
+		func(override encoding.Encoding, data []byte, expected string) {
+			d := NewDecoder(override)
+			decoded, err := d.Decode(data)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(string(decoded)).Should(Equal(expected))
+
+			// the reader reads byte by byte to check that no character is split
+			read, err := io.ReadAll(d.NewReader(iotest.OneByteReader(bytes.NewReader(data))))
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(string(read)).Should(Equal(expected))
+		},
+		Entry("UTF-8",
+			nil, []byte(encodedTitle), encodedTitle,
+		),
+		Entry("UTF-8 with byte order mark",
+			nil, append([]byte{0xEF, 0xBB, 0xBF}, encodedTitle...), encodedTitle,
+		),
+		Entry("UTF-16 little endian with byte order mark",
+			nil, encode(unicode.UTF16(unicode.LittleEndian, unicode.UseBOM), encodedTitle), encodedTitle,
+		),
+		Entry("UTF-16 big endian with byte order mark",
+			nil, encode(unicode.UTF16(unicode.BigEndian, unicode.UseBOM), encodedTitle), encodedTitle,
+		),
+		Entry("UTF-16 little endian without byte order mark",
+			nil, encode(unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM), encodedTitle), encodedTitle,
+		),
+		Entry("Windows-1252",
+			nil, encode(charmap.Windows1252, encodedTitle), encodedTitle,
+		),
+		Entry("Latin-1 as override",
+			charmap.ISO8859_1, []byte("\"M\xe1iri\",(T)\n"), "\"Máiri\",(T)\n",
+		),
+		Entry("a byte order mark with an override",
+			charmap.ISO8859_1, append([]byte{0xEF, 0xBB, 0xBF}, encodedTitle...), encodedTitle,
+		),
+		Entry("lone \\r line endings",
+			nil, []byte("Bagpipe Reader:1.0\r& C_4 !t\r"), "Bagpipe Reader:1.0\n& C_4 !t\n",
+		),
+		Entry("\\r\\n line endings",
+			nil, []byte("Bagpipe Reader:1.0\r\n& C_4 !t\r\n"), "Bagpipe Reader:1.0\r\n& C_4 !t\r\n",
+		),
+		Entry("empty data",
+			nil, []byte{}, "",
+		),
+	)
+
+	It("should detect UTF-8 with a character that is cut off at the end of the start of a file", func() {
+		data := []byte(strings.Repeat("a", sniffSize-1) + "á")
+		Expect(detectEncoding(data[:sniffSize], false)).Should(Equal(unicode.UTF8))
+		Expect(detectEncoding(data[:sniffSize], true)).Should(Equal(charmap.Windows1252))
+	})
+
+	It("should return the encoding for an IANA name", func() {
+		enc, err := EncodingByName("windows-1252")
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(enc).Should(Equal(charmap.Windows1252))
+
+		_, err = EncodingByName("no-encoding")
+		Expect(err).Should(HaveOccurred())
+	})
+})
@@ -1,430 +1,458 @@
 package bwwfile
 
 import (
-	"errors"
 	"fmt"
+	"github.com/alecthomas/participle/v2/lexer"
+	"github.com/rs/zerolog/log"
+	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/measure"
 	"github.com/tomvodi/limepipes-plugin-bww/internal/common"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/cst"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/diagnostics"
 	"github.com/tomvodi/limepipes-plugin-bww/internal/filestructure"
-	"regexp"
-	"slices"
 	"strconv"
 	"strings"
 )
 
-type ParserState uint8
-
-const (
-	FileState  ParserState = iota // Top level state
-	StaffState                    // State for music symbols between & and!t
-)
-
-const StaffStart = "&"
-
-var bpDefRegex = regexp.MustCompile(`(Bagpipe Reader|Bagpipe Music Writer Gold|Bagpipe Musicworks Gold):\d+\.\d+`)
-var descRegex = regexp.MustCompile(`"([^"]*)",\(([TYMFI])[^)\n]+\)`)
-var tokenRegex = regexp.MustCompile(`"([^"]*)",\(I(,[^,)]+)+\)|"([^"]*)"|\S+`)
-var commentRegex = regexp.MustCompile(`"([^"]*)"$`)
-var staffEndRegex = regexp.MustCompile(`^(''!I|!t|!I)$`)
-var barlineRegex = regexp.MustCompile(`^(!|I!''|I!)$`)
-var metaRegex = regexp.MustCompile(`^(MIDINoteMappings|FrequencyMappings|InstrumentMappings|GracenoteDurations|FontSizes|TuneFormat)`)
-var tuneTempoRegex = regexp.MustCompile(`^TuneTempo,(\d+)$`)
+const tuneTempoPrefix = "TuneTempo,"
 
+// Tokenizer parses bww files with the bww grammar and returns the elements
+// of the file as tokens with their positions.
 type Tokenizer struct {
-	state    ParserState
-	currLine int
+	mode common.ParseMode
 }
 
 func (t *Tokenizer) Tokenize(
 	data []byte,
 ) ([]*common.Token, error) {
-	t.state = FileState
-	t.currLine = 0
-
-	if len(data) == 0 {
-		return nil, fmt.Errorf("empty data")
-	}
-
-	// for windows file endings
-	cleanData := strings.ReplaceAll(string(data), "\r\n", "\n")
-
-	lines := strings.Split(cleanData, "\n")
-
-	var allTokens []*common.Token
-	for _, line := range lines {
-		lineTokens, err := t.TokenizeLine(line)
-		if errors.Is(err, common.ErrLineSkip) {
-			t.currLine++
-			continue
-		}
-		if err != nil {
-			return nil, fmt.Errorf("error tokenizing line %d: %w", t.currLine, err)
-		}
-
-		allTokens = append(allTokens, lineTokens...)
-
-		t.currLine++
-	}
-
-	allTokens = t.checkAndModifyTokensForStaffComments(allTokens)
-
-	return allTokens, nil
+	return t.TokenizeChunk(common.NewFileChunk(data))
 }
 
-func (t *Tokenizer) TokenizeLine(
-	line string,
+// TokenizeChunk tokenizes a chunk of a file. The tokens get the lines of
+// the file, not the ones of the chunk data.
+// The problems of the grammar items are returned as diagnostics.List together
+// with the tokens of the other items, so that the later stages can still report
+// their problems. If the data can't be parsed with the grammar, no tokens are returned.
+func (t *Tokenizer) TokenizeChunk(
+	chunk *common.FileChunk,
 ) ([]*common.Token, error) {
-	trimLine := strings.TrimSpace(line)
-	if trimLine == "" {
-		return nil, common.ErrLineSkip
-	}
-
-	if strings.HasPrefix(trimLine, StaffStart) {
-		t.state = StaffState
-	}
-
-	lineTokens, err := t.getTokensFromLine(line)
+	e, g, err := t.parseChunk(chunk)
 	if err != nil {
 		return nil, err
 	}
 
-	if lineTokensEndStaff(lineTokens) {
-		t.state = FileState
-	}
-
-	return lineTokens, nil
+	return t.emitTokens(e, g)
 }
 
-// lineTokensEndStaff checks if the last token in the slice is a StaffEnd token or
-// if the last token is a dalsegno or dacapoalfine and the penultimate token is a StaffEnd token.
-func lineTokensEndStaff(tokens TuneTokens) bool {
-	if len(tokens) == 0 {
-		return false
-	}
-
-	lastToken := tokens[len(tokens)-1].Value
-	_, lastTokenIsStaffEnd := lastToken.(filestructure.StaffEnd)
-
-	if lastTokenIsStaffEnd {
-		return true
-	}
-
-	// check for staff end followed by dalsegno or dacapoalfine
-	if len(tokens) <= 1 {
-		return false
+// TokenizeWithTree tokenizes the data like Tokenize and returns the lossless
+// syntax tree of the data as well, which is built from the same grammar items
+// as the tokens. The syntax tree is also returned if the tokens have problems,
+// so that tooling can work with files that have errors.
+func (t *Tokenizer) TokenizeWithTree(
+	data []byte,
+) ([]*common.Token, *cst.File, error) {
+	e, g, err := t.parseChunk(common.NewFileChunk(data))
+	if err != nil {
+		return nil, nil, err
 	}
 
-	penultimateToken := tokens[len(tokens)-2].Value
-	_, penultimateTokenIsStaffEnd := penultimateToken.(filestructure.StaffEnd)
-
-	_, lastTokenIsDalSegno := lastToken.(filestructure.DalSegno)
-	_, lastTokenIsDaCapoAlFine := lastToken.(filestructure.DacapoAlFine)
-
-	if penultimateTokenIsStaffEnd && (lastTokenIsDalSegno || lastTokenIsDaCapoAlFine) {
-		return true
+	tree, err := buildSyntaxTree(e.src, g, e.lineStarts)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed building syntax tree: %w", err)
 	}
 
-	return false
+	tokens, err := t.emitTokens(e, g)
+	return tokens, tree, err
 }
 
-func containsStaffEnd(tokens []*common.Token) bool {
-	for _, tok := range tokens {
-		if _, ok := tok.Value.(filestructure.StaffEnd); ok {
-			return true
+// parseChunk parses the chunk data with the bww grammar and returns
+// the emitter for its tokens.
+func (t *Tokenizer) parseChunk(
+	chunk *common.FileChunk,
+) (*tokenEmitter, *bwwGrammar, error) {
+	data := chunk.Data
+	if len(data) == 0 {
+		return nil, nil, &diagnostics.Diagnostic{
+			Code:     diagnostics.CodeEmptyData,
+			Severity: measure.Severity_Error,
+			Message:  "empty data",
 		}
 	}
 
-	return false
-}
-
-// checkAndModifyTokensForStaffComments
-// A comment and/or inline text right before a staff start is considered a staff comment
-// or staff inline text.
-// This method checks the last tokens before a StaffStart for TuneComment and TuneInline
-// types and changes them to StaffComment and StaffInline respectively.
-func (t *Tokenizer) checkAndModifyTokensForStaffComments(
-	tokens []*common.Token,
-) []*common.Token {
-	for i, tok := range slices.Backward(tokens) {
-		if _, ok := tok.Value.(filestructure.StaffStart); ok {
-			tokens = t.modifyTokensForStaffCommentsAtStaffStart(tokens, i)
-		}
+	src := string(data)
+	g, err := bwwParser.ParseString("", src)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed parsing file with bww grammar: %w", err)
 	}
 
-	return tokens
+	return newTokenEmitter(t.mode, chunk, src), g, nil
 }
 
-// modifyTokensForStaffCommentsAtStaffStart modifies the tokens right before a StaffStart token
-// to StaffComment or StaffInline if they are TuneComment or TuneInline.
-func (t *Tokenizer) modifyTokensForStaffCommentsAtStaffStart(
-	tokens []*common.Token,
-	staffStartIndex int,
-) []*common.Token {
-	x := staffStartIndex - 1
-	y := staffStartIndex - 2
-
-	if x >= 0 {
-		switch v := tokens[x].Value.(type) {
-		case filestructure.TuneComment:
-			tokens[x].Value = filestructure.StaffComment(v)
-		case filestructure.TuneInline:
-			tokens[x].Value = filestructure.StaffInline(v)
-		}
-	}
-	if y >= 0 {
-		switch v := tokens[y].Value.(type) {
-		case filestructure.TuneComment:
-			tokens[y].Value = filestructure.StaffComment(v)
-		case filestructure.TuneInline:
-			tokens[y].Value = filestructure.StaffInline(v)
-		}
+func (t *Tokenizer) emitTokens(
+	e *tokenEmitter,
+	g *bwwGrammar,
+) ([]*common.Token, error) {
+	for _, it := range g.Items {
+		e.addFileItem(it)
 	}
 
-	return tokens
+	return e.tokens, e.diags.Err()
 }
 
-func (t *Tokenizer) getTokensFromLine(
-	line string,
-) ([]*common.Token, error) {
-	switch t.state {
-	case FileState:
-		return t.getFileTokensFromLine(line)
-	case StaffState:
-		tokens, err := t.getStaffTokensFromLine(line)
-		if err != nil {
-			return nil, err
-		}
-
-		if containsStaffEnd(tokens) && !lineTokensEndStaff(tokens) {
-			return nil, fmt.Errorf("staff end token is not at the end of line %d", t.currLine)
-		}
+// tokenEmitter converts the items of the bww grammar into tokens. The problems
+// of the items are collected as diagnostics.
+type tokenEmitter struct {
+	mode       common.ParseMode
+	chunk      *common.FileChunk
+	src        string
+	lineStarts []int // the offsets of the first character of every line
+	tokens     []*common.Token
+	diags      diagnostics.List
+}
 
-		return tokens, nil
+func (e *tokenEmitter) addFileItem(it *fileItem) {
+	switch {
+	case it.Version != "":
+		e.add(filestructure.BagpipePlayerVersion(it.Version), it.Pos)
+	case it.Text != nil:
+		e.addFileText(it)
+	case it.Tempo != "":
+		e.addTempo(it.Tempo, it.Pos, false)
+	case it.Meta != "":
+		e.addMetaData(it)
+	case it.Staff != nil:
+		e.addStaff(it.Staff)
 	default:
-		panic("tokenizer: unhandled parser state")
+		e.addUnknownLine(it.Pos)
 	}
 }
 
-func (t *Tokenizer) getFileTokensFromLine(
-	line string,
-) ([]*common.Token, error) {
-	bpDef := t.isBagpipeDefinition(line)
-	if bpDef != nil {
-		return []*common.Token{bpDef}, nil
+// addFileText adds a text outside of a staff. A text without parameters
+// is a comment of the tune.
+func (e *tokenEmitter) addFileText(it *fileItem) {
+	text := unquote(it.Text.Text)
+	if it.Text.Params == "" {
+		e.add(filestructure.TuneComment(text), it.Pos)
+		return
 	}
 
-	desc := t.isTuneDescription(line)
-	if desc != nil {
-		return []*common.Token{desc}, nil
+	fieldType, style, ok := textParams(it.Text.Params)
+	if !ok {
+		e.addUnknownLine(it.Pos)
+		return
 	}
 
-	comment := t.isComment(line)
-	if comment != nil {
-		return []*common.Token{comment}, nil
+	var val any
+	switch fieldType {
+	case "T":
+		val = filestructure.TuneTitle(text)
+	case "Y":
+		val = filestructure.TuneType(text)
+	case "M":
+		val = filestructure.TuneComposer(text)
+	case "F":
+		val = filestructure.TuneFooter(text)
+	case "I":
+		val = filestructure.TuneInline(text)
+	default:
+		e.addUnknownLine(it.Pos)
+		return
 	}
 
-	tute, err := getTuneTempo(line)
-	if err != nil && !errors.Is(err, common.ErrSymbolNotFound) {
-		return nil, err
-	}
-	if err == nil {
-		return []*common.Token{
-			{
-				Value: filestructure.TuneTempo(tute),
-				Line:  t.currLine,
-				Col:   0,
-			},
-		}, nil
+	e.add(val, it.Pos).Style = style
+}
+
+// addTempo adds the tune tempo or, inside of a staff, a tempo change.
+func (e *tokenEmitter) addTempo(
+	text string,
+	pos lexer.Position,
+	inStaff bool,
+) {
+	tempo, err := parseTuneTempo(text)
+	if err != nil {
+		e.diags.Add(diagnostics.At(err, e.line(pos), e.column(pos)))
+		return
 	}
 
-	if t.isMetaData(line) {
-		return nil, common.ErrLineSkip
+	if inStaff {
+		e.add(filestructure.TempoChange(tempo), pos)
+		return
 	}
 
-	return nil, fmt.Errorf("no file token found for line: '%s'", line)
+	e.add(filestructure.TuneTempo(tempo), pos)
 }
 
-func (t *Tokenizer) isBagpipeDefinition(text string) *common.Token {
-	idx := bpDefRegex.FindIndex([]byte(text))
-	if idx == nil {
-		return nil
-	}
-	bpDef := text[idx[0]:idx[1]]
-	return &common.Token{
-		Value: filestructure.BagpipePlayerVersion(bpDef),
-		Line:  t.currLine,
-		Col:   idx[0],
+// addMetaData adds the token of a meta data block. An invalid block is
+// skipped, so that the tunes of the file can still be parsed, and a warning
+// diagnostic is added as token instead, which tells why the block was skipped.
+func (e *tokenEmitter) addMetaData(it *fileItem) {
+	md, err := parseMetaData(it.Meta)
+	if err != nil {
+		log.Warn().Err(err).Msgf("skipping meta data in line %d", e.line(it.Pos))
+		text := strings.TrimSpace(it.Meta)
+		d := &diagnostics.Diagnostic{
+			Code:     diagnostics.CodeInvalidMetaData,
+			Severity: measure.Severity_Warning,
+			Message:  fmt.Sprintf("skipped meta data block: %s", err.Error()),
+			Text:     text,
+			Fix:      "correct the parameters of the block or remove it",
+		}
+		e.add(d.At(e.line(it.Pos), e.column(it.Pos)), it.Pos)
+		return
 	}
+
+	e.add(md, it.Pos)
 }
 
-func (t *Tokenizer) isTuneDescription(text string) *common.Token {
-	idx := descRegex.FindAllSubmatchIndex([]byte(text), -1)
-	if idx == nil {
-		return nil
+// addUnknownLine adds a diagnostic for the rest of the line that doesn't
+// match the grammar. In lenient mode, the line is skipped with a warning.
+func (e *tokenEmitter) addUnknownLine(pos lexer.Position) {
+	text := strings.TrimSpace(e.restOfLine(pos))
+	if e.mode.IsLenient() {
+		log.Warn().Msgf("skipping unknown line %d: '%s'", e.line(pos), text)
+		return
 	}
 
-	loc := idx[0]
-	var val any
-	desc := text[loc[2]:loc[3]]
-	descType := text[loc[4]:loc[5]]
-	if descType == "T" {
-		val = filestructure.TuneTitle(desc)
-	}
-	if descType == "Y" {
-		val = filestructure.TuneType(desc)
-	}
-	if descType == "M" {
-		val = filestructure.TuneComposer(desc)
-	}
-	if descType == "F" {
-		val = filestructure.TuneFooter(desc)
+	d := &diagnostics.Diagnostic{
+		Code:     diagnostics.CodeUnknownLine,
+		Severity: measure.Severity_Error,
+		Message:  fmt.Sprintf("no file token found for line: '%s'", text),
+		Text:     text,
 	}
-	if descType == "I" {
-		val = filestructure.TuneInline(desc)
+	e.diags.Add(d.At(e.line(pos), e.column(pos)))
+}
+
+func (e *tokenEmitter) addStaff(st *staffItem) {
+	e.addStaffStart(st.Pos)
+	for _, sym := range st.Symbols {
+		e.addStaffSymbol(sym)
 	}
 
-	return &common.Token{
-		Value: val,
-		Line:  t.currLine,
-		Col:   loc[0],
+	if st.End != nil {
+		e.addStaffEnd(st.End)
 	}
 }
 
-func (t *Tokenizer) isInlineText(text string) *common.Token {
-	idx := descRegex.FindAllSubmatchIndex([]byte(text), -1)
-	if idx == nil {
-		return nil
+// addStaffStart adds a staff start. A comment and/or inline text right before
+// a staff start is considered a staff comment or staff inline text, so the last
+// two tokens are changed to StaffComment and StaffInline if they are
+// TuneComment or TuneInline.
+func (e *tokenEmitter) addStaffStart(pos lexer.Position) {
+	for _, tok := range e.tokens[max(len(e.tokens)-2, 0):] {
+		switch v := tok.Value.(type) {
+		case filestructure.TuneComment:
+			tok.Value = filestructure.StaffComment(v)
+		case filestructure.TuneInline:
+			tok.Value = filestructure.StaffInline(v)
+		}
 	}
 
-	loc := idx[0]
-	var val any
-	desc := text[loc[2]:loc[3]]
-	descType := text[loc[4]:loc[5]]
-	if descType == "I" {
-		val = filestructure.InlineText(desc)
-	}
+	e.add(filestructure.StaffStart("&"), pos)
+}
 
-	return &common.Token{
-		Value: val,
-		Line:  t.currLine,
-		Col:   loc[0],
+// revive:disable:cognitive-complexity this method has a high complexity due to the number of different symbol types
+// but it is easy to understand and maintain
+func (e *tokenEmitter) addStaffSymbol(sym *staffSymbol) {
+	switch {
+	case sym.Newline:
+	case sym.Text != nil:
+		e.addStaffText(sym.Text)
+	case sym.Tempo != "":
+		e.addTempo(sym.Tempo, sym.Pos, true)
+	case sym.StaffStart != "":
+		e.addStaffStart(sym.Pos)
+	case sym.Barline != "":
+		e.add(filestructure.Barline(sym.Barline), sym.Pos)
+	case sym.Navigation != "":
+		e.add(navigationValue(sym.Navigation), sym.Pos)
+	case isStaffEnd(sym.Symbol):
+		e.addMisplacedStaffEnd(filestructure.StaffEnd(sym.Symbol), sym.Pos)
+	default:
+		e.add(sym.Symbol, sym.Pos)
 	}
 }
 
-func (t *Tokenizer) isComment(
-	text string,
-) *common.Token {
-	idx := commentRegex.FindAllSubmatchIndex([]byte(text), -1)
-	if idx == nil {
-		return nil
-	}
+// revive:enable:cognitive-complexity
 
-	loc := idx[0]
-	comment := text[loc[2]:loc[3]]
-	tok := &common.Token{
-		Value: filestructure.InlineComment(comment),
-		Line:  t.currLine,
-		Col:   loc[0],
+// addStaffText adds a text inside of a staff. A text without parameters is
+// a comment and a text with the parameters of an inline text is an inline text.
+// Other parameters are added as symbol, so that they are reported as unknown symbol.
+func (e *tokenEmitter) addStaffText(t *textItem) {
+	text := unquote(t.Text)
+	if t.Params == "" {
+		e.add(filestructure.InlineComment(text), t.Pos)
+		return
 	}
 
-	if t.state == FileState {
-		tok.Value = filestructure.TuneComment(comment)
+	fieldType, style, ok := textParams(t.Params)
+	if !ok || fieldType != "I" {
+		e.add(filestructure.InlineComment(text), t.Pos)
+		paramsPos := t.Pos
+		paramsPos.Advance(t.Text)
+		e.add(t.Params, paramsPos)
+		return
 	}
 
-	return tok
-}
-
-func (t *Tokenizer) isMetaData(
-	text string,
-) bool {
-	trimmed := strings.TrimSpace(text)
-	return metaRegex.MatchString(trimmed)
+	e.add(filestructure.InlineText(text), t.Pos).Style = style
 }
 
-func (t *Tokenizer) getStaffTokensFromLine(
-	line string,
-) (tokens []*common.Token, err error) {
-	idxs := tokenRegex.FindAllIndex([]byte(line), -1)
-	for _, idx := range idxs {
-		tokStr := line[idx[0]:idx[1]]
-		currTok := &common.Token{
-			Line: t.currLine,
-			Col:  idx[0],
+// addStaffEnd adds the staff end at the end of a line. Texts and time line ends
+// that follow the staff end belong to the last measure of the staff and are added
+// before it.
+func (e *tokenEmitter) addStaffEnd(se *staffEndItem) {
+	for _, tr := range se.Trailing {
+		switch {
+		case tr.Text != nil:
+			e.addStaffText(tr.Text)
+		case tr.TimelineEnd != "":
+			e.add(tr.TimelineEnd, tr.Pos)
 		}
+	}
 
-		val, err := t.getTokenValueForString(tokStr)
-		if err != nil {
-			return nil, err
+	e.add(filestructure.StaffEnd(se.End), se.Pos)
+	for _, tr := range se.Trailing {
+		if tr.Navigation != "" {
+			e.add(navigationValue(tr.Navigation), tr.Pos)
 		}
-		currTok.Value = val
-
-		tokens = append(tokens, currTok)
 	}
-
-	return tokens, nil
 }
 
-// revive:disable:cognitive-complexity this method has a high complexity due to the number of different token types
-// but it is easy to understand and maintain
-func (t *Tokenizer) getTokenValueForString(
-	tokStr string,
-) (any, error) {
-	if barlineRegex.MatchString(tokStr) {
-		return filestructure.Barline(tokStr), nil
-	}
+// addMisplacedStaffEnd adds a staff end that is not at the end of a line. The
+// symbols after it on the same line are accepted and placed in a new measure, as if
+// they were written on a new line. In strict mode, a warning diagnostic is added as
+// token, which is returned with the warnings of the file. In lenient mode, a parser
+// message token is added before the staff end instead, so that the message is added
+// to the measure that is ended by it.
+func (e *tokenEmitter) addMisplacedStaffEnd(
+	se filestructure.StaffEnd,
+	pos lexer.Position,
+) {
+	d := &diagnostics.Diagnostic{
+		Code:     diagnostics.CodeMisplacedStaffEnd,
+		Severity: measure.Severity_Warning,
+		Message:  fmt.Sprintf("staff end %s is not at the end of the line", se),
+		Text:     string(se),
+		Fix:      "move the symbols after the staff end into a new staff",
+	}
+	d = d.At(e.line(pos), e.column(pos))
+	if !e.mode.IsLenient() {
+		e.add(d, pos)
+		e.add(se, pos)
+		return
+	}
+
+	e.add(filestructure.ParserMessage{
+		Symbol: string(se),
+		Text:   d.Error() + ", the following symbols are placed in a new measure",
+	}, pos)
+	e.add(se, pos)
+}
 
-	if tokStr == StaffStart {
-		return filestructure.StaffStart(tokStr), nil
+func (e *tokenEmitter) add(
+	value any,
+	pos lexer.Position,
+) *common.Token {
+	tok := &common.Token{
+		Value: value,
+		Line:  e.line(pos),
+		Col:   e.column(pos),
 	}
+	e.tokens = append(e.tokens, tok)
 
-	if tokStr == "dalsegno" {
-		return filestructure.DalSegno(tokStr), nil
-	}
+	return tok
+}
 
-	if tokStr == "dacapoalfine" {
-		return filestructure.DacapoAlFine(tokStr), nil
-	}
+// line returns the zero based line of the position in the file.
+func (e *tokenEmitter) line(pos lexer.Position) int {
+	return e.chunk.FileLine(pos.Line - 1)
+}
 
-	if staffEndRegex.MatchString(tokStr) {
-		return filestructure.StaffEnd(tokStr), nil
-	}
+// column returns the zero based column of the position in bytes.
+func (e *tokenEmitter) column(pos lexer.Position) int {
+	return pos.Offset - e.lineStarts[pos.Line-1]
+}
 
-	tute, err := getTuneTempo(tokStr)
-	if err != nil && !errors.Is(err, common.ErrSymbolNotFound) {
-		return nil, err
-	}
-	if err == nil {
-		return filestructure.TempoChange(tute), nil
+// restOfLine returns the text from the position to the end of its line.
+func (e *tokenEmitter) restOfLine(pos lexer.Position) string {
+	rest := e.src[pos.Offset:]
+	if idx := strings.IndexByte(rest, '\n'); idx >= 0 {
+		return rest[:idx]
 	}
 
-	if ct := t.isComment(tokStr); ct != nil {
-		return ct.Value, nil
-	}
+	return rest
+}
 
-	if it := t.isInlineText(tokStr); it != nil {
-		return it.Value, nil
+func navigationValue(nav string) any {
+	switch nav {
+	case "segno":
+		return filestructure.Segno(nav)
+	case "fine":
+		return filestructure.Fine(nav)
+	case "dalsegno":
+		return filestructure.DalSegno(nav)
+	default:
+		return filestructure.DacapoAlFine(nav)
 	}
-
-	return tokStr, nil
 }
 
-// revive:enable:cognitive-complexity
+func unquote(text string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(text, `"`), `"`)
+}
 
-func getTuneTempo(text string) (uint32, error) {
-	idx := tuneTempoRegex.FindAllSubmatchIndex([]byte(text), -1)
-	if len(idx) == 0 {
-		return 0, common.ErrSymbolNotFound
+// textParams returns the field type and the text style of the parameters of
+// a text field like ,(T,L,0,0,Times New Roman,15,700,0,1,2,0,0,32768).
+// It returns false if the parameters have no field type.
+func textParams(
+	params string,
+) (string, *filestructure.TextStyle, bool) {
+	inner := strings.TrimSuffix(strings.TrimPrefix(params, ",("), ")")
+	fieldType, style, found := strings.Cut(inner, ",")
+	if !found || len(fieldType) != 1 {
+		return "", nil, false
 	}
 
-	loc := idx[0]
-	tt := text[loc[2]:loc[3]]
+	return fieldType, parseTextStyle(style), true
+}
+
+func parseTuneTempo(text string) (uint32, error) {
+	tt := strings.TrimPrefix(text, tuneTempoPrefix)
 	tempo, err := strconv.ParseUint(tt, 10, 32)
 	if err != nil {
-		return 0, err
+		return 0, &diagnostics.Diagnostic{
+			Code:     diagnostics.CodeInvalidTempo,
+			Severity: measure.Severity_Error,
+			Message:  fmt.Sprintf("invalid tune tempo %s", tt),
+			Text:     text,
+			Err:      err,
+		}
 	}
 
 	return uint32(tempo), nil
 }
 
-func NewTokenizer() *Tokenizer {
-	return &Tokenizer{}
+func newTokenEmitter(
+	mode common.ParseMode,
+	chunk *common.FileChunk,
+	src string,
+) *tokenEmitter {
+	e := &tokenEmitter{
+		mode:       mode,
+		chunk:      chunk,
+		src:        src,
+		lineStarts: []int{0},
+	}
+	for i, c := range src {
+		if c == '\n' {
+			e.lineStarts = append(e.lineStarts, i+1)
+		}
+	}
+
+	return e
+}
+
+func NewTokenizer(
+	mode common.ParseMode,
+) *Tokenizer {
+	return &Tokenizer{
+		mode: mode,
+	}
 }
@@ -0,0 +1,108 @@
+package bwwfile
+
+import (
+	"github.com/rs/zerolog"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/common"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/filestructure"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// addSeedFiles adds all bww files of the test files of the parser and
+// the file structure to the seed corpus of the fuzz test.
+func addSeedFiles(f *testing.F) {
+	files, err := filepath.Glob("../bww/parser/testfiles/*.bww")
+	if err != nil {
+		f.Fatal(err)
+	}
+	localFiles, err := filepath.Glob("./testfiles/*.bww")
+	if err != nil {
+		f.Fatal(err)
+	}
+
+	for _, file := range append(files, localFiles...) {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(data, false)
+		f.Add(data, true)
+	}
+}
+
+// checkTokenPositions checks that every token is placed inside the data.
+func checkTokenPositions(
+	t *testing.T,
+	data []byte,
+	tokens []*common.Token,
+) {
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	for _, tok := range tokens {
+		if tok.Line < 0 || tok.Line >= len(lines) {
+			t.Fatalf("token %v is in line %d of %d lines", tok.Value, tok.Line, len(lines))
+		}
+		if tok.Col < 0 || tok.Col >= len(lines[tok.Line]) {
+			t.Fatalf("token %v is in column %d of line %d with length %d",
+				tok.Value, tok.Col, tok.Line, len(lines[tok.Line]))
+		}
+	}
+}
+
+// checkMeasureSymbols checks that every symbol of the tune has a text or is a tempo change.
+func checkMeasureSymbols(
+	t *testing.T,
+	tuneIdx int,
+	tune *filestructure.Tune,
+) {
+	for i, m := range tune.Measures {
+		for _, s := range m.Symbols {
+			if s.Text == "" && !s.IsTempoChange() {
+				t.Fatalf("symbol at %v of measure %d of tune %d has no text", s.Pos, i, tuneIdx)
+			}
+		}
+	}
+}
+
+func parseModeFor(lenient bool) common.ParseMode {
+	if lenient {
+		return common.LenientParsing
+	}
+
+	return common.StrictParsing
+}
+
+func FuzzTokenize(f *testing.F) {
+	zerolog.SetGlobalLevel(zerolog.ErrorLevel)
+	addSeedFiles(f)
+
+	f.Fuzz(func(t *testing.T, data []byte, lenient bool) {
+		tokens, tree, err := NewTokenizer(parseModeFor(lenient)).TokenizeWithTree(data)
+		if tree != nil && tree.String() != string(data) {
+			t.Fatalf("syntax tree doesn't reproduce the data: %q", tree.String())
+		}
+		if err != nil {
+			return
+		}
+
+		checkTokenPositions(t, data, tokens)
+	})
+}
+
+func FuzzStructureParse(f *testing.F) {
+	zerolog.SetGlobalLevel(zerolog.ErrorLevel)
+	addSeedFiles(f)
+
+	f.Fuzz(func(t *testing.T, data []byte, lenient bool) {
+		sp := NewStructureParser(NewTokenizer(parseModeFor(lenient)), NewTokenConverter(), NewDecoder(nil))
+		bf, err := sp.ParseDocumentStructure(data)
+		if err != nil {
+			return
+		}
+
+		for i, td := range bf.TuneDefs {
+			checkMeasureSymbols(t, i, td.Tune)
+		}
+	})
+}
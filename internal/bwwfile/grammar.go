@@ -0,0 +1,96 @@
+package bwwfile
+
+import (
+	"github.com/alecthomas/participle/v2"
+	"github.com/alecthomas/participle/v2/lexer"
+)
+
+// bwwLexer splits a bww file into tokens. Everything that isn't whitespace,
+// a line break or one of the more specific tokens is a symbol, so the lexer
+// doesn't fail on any input. A line break may be \n or \r\n, so every byte
+// of a file with Windows line endings is part of a token as well.
+// The symbols that structure a staff, like barlines and staff ends, get their
+// own token types from the staff lexer.
+var bwwLexer = newStaffLexerDefinition(lexer.MustSimple([]lexer.SimpleRule{
+	{Name: "Newline", Pattern: `\r?\n`},
+	{Name: "Whitespace", Pattern: `[^\S\r\n]+|\r`},
+	{Name: "Version", Pattern: `(Bagpipe Reader|Bagpipe Music Writer Gold|Bagpipe Musicworks Gold):\d+\.\d+`},
+	{Name: "Meta", Pattern: `(MIDINoteMappings|FrequencyMappings|InstrumentMappings|GracenoteDurations|FontSizes|TuneFormat),[^\r\n]*`},
+	{Name: "Tempo", Pattern: `TuneTempo,\d+\b`},
+	{Name: "String", Pattern: `"[^"\n]*"`},
+	{Name: "Params", Pattern: `,\([^)\n]*\)`},
+	{Name: "Symbol", Pattern: `\S+`},
+}))
+
+var bwwParser = participle.MustBuild[bwwGrammar](
+	participle.Lexer(bwwLexer.withoutWhitespace()),
+)
+
+// bwwGrammar is the grammar of a bww file. A file consists of lines with the
+// Bagpipe Player version, texts, the tune tempo and meta data blocks
+// and of staves that start with & and end with a staff end.
+// Content that doesn't match the grammar is captured as unknown, so parsing
+// never stops at the first problem of a file.
+type bwwGrammar struct {
+	Items []*fileItem `parser:"( @@ | Newline )*"`
+}
+
+// fileItem is an element of the file outside of a staff.
+type fileItem struct {
+	Pos     lexer.Position
+	Version string     `parser:"@Version"`
+	Text    *textItem  `parser:"| @@"`
+	Tempo   string     `parser:"| @Tempo"`
+	Meta    string     `parser:"| @Meta"`
+	Staff   *staffItem `parser:"| @@"`
+	Unknown []string   `parser:"| @~Newline+"`
+}
+
+// textItem is a quoted text. With parameters it is a text field like the title
+// or an inline text, without parameters it is a comment.
+type textItem struct {
+	Pos    lexer.Position
+	Text   string `parser:"@String"`
+	Params string `parser:"@Params?"`
+}
+
+// staffItem is a staff from the staff start & to the staff end at the end of a line.
+// A staff may span several lines. If the file ends before the staff end, the
+// staff has no end.
+type staffItem struct {
+	Pos     lexer.Position
+	Symbols []*staffSymbol `parser:"StaffStart @@*"`
+	End     *staffEndItem  `parser:"@@?"`
+}
+
+// staffSymbol is an element of a staff. A staff end that isn't at the end of
+// a line is a symbol, it is reported as misplaced staff end. The symbol comes
+// first, as it is the most common element of a staff.
+type staffSymbol struct {
+	Pos        lexer.Position
+	Symbol     string    `parser:"@Symbol"`
+	Newline    bool      `parser:"| @Newline"`
+	Text       *textItem `parser:"| @@"`
+	Tempo      string    `parser:"| @Tempo"`
+	StaffStart string    `parser:"| @StaffStart"`
+	Barline    string    `parser:"| @Barline"`
+	Navigation string    `parser:"| @Navigation"`
+}
+
+// staffEndItem is the end of a staff. It may be followed by a dalsegno or
+// dacapoalfine, by time line ends and by texts on the same line.
+type staffEndItem struct {
+	Pos      lexer.Position
+	End      string            `parser:"@StaffEnd"`
+	Trailing []*staffEndSuffix `parser:"@@*"`
+}
+
+// staffEndSuffix is an element after a staff end on the same line. As the
+// lexer only gives a staff end its type if nothing else follows it, the only
+// symbols after a staff end are time line ends.
+type staffEndSuffix struct {
+	Pos         lexer.Position
+	Navigation  string    `parser:"@Navigation"`
+	TimelineEnd string    `parser:"| @Symbol"`
+	Text        *textItem `parser:"| @@"`
+}
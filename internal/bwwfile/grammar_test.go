@@ -0,0 +1,546 @@
+package bwwfile
+
+import (
+	"fmt"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/measure"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/common"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/diagnostics"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/filestructure"
+	"io"
+	"os"
+)
+
+func dataFromFile(filePath string) []byte {
+	bwwFile, err := os.Open(filePath)
+	Expect(err).ShouldNot(HaveOccurred())
+	var data []byte
+	data, err = io.ReadAll(bwwFile)
+	Expect(err).ShouldNot(HaveOccurred())
+
+	return data
+}
+
+func newToken(value any, line, col int) *common.Token {
+	return &common.Token{
+		Value: value,
+		Line:  line,
+		Col:   col,
+	}
+}
+
+func invalidMetaDataWarning(
+	text string,
+	reason string,
+	line int,
+) *diagnostics.Diagnostic {
+	d := &diagnostics.Diagnostic{
+		Code:     diagnostics.CodeInvalidMetaData,
+		Severity: measure.Severity_Warning,
+		Message:  "skipped meta data block: " + reason,
+		Text:     text,
+		Fix:      "correct the parameters of the block or remove it",
+	}
+
+	return d.At(line, 0)
+}
+
+func newStyledToken(
+	value any,
+	style *filestructure.TextStyle,
+	pos filestructure.Position,
+) *common.Token {
+	tok := newToken(value, pos.Line, pos.Column)
+	tok.Style = style
+
+	return tok
+}
+
+// titleStyle is the style of (T,L,0,0,Times New Roman,15,700,0,1,2,0,0,32768)
+func titleStyle() *filestructure.TextStyle {
+	return &filestructure.TextStyle{
+		Alignment:      filestructure.AlignLeft,
+		FontFace:       "Times New Roman",
+		FontSize:       15,
+		FontWeight:     700,
+		CharSet:        1,
+		PitchAndFamily: 2,
+		Color:          32768,
+	}
+}
+
+// courierStyle is the style of (I,R,0,0,Courier New,12,400,255,0,49,0,0,0)
+func courierStyle() *filestructure.TextStyle {
+	return &filestructure.TextStyle{
+		Alignment:      filestructure.AlignRight,
+		FontFace:       "Courier New",
+		FontSize:       12,
+		FontWeight:     400,
+		Italic:         255,
+		PitchAndFamily: 49,
+	}
+}
+
+//nolint:unused
+func printTokens(tokens []*common.Token) {
+	for _, tok := range tokens {
+		switch t := tok.Value.(type) {
+		case string:
+			_, err := fmt.Printf("newToken(\"%v\", %d, %d),\n", t, tok.Line, tok.Col)
+			Expect(err).ShouldNot(HaveOccurred())
+		default:
+			_, err := fmt.Printf("newToken(%T(\"%v\"), %d, %d),\n", tok.Value, tok.Value, tok.Line, tok.Col)
+			Expect(err).ShouldNot(HaveOccurred())
+		}
+	}
+}
+
+var _ = Describe("Grammar", func() {
+	var ft *Tokenizer
+	var err error
+	var tokens []*common.Token
+	var data []byte
+
+	BeforeEach(func() {
+		ft = NewTokenizer(common.StrictParsing)
+	})
+
+	JustBeforeEach(func() {
+		tokens, err = ft.Tokenize(data)
+	})
+
+	When("tokenize a file with a staff with 4 measures in it", func() {
+		BeforeEach(func() {
+			data = dataFromFile("./testfiles/four_measures.bww")
+		})
+
+		It("should tokenize the file", func() {
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(tokens).Should(BeComparableTo(
+				[]*common.Token{
+					newToken(filestructure.BagpipePlayerVersion("Bagpipe Reader:1.0"), 0, 0),
+					newStyledToken(filestructure.TuneTitle("Tune Title"), titleStyle(), filestructure.Position{Line: 2, Column: 0}),
+					newToken(filestructure.StaffStart("&"), 4, 0),
+					newToken("4_4", 4, 2),
+					newToken(filestructure.Barline("!"), 4, 6),
+					newToken("LA_4", 4, 8),
+					newToken(filestructure.Barline("!"), 4, 13),
+					newToken("B_4", 4, 15),
+					newToken(filestructure.Barline("!"), 4, 19),
+					newToken("C_4", 4, 21),
+					newToken(filestructure.StaffEnd("!t"), 4, 25),
+				}),
+			)
+		})
+	})
+
+	When("tokenize a file with a staff with inline comments", func() {
+		BeforeEach(func() {
+			data = dataFromFile("./testfiles/tune_with_inline_comments.bww")
+		})
+
+		It("should tokenize the file", func() {
+			printTokens(tokens)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(tokens).Should(BeComparableTo(
+				[]*common.Token{
+					newToken(filestructure.BagpipePlayerVersion("Bagpipe Reader:1.0"), 0, 0),
+					newToken(filestructure.TuneComment("just a comment"), 2, 0),
+					newStyledToken(filestructure.TuneTitle("Tune Title"), titleStyle(), filestructure.Position{Line: 4, Column: 0}),
+					newStyledToken(filestructure.TuneInline("tune inline text"), courierStyle(), filestructure.Position{Line: 5, Column: 0}),
+					newToken(filestructure.TuneComment("and another comment"), 6, 0),
+					newStyledToken(filestructure.StaffInline("staff inline text"), courierStyle(), filestructure.Position{Line: 8, Column: 0}),
+					newToken(filestructure.StaffComment("staff comment"), 9, 0),
+					newToken(filestructure.StaffStart("&"), 11, 0),
+					newToken("LA_4", 11, 2),
+					newToken(filestructure.StaffEnd("!t"), 11, 7),
+					newStyledToken(filestructure.StaffInline("staff inline text"), courierStyle(), filestructure.Position{Line: 13, Column: 0}),
+					newToken(filestructure.StaffComment("staff comment"), 14, 0),
+					newToken(filestructure.StaffStart("&"), 16, 0),
+					newToken("D_4", 16, 3),
+					newToken(filestructure.StaffEnd("!I"), 16, 7),
+				}),
+			)
+		})
+	})
+
+	When("tokenize a file with a staff with symbol comments", func() {
+		BeforeEach(func() {
+			data = dataFromFile("./testfiles/tune_with_symbol_comment.bww")
+		})
+
+		It("should tokenize the file", func() {
+			printTokens(tokens)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(tokens).Should(BeComparableTo(
+				[]*common.Token{
+					newToken(filestructure.BagpipePlayerVersion("Bagpipe Reader:1.0"), 0, 0),
+					newStyledToken(filestructure.TuneTitle("Tune Title"), titleStyle(), filestructure.Position{Line: 3, Column: 0}),
+					newToken(filestructure.StaffStart("&"), 6, 0),
+					newToken(filestructure.InlineComment("comment measure"), 6, 2),
+					newToken("LA_4", 6, 20),
+					newToken(filestructure.InlineComment("comment symbol"), 6, 25),
+					newToken(filestructure.Barline("!"), 7, 0),
+					newToken("B_4", 7, 2),
+					newStyledToken(filestructure.InlineText("comment with inline style"),
+						&filestructure.TextStyle{
+							Alignment:  filestructure.AlignLeft,
+							FontFace:   "Times New Roman",
+							FontSize:   11,
+							FontWeight: 700,
+						}, filestructure.Position{Line: 7, Column: 6}),
+					newToken(filestructure.StaffEnd("!t"), 7, 78),
+				}),
+			)
+		})
+	})
+
+	When("tokenize a file with metadata", func() {
+		BeforeEach(func() {
+			data = []byte(`Bagpipe Reader:1.0
+MIDINoteMappings,(54,56,58,59,61,63,64,66,68,56,58,60,61,63,65,66,68,70,55,57,59,60,62,64,65,67,69)
+FrequencyMappings,(370,415,466,494,554,622,659,740,831,415,466,523,554,622,699,740,831,932,392,440,494,523,587,659,699,784,880)
+InstrumentMappings,(71,71,45,33,1000,60,70)
+GracenoteDurations,(20,40,30,50,100,200,800,1200,250,250,250,500,200)
+FontSizes,(90,100,100,80,250)
+TuneFormat,(1,0,M,L,500,500,500,500,P,0,0)
+`)
+		})
+
+		It("should tokenize the file with the metadata", func() {
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(tokens).Should(BeComparableTo(
+				[]*common.Token{
+					newToken(filestructure.BagpipePlayerVersion("Bagpipe Reader:1.0"), 0, 0),
+					newToken(&filestructure.MIDINoteMappings{
+						Natural: filestructure.PitchValues{54, 56, 58, 59, 61, 63, 64, 66, 68},
+						Sharp:   filestructure.PitchValues{56, 58, 60, 61, 63, 65, 66, 68, 70},
+						Flat:    filestructure.PitchValues{55, 57, 59, 60, 62, 64, 65, 67, 69},
+					}, 1, 0),
+					newToken(&filestructure.FrequencyMappings{
+						Natural: filestructure.PitchValues{370, 415, 466, 494, 554, 622, 659, 740, 831},
+						Sharp:   filestructure.PitchValues{415, 466, 523, 554, 622, 699, 740, 831, 932},
+						Flat:    filestructure.PitchValues{392, 440, 494, 523, 587, 659, 699, 784, 880},
+					}, 2, 0),
+					newToken(&filestructure.InstrumentMappings{71, 71, 45, 33, 1000, 60, 70}, 3, 0),
+					newToken(&filestructure.GracenoteDurations{
+						20, 40, 30, 50, 100, 200, 800, 1200, 250, 250, 250, 500, 200,
+					}, 4, 0),
+					newToken(&filestructure.FontSizes{90, 100, 100, 80, 250}, 5, 0),
+					newToken(&filestructure.TuneFormat{
+						LayoutFlags: [2]bool{true, false},
+						LayoutMode:  "M",
+						PageSize:    "L",
+						Margins:     [4]int{500, 500, 500, 500},
+						Orientation: filestructure.OrientationPortrait,
+					}, 6, 0),
+				}),
+			)
+		})
+	})
+
+	When("tokenize a file with invalid metadata", func() {
+		BeforeEach(func() {
+			data = []byte(`Bagpipe Reader:1.0
+InstrumentMappings,(71,71,,33,1000,60,70)
+FontSizes,(90,100,100,80)
+TuneFormat,(1,0,M,L,500,500,500,500,P,0,)
+`)
+		})
+
+		It("should skip the invalid metadata with a warning", func() {
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(tokens).Should(BeComparableTo(
+				[]*common.Token{
+					newToken(filestructure.BagpipePlayerVersion("Bagpipe Reader:1.0"), 0, 0),
+					newToken(invalidMetaDataWarning(
+						"InstrumentMappings,(71,71,,33,1000,60,70)",
+						"invalid InstrumentMappings: parameter 3 '' is not a number", 1,
+					), 1, 0),
+					newToken(invalidMetaDataWarning(
+						"FontSizes,(90,100,100,80)",
+						"invalid FontSizes: expected 5 parameters but got 4", 2,
+					), 2, 0),
+					newToken(invalidMetaDataWarning(
+						"TuneFormat,(1,0,M,L,500,500,500,500,P,0,)",
+						"invalid TuneFormat: parameter 11 '' is not 0 or 1", 3,
+					), 3, 0),
+				}),
+			)
+		})
+	})
+
+	When("tokenize a file with tune tempo", func() {
+		BeforeEach(func() {
+			data = []byte(`Bagpipe Reader:1.0
+"Tune Title",(T,L,0,0,Times New Roman,15,700,0,1,2,0,0,32768)
+TuneTempo,105
+& TuneTempo,80 C_4  !t
+`)
+		})
+
+		It("should tokenize the file", func() {
+			printTokens(tokens)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(tokens).Should(BeComparableTo(
+				[]*common.Token{
+					newToken(filestructure.BagpipePlayerVersion("Bagpipe Reader:1.0"), 0, 0),
+					newStyledToken(filestructure.TuneTitle("Tune Title"), titleStyle(), filestructure.Position{Line: 1, Column: 0}),
+					newToken(filestructure.TuneTempo(105), 2, 0),
+					newToken(filestructure.StaffStart("&"), 3, 0),
+					newToken(filestructure.TempoChange(80), 3, 2),
+					newToken("C_4", 3, 15),
+					newToken(filestructure.StaffEnd("!t"), 3, 20)}),
+			)
+		})
+	})
+
+	When("tokenize a file with dalsegno", func() {
+		BeforeEach(func() {
+			data = []byte(`Bagpipe Reader:1.0
+"Tune Title",(T,L,0,0,Times New Roman,15,700,0,1,2,0,0,32768)
+& segno 
+! C_4 !t dalsegno
+`)
+		})
+
+		It("should tokenize the file", func() {
+			//printTokens(tokens)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(tokens).Should(BeComparableTo(
+				[]*common.Token{
+					newToken(filestructure.BagpipePlayerVersion("Bagpipe Reader:1.0"), 0, 0),
+					newStyledToken(filestructure.TuneTitle("Tune Title"), titleStyle(), filestructure.Position{Line: 1, Column: 0}),
+					newToken(filestructure.StaffStart("&"), 2, 0),
+					newToken(filestructure.Segno("segno"), 2, 2),
+					newToken(filestructure.Barline("!"), 3, 0),
+					newToken("C_4", 3, 2),
+					newToken(filestructure.StaffEnd("!t"), 3, 6),
+					newToken(filestructure.DalSegno("dalsegno"), 3, 9),
+				}),
+			)
+		})
+	})
+
+	When("tokenize a file with dacapoalfine", func() {
+		BeforeEach(func() {
+			data = []byte(`Bagpipe Reader:1.0
+"Tune Title",(T,L,0,0,Times New Roman,15,700,0,1,2,0,0,32768)
+& segno 
+! C_4 !t dacapoalfine
+`)
+		})
+
+		It("should tokenize the file", func() {
+			printTokens(tokens)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(tokens).Should(BeComparableTo(
+				[]*common.Token{
+					newToken(filestructure.BagpipePlayerVersion("Bagpipe Reader:1.0"), 0, 0),
+					newStyledToken(filestructure.TuneTitle("Tune Title"), titleStyle(), filestructure.Position{Line: 1, Column: 0}),
+					newToken(filestructure.StaffStart("&"), 2, 0),
+					newToken(filestructure.Segno("segno"), 2, 2),
+					newToken(filestructure.Barline("!"), 3, 0),
+					newToken("C_4", 3, 2),
+					newToken(filestructure.StaffEnd("!t"), 3, 6),
+					newToken(filestructure.DacapoAlFine("dacapoalfine"), 3, 9),
+				}),
+			)
+		})
+	})
+
+	When("tokenize a file with a staff end in the middle of a line", func() {
+		BeforeEach(func() {
+			data = []byte(`Bagpipe Reader:1.0
+"Tune Title",(T,L,0,0,Times New Roman,15,700,0,1,2,0,0,32768)
+& C_4 !t D_4
+& E_4 !t
+`)
+		})
+
+		It("should keep the staff end and the following symbols with a warning", func() {
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(tokens).Should(BeComparableTo(
+				[]*common.Token{
+					newToken(filestructure.BagpipePlayerVersion("Bagpipe Reader:1.0"), 0, 0),
+					newStyledToken(filestructure.TuneTitle("Tune Title"), titleStyle(), filestructure.Position{Line: 1, Column: 0}),
+					newToken(filestructure.StaffStart("&"), 2, 0),
+					newToken("C_4", 2, 2),
+					newToken(&diagnostics.Diagnostic{
+						Code:     diagnostics.CodeMisplacedStaffEnd,
+						Severity: measure.Severity_Warning,
+						Message:  "staff end !t is not at the end of the line",
+						Start:    diagnostics.Position{Line: 2, Column: 6},
+						End:      diagnostics.Position{Line: 2, Column: 8},
+						Text:     "!t",
+						Fix:      "move the symbols after the staff end into a new staff",
+					}, 2, 6),
+					newToken(filestructure.StaffEnd("!t"), 2, 6),
+					newToken("D_4", 2, 9),
+					newToken(filestructure.StaffStart("&"), 3, 0),
+					newToken("E_4", 3, 2),
+					newToken(filestructure.StaffEnd("!t"), 3, 6),
+				}),
+			)
+		})
+
+		When("tokenizing in lenient mode", func() {
+			BeforeEach(func() {
+				ft = NewTokenizer(common.LenientParsing)
+			})
+
+			It("should add a parser message before the staff end", func() {
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(tokens).Should(BeComparableTo(
+					[]*common.Token{
+						newToken(filestructure.BagpipePlayerVersion("Bagpipe Reader:1.0"), 0, 0),
+						newStyledToken(filestructure.TuneTitle("Tune Title"), titleStyle(), filestructure.Position{Line: 1, Column: 0}),
+						newToken(filestructure.StaffStart("&"), 2, 0),
+						newToken("C_4", 2, 2),
+						newToken(filestructure.ParserMessage{
+							Symbol: "!t",
+							Text: "line 2, column 6: staff end !t is not at the end of the line, " +
+								"the following symbols are placed in a new measure",
+						}, 2, 6),
+						newToken(filestructure.StaffEnd("!t"), 2, 6),
+						newToken("D_4", 2, 9),
+						newToken(filestructure.StaffStart("&"), 3, 0),
+						newToken("E_4", 3, 2),
+						newToken(filestructure.StaffEnd("!t"), 3, 6),
+					}),
+				)
+			})
+		})
+	})
+
+	When("tokenize a file with an unknown line outside of a staff", func() {
+		BeforeEach(func() {
+			data = []byte(`Bagpipe Reader:1.0
+"Tune Title",(T,L,0,0,Times New Roman,15,700,0,1,2,0,0,32768)
+unknown line
+& C_4 !t
+`)
+		})
+
+		It("should return a diagnostic for the line", func() {
+			Expect(diagnostics.FromError(err)).Should(BeComparableTo(diagnostics.List{
+				{
+					Code:     diagnostics.CodeUnknownLine,
+					Severity: measure.Severity_Error,
+					Message:  "no file token found for line: 'unknown line'",
+					Start:    diagnostics.Position{Line: 2, Column: 0},
+					End:      diagnostics.Position{Line: 2, Column: 12},
+					Text:     "unknown line",
+				},
+			}))
+		})
+
+		When("tokenizing in lenient mode", func() {
+			BeforeEach(func() {
+				ft = NewTokenizer(common.LenientParsing)
+			})
+
+			It("should skip the line", func() {
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(tokens).Should(BeComparableTo(
+					[]*common.Token{
+						newToken(filestructure.BagpipePlayerVersion("Bagpipe Reader:1.0"), 0, 0),
+						newStyledToken(filestructure.TuneTitle("Tune Title"), titleStyle(), filestructure.Position{Line: 1, Column: 0}),
+						newToken(filestructure.StaffStart("&"), 3, 0),
+						newToken("C_4", 3, 2),
+						newToken(filestructure.StaffEnd("!t"), 3, 6),
+					}),
+				)
+			})
+		})
+	})
+
+	When("tokenize a file with a text after the staff end", func() {
+		BeforeEach(func() {
+			data = []byte(`Bagpipe Reader:1.0
+& C_4 !t "comment" dalsegno
+& E_4 !t
+`)
+		})
+
+		It("should add the text before the staff end", func() {
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(tokens).Should(BeComparableTo(
+				[]*common.Token{
+					newToken(filestructure.BagpipePlayerVersion("Bagpipe Reader:1.0"), 0, 0),
+					newToken(filestructure.StaffStart("&"), 1, 0),
+					newToken("C_4", 1, 2),
+					newToken(filestructure.InlineComment("comment"), 1, 9),
+					newToken(filestructure.StaffEnd("!t"), 1, 6),
+					newToken(filestructure.DalSegno("dalsegno"), 1, 19),
+					newToken(filestructure.StaffStart("&"), 2, 0),
+					newToken("E_4", 2, 2),
+					newToken(filestructure.StaffEnd("!t"), 2, 6),
+				}),
+			)
+		})
+	})
+
+	When("tokenize a file with a staff that isn't ended", func() {
+		BeforeEach(func() {
+			ft = NewTokenizer(common.LenientParsing)
+			data = []byte(`Bagpipe Reader:1.0
+& C_4 ! D_4
+unknown "text" line
+& E_4`)
+		})
+
+		It("should continue with the next staff", func() {
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(tokens).Should(BeComparableTo(
+				[]*common.Token{
+					newToken(filestructure.BagpipePlayerVersion("Bagpipe Reader:1.0"), 0, 0),
+					newToken(filestructure.StaffStart("&"), 1, 0),
+					newToken("C_4", 1, 2),
+					newToken(filestructure.Barline("!"), 1, 6),
+					newToken("D_4", 1, 8),
+					newToken("unknown", 2, 0),
+					newToken(filestructure.InlineComment("text"), 2, 8),
+					newToken("line", 2, 15),
+					newToken(filestructure.StaffStart("&"), 3, 0),
+					newToken("E_4", 3, 2),
+				}),
+			)
+		})
+	})
+
+	When("tokenize a file with several invalid lines", func() {
+		BeforeEach(func() {
+			data = []byte(`Bagpipe Reader:1.0
+"Tune Title",(T,L,0,0,Times New Roman,15,700,0,1,2,0,0,32768)
+  unknown line
+& C_4 !t D_4
+& E_4 !t
+TuneTempo,99999999999
+`)
+		})
+
+		It("should return a diagnostic for every invalid line", func() {
+			diags := diagnostics.FromError(err)
+			Expect(diags).Should(HaveLen(2))
+			Expect(diags[0].Code).Should(Equal(diagnostics.CodeUnknownLine))
+			Expect(diags[0].Start).Should(Equal(diagnostics.Position{Line: 2, Column: 2}))
+			Expect(diags[1].Code).Should(Equal(diagnostics.CodeInvalidTempo))
+			Expect(diags[1].Start).Should(Equal(diagnostics.Position{Line: 5, Column: 0}))
+		})
+
+		It("should add a warning for the misplaced staff end", func() {
+			var warnings []*diagnostics.Diagnostic
+			for _, t := range tokens {
+				if d, ok := t.Value.(*diagnostics.Diagnostic); ok {
+					warnings = append(warnings, d)
+				}
+			}
+			Expect(warnings).Should(HaveLen(1))
+			Expect(warnings[0].Code).Should(Equal(diagnostics.CodeMisplacedStaffEnd))
+			Expect(warnings[0].Severity).Should(Equal(measure.Severity_Warning))
+			Expect(warnings[0].Start).Should(Equal(diagnostics.Position{Line: 3, Column: 6}))
+		})
+	})
+})
@@ -0,0 +1,197 @@
+package bwwfile
+
+import (
+	"errors"
+	"fmt"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/filestructure"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const (
+	midiNoteMappings   = "MIDINoteMappings"
+	frequencyMappings  = "FrequencyMappings"
+	instrumentMappings = "InstrumentMappings"
+	gracenoteDurations = "GracenoteDurations"
+	fontSizes          = "FontSizes"
+	tuneFormat         = "TuneFormat"
+)
+
+var metaDataParamsRegex = regexp.MustCompile(`^(\w+),\(([^)]*)\)$`)
+
+var metaDataParsers = map[string]func(params []string) (any, error){
+	midiNoteMappings: func(params []string) (any, error) {
+		pm, err := parsePitchMappings(params)
+		return (*filestructure.MIDINoteMappings)(pm), err
+	},
+	frequencyMappings: func(params []string) (any, error) {
+		pm, err := parsePitchMappings(params)
+		return (*filestructure.FrequencyMappings)(pm), err
+	},
+	instrumentMappings: func(params []string) (any, error) {
+		im := &filestructure.InstrumentMappings{}
+		return im, parseInts(params, im[:])
+	},
+	gracenoteDurations: func(params []string) (any, error) {
+		gd := &filestructure.GracenoteDurations{}
+		return gd, parseInts(params, gd[:])
+	},
+	fontSizes: func(params []string) (any, error) {
+		fs := &filestructure.FontSizes{}
+		return fs, parseInts(params, fs[:])
+	},
+	tuneFormat: func(params []string) (any, error) {
+		return parseTuneFormat(params)
+	},
+}
+
+// parseMetaData parses a meta data line like FontSizes,(90,100,100,80,250)
+// into the structure of the meta data block.
+func parseMetaData(line string) (any, error) {
+	m := metaDataParamsRegex.FindStringSubmatch(strings.TrimSpace(line))
+	if m == nil {
+		return nil, fmt.Errorf("meta data '%s' has no parameter list", line)
+	}
+
+	parse, ok := metaDataParsers[m[1]]
+	if !ok {
+		return nil, fmt.Errorf("unknown meta data %s", m[1])
+	}
+
+	md, err := parse(strings.Split(m[2], ","))
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", m[1], err)
+	}
+
+	return md, nil
+}
+
+func parsePitchMappings(params []string) (*filestructure.PitchMappings, error) {
+	var vals [3 * filestructure.ChanterPitchCount]int
+	if err := parseInts(params, vals[:]); err != nil {
+		return nil, err
+	}
+
+	pm := &filestructure.PitchMappings{}
+	copy(pm.Natural[:], vals[:filestructure.ChanterPitchCount])
+	copy(pm.Sharp[:], vals[filestructure.ChanterPitchCount:2*filestructure.ChanterPitchCount])
+	copy(pm.Flat[:], vals[2*filestructure.ChanterPitchCount:])
+
+	return pm, nil
+}
+
+// parseInts parses the parameters into dst. The number of parameters must match
+// the length of dst and every parameter must be a number that isn't negative.
+func parseInts(params []string, dst []int) error {
+	if len(params) != len(dst) {
+		return fmt.Errorf("expected %d parameters but got %d", len(dst), len(params))
+	}
+
+	return parseIntsFrom(params, 1, dst)
+}
+
+// parseIntsFrom parses the parameters that must be numbers that aren't negative
+// into dst. first is the number of the first parameter for the error message.
+func parseIntsFrom(params []string, first int, dst []int) error {
+	for i, p := range params {
+		v, err := strconv.Atoi(strings.TrimSpace(p))
+		if err != nil {
+			return fmt.Errorf("parameter %d '%s' is not a number", first+i, p)
+		}
+		if v < 0 {
+			return fmt.Errorf("parameter %d '%s' is negative", first+i, p)
+		}
+		dst[i] = v
+	}
+
+	return nil
+}
+
+// parseTuneFormat parses the parameters of a TuneFormat block. The flags must be
+// 0 or 1, the layout mode and page size a letter, the margins numbers and the
+// orientation P or L. All invalid parameters are returned together.
+func parseTuneFormat(params []string) (*filestructure.TuneFormat, error) {
+	if len(params) != filestructure.TuneFormatParamCount {
+		return nil, fmt.Errorf("expected %d parameters but got %d",
+			filestructure.TuneFormatParamCount, len(params))
+	}
+
+	tf := &filestructure.TuneFormat{}
+	err := errors.Join(
+		parseFlags(params[0:2], 1, tf.LayoutFlags[:]),
+		parseLetter(params[2], 3, &tf.LayoutMode),
+		parseLetter(params[3], 4, &tf.PageSize),
+		parseIntsFrom(params[4:8], 5, tf.Margins[:]),
+		parseOrientation(params[8], 9, &tf.Orientation),
+		parseFlags(params[9:11], 10, tf.ExtraFlags[:]),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return tf, nil
+}
+
+// parseFlags parses the parameters that must be 0 or 1 into dst.
+// first is the number of the first parameter for the error message.
+func parseFlags(params []string, first int, dst []bool) error {
+	for i, p := range params {
+		switch strings.TrimSpace(p) {
+		case "0":
+			dst[i] = false
+		case "1":
+			dst[i] = true
+		default:
+			return fmt.Errorf("parameter %d '%s' is not 0 or 1", first+i, p)
+		}
+	}
+
+	return nil
+}
+
+// parseLetter parses the parameter with the number num that must be a single letter into dst.
+func parseLetter(p string, num int, dst *string) error {
+	p = strings.TrimSpace(p)
+	if len(p) != 1 || !unicode.IsLetter(rune(p[0])) {
+		return fmt.Errorf("parameter %d '%s' is not a letter", num, p)
+	}
+
+	*dst = p
+	return nil
+}
+
+// parseOrientation parses the parameter with the number num that must be an orientation into dst.
+func parseOrientation(p string, num int, dst *filestructure.Orientation) error {
+	o := filestructure.Orientation(strings.TrimSpace(p))
+	if o != filestructure.OrientationPortrait && o != filestructure.OrientationLandscape {
+		return fmt.Errorf("parameter %d '%s' is not an orientation", num, p)
+	}
+
+	*dst = o
+	return nil
+}
+
+// setMetaData sets the meta data block v on md. If v is not a meta data
+// block, false is returned.
+func setMetaData(md *filestructure.MetaData, v any) bool {
+	switch b := v.(type) {
+	case *filestructure.MIDINoteMappings:
+		md.MIDINoteMappings = b
+	case *filestructure.FrequencyMappings:
+		md.FrequencyMappings = b
+	case *filestructure.InstrumentMappings:
+		md.InstrumentMappings = b
+	case *filestructure.GracenoteDurations:
+		md.GracenoteDurations = b
+	case *filestructure.FontSizes:
+		md.FontSizes = b
+	case *filestructure.TuneFormat:
+		md.TuneFormat = b
+	default:
+		return false
+	}
+
+	return true
+}
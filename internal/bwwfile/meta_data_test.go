@@ -0,0 +1,66 @@
+package bwwfile
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/filestructure"
+)
+
+var _ = Describe("MetaData", func() {
+	DescribeTable("parsing a TuneFormat",
+		func(line string, expected *filestructure.TuneFormat) {
+			md, err := parseMetaData(line)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(md).Should(Equal(expected))
+			Expect(expected.String()).Should(Equal(line))
+		},
+		Entry("portrait letter page",
+			"TuneFormat,(1,0,M,L,500,500,500,500,P,0,0)",
+			&filestructure.TuneFormat{
+				LayoutFlags: [2]bool{true, false},
+				LayoutMode:  "M",
+				PageSize:    "L",
+				Margins:     [4]int{500, 500, 500, 500},
+				Orientation: filestructure.OrientationPortrait,
+			},
+		),
+		Entry("landscape page with all flags set",
+			"TuneFormat,(1,1,M,A,400,450,500,550,L,1,1)",
+			&filestructure.TuneFormat{
+				LayoutFlags: [2]bool{true, true},
+				LayoutMode:  "M",
+				PageSize:    "A",
+				Margins:     [4]int{400, 450, 500, 550},
+				Orientation: filestructure.OrientationLandscape,
+				ExtraFlags:  [2]bool{true, true},
+			},
+		),
+	)
+
+	DescribeTable("parsing an invalid TuneFormat",
+		func(line string, expectedErr string) {
+			_, err := parseMetaData(line)
+			Expect(err).Should(MatchError(expectedErr))
+		},
+		Entry("too few parameters",
+			"TuneFormat,(1,0,M,L,500,500,500,500,P,0)",
+			"invalid TuneFormat: expected 11 parameters but got 10",
+		),
+		Entry("a flag that isn't 0 or 1",
+			"TuneFormat,(2,0,M,L,500,500,500,500,P,0,0)",
+			"invalid TuneFormat: parameter 1 '2' is not 0 or 1",
+		),
+		Entry("a page size that isn't a letter",
+			"TuneFormat,(1,0,M,4,500,500,500,500,P,0,0)",
+			"invalid TuneFormat: parameter 4 '4' is not a letter",
+		),
+		Entry("a negative margin",
+			"TuneFormat,(1,0,M,L,500,-500,500,500,P,0,0)",
+			"invalid TuneFormat: parameter 6 '-500' is negative",
+		),
+		Entry("an unknown orientation and an empty flag",
+			"TuneFormat,(1,0,M,L,500,500,500,500,X,0,)",
+			"invalid TuneFormat: parameter 9 'X' is not an orientation\nparameter 11 '' is not 0 or 1",
+		),
+	)
+})
@@ -0,0 +1,74 @@
+package bwwfile
+
+import (
+	"bytes"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/common"
+)
+
+// sourceData is the data of a bww file with the byte offsets of its lines.
+type sourceData struct {
+	data       []byte
+	chunk      *common.FileChunk
+	lineStarts []int
+}
+
+func newSourceData(chunk *common.FileChunk) *sourceData {
+	sd := &sourceData{
+		data:       chunk.Data,
+		chunk:      chunk,
+		lineStarts: []int{0},
+	}
+
+	for i, b := range sd.data {
+		if b == '\n' {
+			sd.lineStarts = append(sd.lineStarts, i+1)
+		}
+	}
+
+	return sd
+}
+
+// lineOffset returns the byte offset of the start of the line in the file.
+// For a line after the last line, the length of the data is returned.
+func (sd *sourceData) lineOffset(fileLine int) int {
+	line := sd.chunk.DataLine(fileLine)
+	if line < len(sd.lineStarts) {
+		return sd.lineStarts[line]
+	}
+
+	return len(sd.data)
+}
+
+// tuneData returns the data of the tune with index i exactly as it is in
+// the source data. A tune reaches from the line of its first token up to
+// the line of the first token of the next tune. The file preamble, i.e.
+// everything before the first tune like the Bagpipe Player version and the
+// meta data, is prepended to every tune but the first, which already
+// starts with it.
+func (sd *sourceData) tuneData(
+	tt []TuneTokens,
+	i int,
+) []byte {
+	end := len(sd.data)
+	if i+1 < len(tt) {
+		end = sd.lineOffset(firstTokenLine(tt[i+1]))
+	}
+
+	if i == 0 {
+		return bytes.Clone(sd.data[:end])
+	}
+
+	preambleEnd := sd.lineOffset(firstTokenLine(tt[0]))
+	start := sd.lineOffset(firstTokenLine(tt[i]))
+	data := bytes.Clone(sd.data[:preambleEnd])
+
+	return append(data, sd.data[start:end]...)
+}
+
+func firstTokenLine(tt TuneTokens) int {
+	if len(tt) == 0 {
+		return 0
+	}
+
+	return tt[0].Line
+}
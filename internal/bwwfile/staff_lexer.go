@@ -0,0 +1,195 @@
+package bwwfile
+
+import (
+	"github.com/alecthomas/participle/v2/lexer"
+	"io"
+	"maps"
+	"slices"
+)
+
+// staffLexerDefinition lexes a bww file with the rules of the simple lexer and
+// gives the symbols that structure a staff their own token type. A staff end
+// only gets the type StaffEnd if nothing but dalsegno, dacapoalfine, time line
+// ends and texts follow it on its line, otherwise it stays a symbol, i.e. a
+// misplaced staff end.
+// This way, the grammar finds the end of a staff by the token type and doesn't
+// have to look ahead to the end of the line.
+type staffLexerDefinition struct {
+	*lexer.StatefulDefinition
+	symbols map[string]lexer.TokenType
+	types   *staffTokenTypes
+	elide   bool // Whether whitespace is left out of the tokens
+}
+
+// staffTokenTypes are the token types of the simple lexer rules that are
+// needed to classify the symbols, and the types of the classified symbols.
+type staffTokenTypes struct {
+	symbol     lexer.TokenType
+	whitespace lexer.TokenType
+	newline    lexer.TokenType
+	str        lexer.TokenType
+	params     lexer.TokenType
+	staffStart lexer.TokenType
+	staffEnd   lexer.TokenType
+	barline    lexer.TokenType
+	navigation lexer.TokenType
+}
+
+// staffLexer classifies the tokens of the simple lexer. The tokens that are
+// read to find the end of a line are kept until they are returned by Next.
+type staffLexer struct {
+	lexer.Lexer
+	types   *staffTokenTypes
+	elide   bool
+	pending []lexer.Token
+}
+
+func (d *staffLexerDefinition) Symbols() map[string]lexer.TokenType {
+	return d.symbols
+}
+
+func (d *staffLexerDefinition) Lex(
+	filename string,
+	r io.Reader,
+) (lexer.Lexer, error) {
+	l, err := d.StatefulDefinition.Lex(filename, r)
+	return d.wrap(l, err)
+}
+
+func (d *staffLexerDefinition) LexString(
+	filename string,
+	input string,
+) (lexer.Lexer, error) {
+	l, err := d.StatefulDefinition.LexString(filename, input)
+	return d.wrap(l, err)
+}
+
+func (d *staffLexerDefinition) wrap(
+	l lexer.Lexer,
+	err error,
+) (lexer.Lexer, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	return &staffLexer{
+		Lexer: l,
+		types: d.types,
+		elide: d.elide,
+	}, nil
+}
+
+func (l *staffLexer) Next() (lexer.Token, error) {
+	t, err := l.read()
+	for err == nil && l.elide && t.Type == l.types.whitespace {
+		t, err = l.read()
+	}
+	if err != nil || t.Type != l.types.symbol {
+		return t, err
+	}
+
+	switch t.Value {
+	case "&":
+		t.Type = l.types.staffStart
+	case "!", "I!", "I!''":
+		t.Type = l.types.barline
+	case "segno", "fine", "dalsegno", "dacapoalfine":
+		t.Type = l.types.navigation
+	case "!t", "!I", "''!I":
+		end, err := l.endsLine()
+		if err != nil {
+			return lexer.Token{}, err
+		}
+		if end {
+			t.Type = l.types.staffEnd
+		}
+	}
+
+	return t, nil
+}
+
+// read returns the next token that was read ahead or else the next token of the simple lexer.
+func (l *staffLexer) read() (lexer.Token, error) {
+	if len(l.pending) > 0 {
+		t := l.pending[0]
+		l.pending = l.pending[1:]
+		return t, nil
+	}
+
+	return l.Lexer.Next()
+}
+
+// endsLine returns true if only dalsegno, dacapoalfine, time line ends and
+// texts follow up to the end of the line or the file.
+func (l *staffLexer) endsLine() (bool, error) {
+	afterString := false
+	for i := 0; ; i++ {
+		if i == len(l.pending) {
+			t, err := l.Lexer.Next()
+			if err != nil {
+				return false, err
+			}
+			l.pending = append(l.pending, t)
+		}
+
+		t := l.pending[i]
+		switch {
+		case t.EOF() || t.Type == l.types.newline:
+			return true, nil
+		case t.Type == l.types.whitespace:
+			continue
+		case t.Type == l.types.params && afterString:
+			afterString = false
+		case t.Type == l.types.str:
+			afterString = true
+		case t.Type == l.types.symbol && (t.Value == "dalsegno" || t.Value == "dacapoalfine"),
+			t.Type == l.types.symbol && isTimelineEnd(t.Value):
+			afterString = false
+		default:
+			return false, nil
+		}
+	}
+}
+
+// newStaffLexerDefinition returns the definition of a staff lexer that classifies
+// the symbols of the simple lexer def.
+func newStaffLexerDefinition(
+	def *lexer.StatefulDefinition,
+) *staffLexerDefinition {
+	symbols := maps.Clone(def.Symbols())
+	next := slices.Min(slices.Collect(maps.Values(symbols))) - 1
+	for _, name := range []string{"StaffStart", "StaffEnd", "Barline", "Navigation"} {
+		symbols[name] = next
+		next--
+	}
+
+	return &staffLexerDefinition{
+		StatefulDefinition: def,
+		symbols:            symbols,
+		types: &staffTokenTypes{
+			symbol:     symbols["Symbol"],
+			whitespace: symbols["Whitespace"],
+			newline:    symbols["Newline"],
+			str:        symbols["String"],
+			params:     symbols["Params"],
+			staffStart: symbols["StaffStart"],
+			staffEnd:   symbols["StaffEnd"],
+			barline:    symbols["Barline"],
+			navigation: symbols["Navigation"],
+		},
+	}
+}
+
+// withoutWhitespace returns a copy of the definition that leaves whitespace
+// out of the tokens, so that the parser doesn't have to skip it.
+func (d *staffLexerDefinition) withoutWhitespace() *staffLexerDefinition {
+	c := *d
+	c.elide = true
+	return &c
+}
+
+// isTimelineEnd returns true if the symbol ends a time line, like _' or bis_'.
+// A time line end after a staff end belongs to the last measure of the staff.
+func isTimelineEnd(s string) bool {
+	return s == "_'" || s == "bis_'"
+}
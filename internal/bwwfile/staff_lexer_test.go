@@ -0,0 +1,69 @@
+package bwwfile
+
+import (
+	"github.com/alecthomas/participle/v2/lexer"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// lexedTypes returns the symbol names of the token types of the data
+// and the values of the tokens, leaving out the whitespace.
+func lexedTypes(data string) []string {
+	lex, err := bwwLexer.withoutWhitespace().LexString("", data)
+	Expect(err).ShouldNot(HaveOccurred())
+	toks, err := lexer.ConsumeAll(lex)
+	Expect(err).ShouldNot(HaveOccurred())
+
+	names := lexer.SymbolsByRune(bwwLexer)
+	var types []string
+	for _, t := range toks {
+		if !t.EOF() {
+			types = append(types, names[t.Type]+" "+t.Value)
+		}
+	}
+
+	return types
+}
+
+var _ = Describe("StaffLexer", func() {
+	DescribeTable("classifying the symbols of a staff",
+		func(data string, expected []string) {
+			Expect(lexedTypes(data)).Should(Equal(expected))
+		},
+		Entry("barlines, navigation marks and a staff end",
+			"& segno ! LA_4 I!'' fine !t",
+			[]string{
+				"StaffStart &", "Navigation segno", "Barline !", "Symbol LA_4",
+				"Barline I!''", "Navigation fine", "StaffEnd !t",
+			},
+		),
+		Entry("a staff end followed by navigation marks and texts",
+			"& LA_4 ''!I dalsegno \"text\",(I,L,0,0,Times New Roman,11,700,0,0,18,0,0,0) \"comment\"\n& B_4 !t",
+			[]string{
+				"StaffStart &", "Symbol LA_4", "StaffEnd ''!I", "Navigation dalsegno",
+				"String \"text\"", "Params ,(I,L,0,0,Times New Roman,11,700,0,0,18,0,0,0)",
+				"String \"comment\"", "Newline \n",
+				"StaffStart &", "Symbol B_4", "StaffEnd !t",
+			},
+		),
+		Entry("a staff end followed by a time line end",
+			"& '1 D_4 !I _'\n",
+			[]string{
+				"StaffStart &", "Symbol '1", "Symbol D_4", "StaffEnd !I", "Symbol _'", "Newline \n",
+			},
+		),
+		Entry("a staff end that isn't at the end of the line",
+			"& LA_4 !t B_4 !I\r\n",
+			[]string{
+				"StaffStart &", "Symbol LA_4", "Symbol !t", "Symbol B_4",
+				"StaffEnd !I", "Newline \r\n",
+			},
+		),
+		Entry("a staff end that is followed by parameters without a text",
+			"& LA_4 !t ,(I,L)\n",
+			[]string{
+				"StaffStart &", "Symbol LA_4", "Symbol !t", "Params ,(I,L)", "Newline \n",
+			},
+		),
+	)
+})
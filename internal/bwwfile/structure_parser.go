@@ -1,32 +1,130 @@
 package bwwfile
 
 import (
+	"errors"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/common"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/diagnostics"
 	"github.com/tomvodi/limepipes-plugin-bww/internal/filestructure"
 	"github.com/tomvodi/limepipes-plugin-bww/internal/interfaces"
+	"io"
+	"iter"
 )
 
 type StructureParser struct {
 	tokenizer interfaces.FileTokenizer
 	conv      interfaces.TokenStructureConverter
+	decoder   interfaces.FileDecoder
 }
 
+// ParseDocumentStructure parses the data of a bww file into the file structure.
+// The data is converted into UTF-8 before it is parsed, so the data of the tune
+// definitions is UTF-8 as well.
+// If the tokens have problems that don't prevent building the file structure,
+// like an unknown line, the file structure is returned together with the problems
+// as diagnostics.List, so that the problems of the tunes can be added to them.
 func (t *StructureParser) ParseDocumentStructure(
 	data []byte,
 ) (*filestructure.BwwFile, error) {
-	tokens, err := t.tokenizer.Tokenize(data)
+	data, err := t.decoder.Decode(data)
 	if err != nil {
 		return nil, err
 	}
 
-	return t.conv.Convert(tokens)
+	tokens, err := t.tokenizer.Tokenize(data)
+	return convertTokens(tokens, err, func() (*filestructure.BwwFile, error) {
+		return t.conv.Convert(data, tokens)
+	})
+}
+
+// ParseTunes reads the file from r tune by tune and yields the tune definitions
+// one at a time, so that only a single tune of the file is kept in memory.
+// Every tune is parsed together with the preamble of the file, so the data of
+// a tune definition and the positions are the same as with ParseDocumentStructure.
+// The problems of a tune are yielded as error and parsing continues with the next tune.
+// Like with ParseDocumentStructure, a tune definition is yielded together with
+// the problems of its tokens, if they don't prevent building the definition.
+func (t *StructureParser) ParseTunes(
+	r io.Reader,
+) iter.Seq2[*filestructure.TuneDefinition, error] {
+	return func(yield func(*filestructure.TuneDefinition, error) bool) {
+		tr := newTuneReader(t.decoder.NewReader(r))
+		for {
+			chunk, err := tr.nextTune()
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+
+			if !t.yieldChunkTunes(chunk, yield) {
+				return
+			}
+		}
+	}
+}
+
+// yieldChunkTunes parses the chunk and yields its tune definitions. It returns
+// false if the caller stopped the iteration.
+func (t *StructureParser) yieldChunkTunes(
+	chunk *common.FileChunk,
+	yield func(*filestructure.TuneDefinition, error) bool,
+) bool {
+	tokens, err := t.tokenizer.TokenizeChunk(chunk)
+	bf, err := convertTokens(tokens, err, func() (*filestructure.BwwFile, error) {
+		return t.conv.ConvertChunk(chunk, tokens)
+	})
+	if bf == nil || len(bf.TuneDefs) == 0 {
+		return err == nil || yield(nil, err)
+	}
+
+	for i := range bf.TuneDefs {
+		// the problems of the tokens are only yielded with the first tune
+		if !yield(&bf.TuneDefs[i], err) {
+			return false
+		}
+		err = nil
+	}
+
+	return true
+}
+
+// convertTokens converts the tokens into the file structure with convert. If the
+// tokenizer returned tokens with its problems tokErr, the tokens are converted anyway
+// and the file structure is returned with the problems of both as diagnostics.List.
+func convertTokens(
+	tokens []*common.Token,
+	tokErr error,
+	convert func() (*filestructure.BwwFile, error),
+) (*filestructure.BwwFile, error) {
+	if tokErr != nil && len(tokens) == 0 {
+		return nil, tokErr
+	}
+
+	bf, err := convert()
+	if tokErr == nil {
+		return bf, err
+	}
+
+	var diags diagnostics.List
+	diags.Add(tokErr)
+	diags.Add(err)
+	if err != nil {
+		return nil, diags
+	}
+
+	return bf, diags
 }
 
 func NewStructureParser(
 	tokenizer interfaces.FileTokenizer,
 	conv interfaces.TokenStructureConverter,
+	decoder interfaces.FileDecoder,
 ) *StructureParser {
 	return &StructureParser{
 		tokenizer: tokenizer,
 		conv:      conv,
+		decoder:   decoder,
 	}
 }
@@ -4,11 +4,15 @@ import (
 	"fmt"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
 	"github.com/tomvodi/limepipes-plugin-bww/internal/common"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/diagnostics"
 	"github.com/tomvodi/limepipes-plugin-bww/internal/filestructure"
 	"github.com/tomvodi/limepipes-plugin-bww/internal/interfaces"
 	"github.com/tomvodi/limepipes-plugin-bww/internal/interfaces/mocks"
 	"github.com/tomvodi/limepipes-plugin-bww/internal/utils"
+	"io"
+	"strings"
 )
 
 var _ = Describe("StructureParser", func() {
@@ -20,6 +24,7 @@ var _ = Describe("StructureParser", func() {
 	var parser interfaces.StructureParser
 	var conv *mocks.TokenStructureConverter
 	var tokenizer *mocks.FileTokenizer
+	var decoder *mocks.FileDecoder
 
 	BeforeEach(func() {
 		data = []byte("test data")
@@ -29,13 +34,31 @@ var _ = Describe("StructureParser", func() {
 
 		tokenizer = mocks.NewFileTokenizer(GinkgoT())
 		conv = mocks.NewTokenStructureConverter(GinkgoT())
-		parser = NewStructureParser(tokenizer, conv)
+		decoder = mocks.NewFileDecoder(GinkgoT())
+		decoder.EXPECT().Decode(mock.Anything).
+			RunAndReturn(func(d []byte) ([]byte, error) {
+				return d, nil
+			}).Maybe()
+		parser = NewStructureParser(tokenizer, conv, decoder)
 	})
 
 	JustBeforeEach(func() {
 		bwwFile, err = parser.ParseDocumentStructure(data)
 	})
 
+	When("decoder returns an error", func() {
+		BeforeEach(func() {
+			decoder = mocks.NewFileDecoder(GinkgoT())
+			decoder.EXPECT().Decode(data).
+				Return(nil, fmt.Errorf("decoder error"))
+			parser = NewStructureParser(tokenizer, conv, decoder)
+		})
+
+		It("should return an error", func() {
+			Expect(err).Should(MatchError("decoder error"))
+		})
+	})
+
 	When("tokenizer returns an error", func() {
 		BeforeEach(func() {
 			tokenizer.EXPECT().Tokenize(data).
@@ -47,11 +70,50 @@ var _ = Describe("StructureParser", func() {
 		})
 	})
 
+	When("tokenizer returns tokens together with problems", func() {
+		tokErr := &diagnostics.Diagnostic{Code: diagnostics.CodeUnknownLine, Message: "unknown line"}
+		cFile := &filestructure.BwwFile{
+			BagpipePlayerVersion: "123",
+		}
+
+		BeforeEach(func() {
+			tokenizer.EXPECT().Tokenize(data).
+				Return(tokens, diagnostics.List{tokErr})
+		})
+
+		When("the tokens can be converted", func() {
+			BeforeEach(func() {
+				conv.EXPECT().Convert(data, tokens).
+					Return(cFile, nil)
+			})
+
+			It("should return the file together with the problems", func() {
+				Expect(bwwFile).Should(Equal(cFile))
+				Expect(diagnostics.FromError(err)).Should(Equal(diagnostics.List{tokErr}))
+			})
+		})
+
+		When("the converter returns an error as well", func() {
+			BeforeEach(func() {
+				conv.EXPECT().Convert(data, tokens).
+					Return(nil, fmt.Errorf("converter error"))
+			})
+
+			It("should return the problems of both", func() {
+				Expect(bwwFile).Should(BeNil())
+				diags := diagnostics.FromError(err)
+				Expect(diags).Should(HaveLen(2))
+				Expect(diags[0]).Should(Equal(tokErr))
+				Expect(diags[1].Message).Should(Equal("converter error"))
+			})
+		})
+	})
+
 	When("converter returns an error", func() {
 		BeforeEach(func() {
 			tokenizer.EXPECT().Tokenize(data).
 				Return(tokens, nil)
-			conv.EXPECT().Convert(tokens).
+			conv.EXPECT().Convert(data, tokens).
 				Return(nil, fmt.Errorf("converter error"))
 		})
 
@@ -67,7 +129,7 @@ var _ = Describe("StructureParser", func() {
 		BeforeEach(func() {
 			tokenizer.EXPECT().Tokenize(data).
 				Return(tokens, nil)
-			conv.EXPECT().Convert(tokens).
+			conv.EXPECT().Convert(data, tokens).
 				Return(cFile, nil)
 		})
 
@@ -77,3 +139,59 @@ var _ = Describe("StructureParser", func() {
 		})
 	})
 })
+
+var _ = Describe("StructureParser reading tune by tune", func() {
+	var parser interfaces.StructureParser
+	var conv *mocks.TokenStructureConverter
+	var tokenizer *mocks.FileTokenizer
+	var tokens []*common.Token
+
+	BeforeEach(func() {
+		tokens = []*common.Token{
+			{Value: "test", Line: 1, Col: 1},
+		}
+		tokenizer = mocks.NewFileTokenizer(GinkgoT())
+		conv = mocks.NewTokenStructureConverter(GinkgoT())
+		decoder := mocks.NewFileDecoder(GinkgoT())
+		decoder.EXPECT().NewReader(mock.Anything).
+			RunAndReturn(func(r io.Reader) io.Reader {
+				return r
+			})
+		parser = NewStructureParser(tokenizer, conv, decoder)
+	})
+
+	When("the tokenizer returns an error for the first tune", func() {
+		tune2 := &filestructure.Tune{}
+
+		BeforeEach(func() {
+			tokenizer.EXPECT().TokenizeChunk(mock.Anything).
+				Return(nil, fmt.Errorf("tokenizer error")).Once()
+			tokenizer.EXPECT().TokenizeChunk(mock.Anything).
+				Return(tokens, nil).Once()
+			conv.EXPECT().ConvertChunk(mock.Anything, tokens).
+				Return(&filestructure.BwwFile{
+					TuneDefs: []filestructure.TuneDefinition{{Tune: tune2}},
+				}, nil)
+		})
+
+		It("should continue with the next tune", func() {
+			var errs []error
+			var tunes []*filestructure.Tune
+			for td, err := range parser.ParseTunes(strings.NewReader(`Bagpipe Reader:1.0
+"Tune 1",(T,L,0,0,Times New Roman,16,700,0,0,18,0,0,0)
+& C_4 !t
+"Tune 2",(T,L,0,0,Times New Roman,16,700,0,0,18,0,0,0)
+& D_4 !t
+`)) {
+				if err != nil {
+					errs = append(errs, err)
+					continue
+				}
+				tunes = append(tunes, td.Tune)
+			}
+
+			Expect(errs).Should(HaveLen(1))
+			Expect(tunes).Should(Equal([]*filestructure.Tune{tune2}))
+		})
+	})
+})
@@ -0,0 +1,199 @@
+package bwwfile
+
+import (
+	"github.com/alecthomas/participle/v2/lexer"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/cst"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/filestructure"
+)
+
+// treeBuilder builds the lossless syntax tree of a file from the items of the
+// bww grammar. Every token that is captured by the grammar is the next token of
+// the lexer that isn't whitespace or a line break, so the items take the lexer
+// tokens in order. The whitespace and line breaks in front of a token are added
+// as trivia to the node that is open when the token is added.
+type treeBuilder struct {
+	toks       []lexer.Token
+	next       int
+	open       []*cst.Node // The open nodes, the last one gets the next nodes
+	lineStarts []int
+}
+
+func (b *treeBuilder) addFileItem(it *fileItem) {
+	switch {
+	case it.Version != "":
+		b.leaf(cst.KindVersion)
+	case it.Text != nil:
+		b.addText(it.Text)
+	case it.Tempo != "":
+		b.leaf(cst.KindTempo)
+	case it.Meta != "":
+		b.leaf(cst.KindMetaData)
+	case it.Staff != nil:
+		b.addStaff(it.Staff)
+	default:
+		b.startNode(cst.KindUnknownLine)
+		for range it.Unknown {
+			b.leaf(cst.KindUnknown)
+		}
+		b.endNode()
+	}
+}
+
+func (b *treeBuilder) addText(t *textItem) {
+	b.startNode(cst.KindText)
+	b.leaf(cst.KindString)
+	if t.Params != "" {
+		b.leaf(cst.KindParams)
+	}
+	b.endNode()
+}
+
+func (b *treeBuilder) addStaff(st *staffItem) {
+	b.startNode(cst.KindStaff)
+	b.leaf(cst.KindStaffStart)
+	for _, sym := range st.Symbols {
+		b.addStaffSymbol(sym)
+	}
+
+	if st.End != nil {
+		b.addStaffEnd(st.End)
+	}
+	b.endNode()
+}
+
+// addStaffEnd adds the staff end with the navigation marks, time line ends
+// and texts that follow it.
+func (b *treeBuilder) addStaffEnd(se *staffEndItem) {
+	b.leaf(cst.KindStaffEnd)
+	for _, tr := range se.Trailing {
+		switch {
+		case tr.Text != nil:
+			b.addText(tr.Text)
+		case tr.TimelineEnd != "":
+			b.leaf(cst.KindSymbol)
+		default:
+			b.leaf(cst.KindNavigation)
+		}
+	}
+}
+
+// addStaffSymbol adds the node of a staff symbol. A line break inside of a
+// staff is trivia, which is added with the next token of the staff.
+func (b *treeBuilder) addStaffSymbol(sym *staffSymbol) {
+	switch {
+	case sym.Newline:
+	case sym.Text != nil:
+		b.addText(sym.Text)
+	case sym.Tempo != "":
+		b.leaf(cst.KindTempo)
+	case sym.StaffStart != "":
+		b.leaf(cst.KindStaffStart)
+	case sym.Barline != "":
+		b.leaf(cst.KindBarline)
+	case sym.Navigation != "":
+		b.leaf(cst.KindNavigation)
+	case isStaffEnd(sym.Symbol):
+		b.leaf(cst.KindStaffEnd)
+	default:
+		b.leaf(cst.KindSymbol)
+	}
+}
+
+// startNode adds a node that gets the following nodes as children.
+// The trivia in front of the node are added to the parent.
+func (b *treeBuilder) startNode(kind cst.Kind) {
+	b.addTrivia()
+	n := &cst.Node{Kind: kind}
+	b.setPosition(n, b.toks[b.next].Pos)
+	b.current().AddChild(n)
+	b.open = append(b.open, n)
+}
+
+func (b *treeBuilder) endNode() {
+	b.open = b.open[:len(b.open)-1]
+}
+
+// leaf adds the next token that isn't trivia as leaf node of the kind.
+func (b *treeBuilder) leaf(kind cst.Kind) {
+	b.addTrivia()
+	b.addToken(kind)
+}
+
+// addTrivia adds the whitespace and line breaks in front of the next token.
+func (b *treeBuilder) addTrivia() {
+	for !b.toks[b.next].EOF() {
+		switch b.toks[b.next].Type {
+		case tokSpace:
+			b.addToken(cst.KindWhitespace)
+		case tokNewline:
+			b.addToken(cst.KindNewline)
+		default:
+			return
+		}
+	}
+}
+
+func (b *treeBuilder) addToken(kind cst.Kind) {
+	t := b.toks[b.next]
+	if t.EOF() {
+		return
+	}
+
+	n := &cst.Node{
+		Kind: kind,
+		Text: t.Value,
+	}
+	b.setPosition(n, t.Pos)
+	b.current().AddChild(n)
+	b.next++
+}
+
+func (b *treeBuilder) current() *cst.Node {
+	return b.open[len(b.open)-1]
+}
+
+// setPosition sets the offset and the zero based line and byte column of the node.
+func (b *treeBuilder) setPosition(
+	n *cst.Node,
+	pos lexer.Position,
+) {
+	n.Offset = pos.Offset
+	n.Pos = filestructure.Position{
+		Line:   pos.Line - 1,
+		Column: pos.Offset - b.lineStarts[pos.Line-1],
+	}
+}
+
+// file returns the syntax tree with the remaining trivia at the end of the file.
+func (b *treeBuilder) file() *cst.File {
+	b.addTrivia()
+	return cst.NewFile(b.open[0])
+}
+
+// buildSyntaxTree returns the syntax tree of the source with the items of
+// the bww grammar that were parsed from it.
+func buildSyntaxTree(
+	src string,
+	g *bwwGrammar,
+	lineStarts []int,
+) (*cst.File, error) {
+	lex, err := bwwLexer.LexString("", src)
+	if err != nil {
+		return nil, err
+	}
+	toks, err := lexer.ConsumeAll(lex)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &treeBuilder{
+		toks:       toks,
+		open:       []*cst.Node{{Kind: cst.KindFile}},
+		lineStarts: lineStarts,
+	}
+	for _, it := range g.Items {
+		b.addFileItem(it)
+	}
+
+	return b.file(), nil
+}
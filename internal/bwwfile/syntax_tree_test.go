@@ -0,0 +1,183 @@
+package bwwfile
+
+import (
+	"fmt"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/common"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/cst"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/filestructure"
+	"path/filepath"
+	"strings"
+)
+
+// dumpTree returns the kinds of the nodes indented by their depth,
+// with the text of the leaf nodes.
+func dumpTree(n *cst.Node) string {
+	sb := &strings.Builder{}
+	depth := 0
+	cst.Inspect(n, func(c *cst.Node) bool {
+		if c == nil {
+			depth--
+			return false
+		}
+
+		sb.WriteString(strings.Repeat("  ", depth))
+		sb.WriteString(string(c.Kind))
+		if c.IsLeaf() {
+			sb.WriteString(fmt.Sprintf(" %q", c.Text))
+		}
+		sb.WriteString("\n")
+		depth++
+		return true
+	})
+
+	return sb.String()
+}
+
+// kindsOf returns the kinds of the nodes.
+func kindsOf(nodes []*cst.Node) []cst.Kind {
+	kinds := make([]cst.Kind, len(nodes))
+	for i, n := range nodes {
+		kinds[i] = n.Kind
+	}
+
+	return kinds
+}
+
+var _ = Describe("SyntaxTree", func() {
+	var ft *Tokenizer
+	var err error
+	var tokens []*common.Token
+	var tree *cst.File
+	var data []byte
+
+	BeforeEach(func() {
+		ft = NewTokenizer(common.StrictParsing)
+	})
+
+	JustBeforeEach(func() {
+		tokens, tree, err = ft.TokenizeWithTree(data)
+	})
+
+	When("building the tree of a file with Windows line endings", func() {
+		BeforeEach(func() {
+			data = []byte("Bagpipe Reader:1.0\r\n" +
+				"\r\n" +
+				"\"Title\",(T,L,0,0,Times New Roman,15,700,0,1,2,0,0,32768)\r\n" +
+				"\r\n" +
+				"&  LA_4 \"comment\"\r\n" +
+				"! B_4 !t dalsegno\r\n")
+		})
+
+		It("should keep every byte of the file in the tree", func() {
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(tree.String()).Should(Equal(string(data)))
+			Expect(tree.Root.Len()).Should(Equal(len(data)))
+			Expect(dumpTree(tree.Root)).Should(Equal(`File
+  Version "Bagpipe Reader:1.0"
+  Newline "\r\n"
+  Newline "\r\n"
+  Text
+    String "\"Title\""
+    Params ",(T,L,0,0,Times New Roman,15,700,0,1,2,0,0,32768)"
+  Newline "\r\n"
+  Newline "\r\n"
+  Staff
+    StaffStart "&"
+    Whitespace "  "
+    Symbol "LA_4"
+    Whitespace " "
+    Text
+      String "\"comment\""
+    Newline "\r\n"
+    Barline "!"
+    Whitespace " "
+    Symbol "B_4"
+    Whitespace " "
+    StaffEnd "!t"
+    Whitespace " "
+    Navigation "dalsegno"
+  Newline "\r\n"
+`))
+		})
+
+		It("should have the positions of the tokens", func() {
+			Expect(tokens).ShouldNot(BeEmpty())
+			for _, tok := range tokens {
+				n := tree.NodeAt(filestructure.Position{Line: tok.Line, Column: tok.Col})
+				Expect(n).ShouldNot(BeNil(), "token %v", tok.Value)
+			}
+
+			b := tree.NodeAt(filestructure.Position{Line: 5, Column: 2})
+			Expect(b.Text).Should(Equal("B_4"))
+			Expect(b.Offset).Should(Equal(strings.Index(string(data), "B_4")))
+			Expect(b.Parent.Kind).Should(Equal(cst.KindStaff))
+		})
+
+		It("should map the nodes to the measures and symbols of the file structure", func() {
+			bf, err := NewTokenConverter().Convert(data, tokens)
+			Expect(err).ShouldNot(HaveOccurred())
+			t := bf.TuneDefs[0].Tune
+			Expect(t.Measures).Should(HaveLen(2))
+
+			nodes := tree.NodesIn(t.Measures[1].Span)
+			Expect(kindsOf(nodes)).Should(Equal([]cst.Kind{
+				cst.KindBarline, cst.KindSymbol, cst.KindStaffEnd, cst.KindNavigation,
+			}))
+
+			m, idx := cst.MeasureOf(t, nodes[1])
+			Expect(idx).Should(Equal(1))
+			Expect(cst.SymbolOf(m, nodes[1])).Should(Equal(t.Measures[1].Symbols[0]))
+			Expect(cst.SymbolOf(m, nodes[0])).Should(BeNil())
+
+			comment := tree.NodeAt(filestructure.Position{Line: 4, Column: 8})
+			Expect(comment.Kind).Should(Equal(cst.KindString))
+			_, idx = cst.MeasureOf(t, comment)
+			Expect(idx).Should(Equal(-1))
+		})
+
+		It("should keep the layout if the text of a node is changed", func() {
+			tree.NodeAt(filestructure.Position{Line: 5, Column: 2}).Text = "C_4"
+			Expect(tree.String()).Should(Equal(strings.Replace(string(data), "B_4", "C_4", 1)))
+		})
+	})
+
+	When("building the tree of a file with an unknown line", func() {
+		BeforeEach(func() {
+			data = []byte("Bagpipe Reader:1.0\nunknown  line \n& LA_4 !t")
+		})
+
+		It("should return the tree together with the problem", func() {
+			Expect(err).Should(HaveOccurred())
+			Expect(tokens).Should(HaveLen(4))
+			Expect(tree.String()).Should(Equal(string(data)))
+			Expect(dumpTree(tree.Root)).Should(ContainSubstring(`  UnknownLine
+    Unknown "unknown"
+    Whitespace "  "
+    Unknown "line"
+  Whitespace " "
+  Newline "\n"
+`))
+		})
+	})
+
+	When("building the trees of the test files", func() {
+		It("should reproduce every file", func() {
+			files, err := filepath.Glob("../bww/parser/testfiles/*.bww")
+			Expect(err).ShouldNot(HaveOccurred())
+			testFiles, err := filepath.Glob("./testfiles/*.bww")
+			Expect(err).ShouldNot(HaveOccurred())
+			files = append(files, testFiles...)
+			Expect(files).ShouldNot(BeEmpty())
+
+			lt := NewTokenizer(common.LenientParsing)
+			for _, f := range files {
+				data := dataFromFile(f)
+				_, tree, err := lt.TokenizeWithTree(data)
+				Expect(tree).ShouldNot(BeNil(), "file %s: %v", f, err)
+				Expect(tree.String()).Should(Equal(string(data)), "file %s", f)
+			}
+		})
+	})
+})
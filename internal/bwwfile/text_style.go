@@ -0,0 +1,58 @@
+package bwwfile
+
+import (
+	"github.com/tomvodi/limepipes-plugin-bww/internal/filestructure"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// textStyleParamCount is the number of parameters of a text style without the
+// field type, e.g. L,0,0,Times New Roman,15,700,0,1,2,0,0,32768
+const textStyleParamCount = 12
+
+var textAlignments = []filestructure.TextAlignment{
+	filestructure.AlignLeft,
+	filestructure.AlignCenter,
+	filestructure.AlignRight,
+}
+
+// parseTextStyle parses the parameters of a text field that follow the field type.
+// If the parameters are not a valid text style, nil is returned, so that
+// the text itself can still be used.
+func parseTextStyle(params string) *filestructure.TextStyle {
+	vals := strings.Split(params, ",")
+	if len(vals) != textStyleParamCount {
+		return nil
+	}
+
+	s := &filestructure.TextStyle{
+		Alignment: filestructure.TextAlignment(vals[0]),
+		FontFace:  vals[3],
+	}
+	if !slices.Contains(textAlignments, s.Alignment) {
+		return nil
+	}
+
+	intParams := map[int]*int{
+		1:  &s.OffsetX,
+		2:  &s.OffsetY,
+		4:  &s.FontSize,
+		5:  &s.FontWeight,
+		6:  &s.Italic,
+		7:  &s.CharSet,
+		8:  &s.PitchAndFamily,
+		9:  &s.Underline,
+		10: &s.StrikeOut,
+		11: &s.Color,
+	}
+	for i, p := range intParams {
+		v, err := strconv.Atoi(strings.TrimSpace(vals[i]))
+		if err != nil {
+			return nil
+		}
+		*p = v
+	}
+
+	return s
+}
@@ -0,0 +1,52 @@
+package bwwfile
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/filestructure"
+)
+
+var _ = Describe("TextStyle", func() {
+	DescribeTable("parseTextStyle",
+		func(params string, expected *filestructure.TextStyle) {
+			Expect(parseTextStyle(params)).Should(BeComparableTo(expected))
+		},
+		Entry("title style",
+			"L,0,0,Times New Roman,15,700,0,1,2,0,0,32768",
+			&filestructure.TextStyle{
+				Alignment:      filestructure.AlignLeft,
+				FontFace:       "Times New Roman",
+				FontSize:       15,
+				FontWeight:     700,
+				CharSet:        1,
+				PitchAndFamily: 2,
+				Color:          32768,
+			},
+		),
+		Entry("all values set",
+			"C,1,2,Arial,3,4,5,6,7,8,9,10",
+			&filestructure.TextStyle{
+				Alignment:      filestructure.AlignCenter,
+				OffsetX:        1,
+				OffsetY:        2,
+				FontFace:       "Arial",
+				FontSize:       3,
+				FontWeight:     4,
+				Italic:         5,
+				CharSet:        6,
+				PitchAndFamily: 7,
+				Underline:      8,
+				StrikeOut:      9,
+				Color:          10,
+			},
+		),
+		Entry("too few values", "L,0,0,Times New Roman,15,700", nil),
+		Entry("invalid alignment", "X,0,0,Times New Roman,15,700,0,1,2,0,0,32768", nil),
+		Entry("non numeric value", "L,0,0,Times New Roman,big,700,0,1,2,0,0,32768", nil),
+	)
+
+	It("should write a parsed style with the same parameters", func() {
+		params := "R,0,0,Courier New,12,400,255,0,49,0,0,0"
+		Expect(parseTextStyle(params).String()).Should(Equal(params))
+	})
+})
@@ -1,14 +1,12 @@
 package bwwfile
 
 import (
-	"fmt"
+	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/measure"
 	"github.com/tomvodi/limepipes-plugin-bww/internal/common"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/diagnostics"
 	"github.com/tomvodi/limepipes-plugin-bww/internal/filestructure"
-	"strings"
 )
 
-var structuredTextTemplate = "\"%s\",(%s,L,0,0,Times NewConverter Roman,11,700,0,0,0,0,0,0)"
-
 const staffEnd = "!t"
 const simpleBarline = "!"
 
@@ -18,11 +16,28 @@ type MeasureTokens []*common.Token
 type TokenConverter struct {
 }
 
+// Convert converts the tokens of the file data into the file structure.
+// The data of every tune definition is taken from data, so that it contains
+// the tune exactly as it was written in the file.
 func (tc *TokenConverter) Convert(
+	data []byte,
+	tokens []*common.Token,
+) (*filestructure.BwwFile, error) {
+	return tc.ConvertChunk(common.NewFileChunk(data), tokens)
+}
+
+// ConvertChunk converts the tokens of a chunk of a file into the file structure.
+// The data of the tune definitions is taken from the chunk data.
+func (tc *TokenConverter) ConvertChunk(
+	chunk *common.FileChunk,
 	tokens []*common.Token,
 ) (*filestructure.BwwFile, error) {
 	if len(tokens) == 0 {
-		return nil, fmt.Errorf("no tokens to convert")
+		return nil, &diagnostics.Diagnostic{
+			Code:     diagnostics.CodeNoTokens,
+			Severity: measure.Severity_Error,
+			Message:  "no tokens to convert",
+		}
 	}
 
 	bf := &filestructure.BwwFile{}
@@ -32,12 +47,15 @@ func (tc *TokenConverter) Convert(
 		return nil, err
 	}
 	bf.BagpipePlayerVersion = bv
+	bf.MetaData = getMetaData(tokens)
+	bf.Warnings = getWarnings(tokens)
 
 	tt := getTuneTokens(tokens)
-	for _, t := range tt {
+	sd := newSourceData(chunk)
+	for i, t := range tt {
 		td := filestructure.TuneDefinition{}
 		td.Tune = getTuneFromTokens(t)
-		td.Data = getTuneDataFromTokens(bv, t)
+		td.Data = sd.tuneData(tt, i)
 		bf.TuneDefs = append(bf.TuneDefs, td)
 	}
 
@@ -54,7 +72,40 @@ func getBagpipePlayerVersion(
 		}
 	}
 
-	return "", fmt.Errorf("no Bagpipe Player Version found")
+	return "", &diagnostics.Diagnostic{
+		Code:     diagnostics.CodeNoBagpipePlayerVersion,
+		Severity: measure.Severity_Error,
+		Message:  "no Bagpipe Player Version found",
+		Fix:      "add a Bagpipe Player version like Bagpipe Reader:1.0 as first line",
+	}
+}
+
+// getMetaData returns the meta data blocks of the file. If a block is defined
+// more than once, the last definition is used.
+func getMetaData(
+	t []*common.Token,
+) filestructure.MetaData {
+	md := filestructure.MetaData{}
+	for _, token := range t {
+		setMetaData(&md, token.Value)
+	}
+
+	return md
+}
+
+// getWarnings returns the diagnostics of the problems that were skipped
+// while tokenizing, like invalid meta data blocks.
+func getWarnings(
+	t []*common.Token,
+) diagnostics.List {
+	var warnings diagnostics.List
+	for _, token := range t {
+		if d, ok := token.Value.(*diagnostics.Diagnostic); ok {
+			warnings = append(warnings, d)
+		}
+	}
+
+	return warnings
 }
 
 // getTuneTokens gets the tokens from a file and splits them up into tokens for each tune.
@@ -92,7 +143,10 @@ func extractFirstTuneTokens(
 
 	for i, t := range tokens {
 		switch t.Value.(type) {
-		case filestructure.BagpipePlayerVersion:
+		case filestructure.BagpipePlayerVersion, *diagnostics.Diagnostic,
+			*filestructure.MIDINoteMappings, *filestructure.FrequencyMappings,
+			*filestructure.InstrumentMappings, *filestructure.GracenoteDurations,
+			*filestructure.FontSizes, *filestructure.TuneFormat:
 			// skipped as it is a file related definition
 		case filestructure.TuneTitle:
 			// When tokens have a staff, there was a tune without a title before this title
@@ -131,19 +185,21 @@ func tuneTokensHaveStaff(tokens TuneTokens) bool {
 	return false
 }
 
+// prependNoNameTitle prepends a title token to the tune tokens. The title gets the
+// line of the first token, so that the tune still starts where it is in the file.
 func prependNoNameTitle(
 	tt TuneTokens,
 ) TuneTokens {
-	return append(
-		[]*common.Token{
-			{
-				Value: filestructure.TuneTitle("No Name"),
-				Line:  0,
-				Col:   0,
-			},
-		},
-		tt...,
-	)
+	title := &common.Token{
+		Value: filestructure.TuneTitle("No Name"),
+		Line:  0,
+		Col:   0,
+	}
+	if len(tt) > 0 {
+		title.Line = tt[0].Line
+	}
+
+	return append([]*common.Token{title}, tt...)
 }
 
 func getTuneFromTokens(
@@ -156,78 +212,11 @@ func getTuneFromTokens(
 
 	tt = fillTuneHeader(t.Header, tt)
 
-	t.Measures = measuresForTokens(tt)
+	t.Measures, t.Staves = measuresForTokens(tt)
 
 	return t
 }
 
-func getTuneDataFromTokens(
-	bv filestructure.BagpipePlayerVersion,
-	tt TuneTokens,
-) []byte {
-	data := []byte(bv + "\n")
-
-	for _, token := range tt {
-		// if token is line token, add a newline character
-		switch v := token.Value.(type) {
-		case filestructure.TuneTitle:
-			t := fmt.Sprintf(structuredTextTemplate, string(v), "T")
-			data = append(data, []byte(t+"\n")...)
-		case filestructure.TuneType:
-			t := fmt.Sprintf(structuredTextTemplate, string(v), "Y")
-			data = append(data, []byte(t+"\n")...)
-		case filestructure.TuneComposer:
-			t := fmt.Sprintf(structuredTextTemplate, string(v), "M")
-			data = append(data, []byte(t+"\n")...)
-		case filestructure.TuneFooter:
-			t := fmt.Sprintf(structuredTextTemplate, string(v), "F")
-			data = append(data, []byte(t+"\n")...)
-		case filestructure.TuneInline:
-			t := fmt.Sprintf(structuredTextTemplate, string(v), "I")
-			data = append(data, []byte(t+"\n")...)
-		case filestructure.TuneComment:
-			t := fmt.Sprintf("%#v", v)
-			data = append(data, []byte(t+"\n")...)
-		case filestructure.TuneTempo:
-			t := fmt.Sprintf("TuneTempo,%d\n", v)
-			data = append(data, []byte(t)...)
-		case filestructure.TempoChange:
-			t := fmt.Sprintf(" TuneTempo,%d", v)
-			data = append(data, []byte(t)...)
-		case filestructure.StaffStart:
-			t := fmt.Sprintf("%#v", v)
-			t = strings.Trim(t, "\"")
-			data = append(data, []byte(t)...)
-		case filestructure.StaffEnd:
-			t := fmt.Sprintf("%#v", v)
-			t = strings.Trim(t, "\"")
-			data = append(data, []byte(" "+t+"\n")...)
-		case filestructure.Barline:
-			t := fmt.Sprintf("%#v", v)
-			t = strings.Trim(t, "\"")
-			data = append(data, []byte("\n"+t)...)
-		case filestructure.InlineText:
-			t := fmt.Sprintf(structuredTextTemplate, string(v), "I")
-			data = append(data, []byte(" "+t)...)
-		case filestructure.StaffInline:
-			t := fmt.Sprintf(structuredTextTemplate, string(v), "I")
-			data = append(data, []byte(t+"\n")...)
-		case filestructure.StaffComment:
-			t := fmt.Sprintf("%#v", v)
-			data = append(data, []byte(t+"\n")...)
-		case filestructure.InlineComment:
-			t := fmt.Sprintf("%#v", v)
-			data = append(data, []byte(" "+t)...)
-		default:
-			t := fmt.Sprintf("%#v", v)
-			t = strings.Trim(t, "\"")
-			data = append(data, []byte(" "+t)...)
-		}
-	}
-
-	return data
-}
-
 // fillTuneHeader fills the tune header with the tokens and returns the remaining tokens
 func fillTuneHeader(
 	h *filestructure.TuneHeader,
@@ -244,14 +233,19 @@ func fillTuneHeader(
 			goto headerFinished
 		case filestructure.TuneTitle:
 			h.Title = token.Value.(filestructure.TuneTitle)
+			h.TitleStyle = token.Style
 		case filestructure.TuneType:
 			h.Type = token.Value.(filestructure.TuneType)
+			h.TypeStyle = token.Style
 		case filestructure.TuneComposer:
 			h.Composer = token.Value.(filestructure.TuneComposer)
+			h.ComposerStyle = token.Style
 		case filestructure.TuneFooter:
 			h.Footer = append(h.Footer, token.Value.(filestructure.TuneFooter))
+			h.FooterStyles = append(h.FooterStyles, token.Style)
 		case filestructure.TuneInline:
 			h.InlineTexts = append(h.InlineTexts, token.Value.(filestructure.TuneInline))
+			h.InlineTextStyles = append(h.InlineTextStyles, token.Style)
 		case filestructure.TuneComment:
 			h.Comments = append(h.Comments, token.Value.(filestructure.TuneComment))
 		case filestructure.TuneTempo:
@@ -267,17 +261,46 @@ headerFinished:
 	return tt[staffStartIdx:]
 }
 
+// measuresForTokens returns the measures of the tune tokens and the staves
+// they are grouped in. A staff ends with the measure that has the staff end.
+// Measures after the last staff end are grouped into a staff that isn't ended.
 func measuresForTokens(
 	tt TuneTokens,
-) []*filestructure.Measure {
+) ([]*filestructure.Measure, []*filestructure.Staff) {
 	var m []*filestructure.Measure
+	var staves []*filestructure.Staff
+	staff := &filestructure.Staff{}
 
 	mtks := measureTokensForTuneTokens(tt)
 	for _, mt := range mtks {
-		m = append(m, measureForTokens(mt))
+		meas := measureForTokens(mt)
+		m = append(m, meas)
+		staff.Measures = append(staff.Measures, meas)
+
+		if measureTokensEndStaff(mt) {
+			staves = append(staves, staff)
+			staff = &filestructure.Staff{}
+		}
 	}
 
-	return m
+	if len(staff.Measures) > 0 {
+		staves = append(staves, staff)
+	}
+
+	return m, staves
+}
+
+// measureTokensEndStaff returns true, if the measure tokens contain a staff end.
+func measureTokensEndStaff(
+	mt MeasureTokens,
+) bool {
+	for _, t := range mt {
+		if _, ok := t.Value.(filestructure.StaffEnd); ok {
+			return true
+		}
+	}
+
+	return false
 }
 
 // measureTokensForTuneTokens converts the tokens for a whole tune into
@@ -305,6 +328,8 @@ func measureTokensForTuneTokens(
 			mt = append(mt, currMeasureTokens)
 			currMeasureTokens = make(MeasureTokens, 0)
 			currMeasureTokens = append(currMeasureTokens, t)
+		case filestructure.DalSegno, filestructure.DacapoAlFine:
+			currMeasureTokens = appendNavigationToken(mt, currMeasureTokens, t)
 		default:
 			currMeasureTokens = append(currMeasureTokens, t)
 		}
@@ -318,6 +343,22 @@ func measureTokensForTuneTokens(
 	return mt
 }
 
+// appendNavigationToken appends a dalsegno or dacapoalfine token to the current
+// measure tokens. If it follows a staff end, it belongs to the measure before and
+// is appended to the last measure tokens instead.
+func appendNavigationToken(
+	mt []MeasureTokens,
+	currMeasureTokens MeasureTokens,
+	t *common.Token,
+) MeasureTokens {
+	if len(currMeasureTokens) == 0 && len(mt) > 0 {
+		mt[len(mt)-1] = append(mt[len(mt)-1], t)
+		return currMeasureTokens
+	}
+
+	return append(currMeasureTokens, t)
+}
+
 // returns true, if the measure tokens are complete, i.e. contains symbols.
 // StaffInline and StaffComment are not considered symbols.
 func measureTokensAreComplete(
@@ -343,11 +384,65 @@ func measureForTokens(
 
 	for _, t := range mt {
 		processTokenForMeasure(m, t)
+		extendMeasureSpan(m, t)
 	}
 
 	return m
 }
 
+// extendMeasureSpan extends the span of the measure to the end of the token,
+// if it is a barline, symbol or navigation mark.
+// Texts and comments are not part of the measure span.
+func extendMeasureSpan(
+	m *filestructure.Measure,
+	t *common.Token,
+) {
+	span, ok := measureTokenSpan(t)
+	if !ok {
+		return
+	}
+
+	if m.Span.IsZero() {
+		m.Span.Start = span.Start
+	}
+	m.Span.End = span.End
+}
+
+// measureTokenSpan returns the span of a barline, symbol or navigation mark token.
+func measureTokenSpan(
+	t *common.Token,
+) (filestructure.Span, bool) {
+	sym := &filestructure.MusicSymbol{
+		Pos: filestructure.Position{
+			Line:   t.Line,
+			Column: t.Col,
+		},
+	}
+
+	switch v := t.Value.(type) {
+	case string:
+		sym.Text = v
+	case filestructure.TempoChange:
+		sym.TempoChange = v
+	case filestructure.Barline:
+		sym.Text = string(v)
+	case filestructure.StaffEnd:
+		sym.Text = string(v)
+	case filestructure.Segno:
+		sym.Text = string(v)
+	case filestructure.Fine:
+		sym.Text = string(v)
+	case filestructure.DalSegno:
+		sym.Text = string(v)
+	case filestructure.DacapoAlFine:
+		sym.Text = string(v)
+	default:
+		return filestructure.Span{}, false
+	}
+
+	return sym.Span(), true
+}
+
 // revive:disable:cognitive-complexity this method has a high complexity due to the number of different token types
 // but it is easy to understand and maintain
 func processTokenForMeasure(
@@ -374,8 +469,17 @@ func processTokenForMeasure(
 		}
 
 		m.LeftBarline = v
+	case filestructure.Segno:
+		m.LeftNavigation = filestructure.Navigation(v)
+	case filestructure.Fine:
+		m.RightNavigation = filestructure.Navigation(v)
+	case filestructure.DalSegno:
+		m.RightNavigation = filestructure.Navigation(v)
+	case filestructure.DacapoAlFine:
+		m.RightNavigation = filestructure.Navigation(v)
 	case filestructure.StaffInline:
 		m.StaffInlineTexts = append(m.StaffInlineTexts, v)
+		m.StaffInlineTextStyles = append(m.StaffInlineTextStyles, t.Style)
 	case filestructure.StaffComment:
 		m.StaffComments = append(m.StaffComments, v)
 	case filestructure.InlineComment:
@@ -389,11 +493,15 @@ func processTokenForMeasure(
 	case filestructure.InlineText:
 		if len(m.Symbols) == 0 {
 			m.InlineTexts = append(m.InlineTexts, v)
+			m.InlineTextStyles = append(m.InlineTextStyles, t.Style)
 			break
 		}
 
 		sym := m.Symbols[len(m.Symbols)-1]
 		sym.InlineTexts = append(sym.InlineTexts, v)
+		sym.InlineTextStyles = append(sym.InlineTextStyles, t.Style)
+	case filestructure.ParserMessage:
+		m.ParserMessages = append(m.ParserMessages, v)
 	case filestructure.TempoChange:
 		newSym.TempoChange = v
 		m.Symbols = append(m.Symbols, newSym)
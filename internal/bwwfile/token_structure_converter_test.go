@@ -1,15 +1,18 @@
 package bwwfile
 
 import (
+	"fmt"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/tomvodi/limepipes-plugin-bww/internal/common"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/diagnostics"
 	"github.com/tomvodi/limepipes-plugin-bww/internal/filestructure"
 )
 
 var _ = Describe("TokenStructureConverter", func() {
 	var err error
 	var bwwFile *filestructure.BwwFile
+	var data []byte
 	var tokens []*common.Token
 	var tc *TokenConverter
 
@@ -18,11 +21,17 @@ var _ = Describe("TokenStructureConverter", func() {
 	})
 
 	JustBeforeEach(func() {
-		bwwFile, err = tc.Convert(tokens)
+		bwwFile, err = tc.Convert(data, tokens)
 	})
 
 	When("converting a file with a tune and two measures", func() {
 		BeforeEach(func() {
+			data = []byte(`Bagpipe Reader:1.0
+
+"Tune Title",(T,L,0,0,Times New Roman,15,700,0,1,2,0,0,32768)
+
+&     !                  !t
+`)
 			tokens = []*common.Token{
 				newToken(filestructure.BagpipePlayerVersion("Bagpipe Reader:1.0"), 0, 0),
 				newToken(filestructure.TuneTitle("Tune Title"), 2, 0),
@@ -38,20 +47,21 @@ var _ = Describe("TokenStructureConverter", func() {
 				BagpipePlayerVersion: "Bagpipe Reader:1.0",
 				TuneDefs: []filestructure.TuneDefinition{
 					{
-						Data: []byte(`Bagpipe Reader:1.0
-"Tune Title",(T,L,0,0,Times NewConverter Roman,11,700,0,0,0,0,0,0)
-&
-! !t
-`),
-						Tune: &filestructure.Tune{
-							Header: &filestructure.TuneHeader{
-								Title: "Tune Title",
-							},
-							Measures: []*filestructure.Measure{
-								{},
-								{},
+						Data: data,
+						Tune: withStaves(
+							&filestructure.Tune{
+								Header: &filestructure.TuneHeader{
+									Title: "Tune Title",
+								},
+								Measures: []*filestructure.Measure{
+									{},
+									{
+										Span: lineSpan(4, 6, 27),
+									},
+								},
 							},
-						},
+							2,
+						),
 					},
 				},
 			}))
@@ -60,6 +70,12 @@ var _ = Describe("TokenStructureConverter", func() {
 
 	When("converting a file with a tune and two measures with symbols", func() {
 		BeforeEach(func() {
+			data = []byte(`Bagpipe Reader:1.0
+
+"Tune Title",(T,L,0,0,Times New Roman,15,700,0,1,2,0,0,32768)
+
+& 4_4 ! LA_4 !t
+`)
 			tokens = []*common.Token{
 				newToken(filestructure.BagpipePlayerVersion("Bagpipe Reader:1.0"), 0, 0),
 				newToken(filestructure.TuneTitle("Tune Title"), 2, 0),
@@ -77,34 +93,35 @@ var _ = Describe("TokenStructureConverter", func() {
 				BagpipePlayerVersion: "Bagpipe Reader:1.0",
 				TuneDefs: []filestructure.TuneDefinition{
 					{
-						Data: []byte(`Bagpipe Reader:1.0
-"Tune Title",(T,L,0,0,Times NewConverter Roman,11,700,0,0,0,0,0,0)
-& 4_4
-! LA_4 !t
-`),
-						Tune: &filestructure.Tune{
-							Header: &filestructure.TuneHeader{
-								Title: "Tune Title",
-							},
-							Measures: []*filestructure.Measure{
-								{
-									Symbols: []*filestructure.MusicSymbol{
-										{
-											Pos:  filestructure.Position{Line: 4, Column: 2},
-											Text: "4_4",
+						Data: data,
+						Tune: withStaves(
+							&filestructure.Tune{
+								Header: &filestructure.TuneHeader{
+									Title: "Tune Title",
+								},
+								Measures: []*filestructure.Measure{
+									{
+										Symbols: []*filestructure.MusicSymbol{
+											{
+												Pos:  filestructure.Position{Line: 4, Column: 2},
+												Text: "4_4",
+											},
 										},
+										Span: lineSpan(4, 2, 5),
 									},
-								},
-								{
-									Symbols: []*filestructure.MusicSymbol{
-										{
-											Pos:  filestructure.Position{Line: 4, Column: 8},
-											Text: "LA_4",
+									{
+										Symbols: []*filestructure.MusicSymbol{
+											{
+												Pos:  filestructure.Position{Line: 4, Column: 8},
+												Text: "LA_4",
+											},
 										},
+										Span: lineSpan(4, 6, 27),
 									},
 								},
 							},
-						},
+							2,
+						),
 					},
 				},
 			}))
@@ -113,6 +130,12 @@ var _ = Describe("TokenStructureConverter", func() {
 
 	When("converting a file with measure and symbol comments", func() {
 		BeforeEach(func() {
+			data = []byte(`Bagpipe Reader:1.0
+
+"Tune Title",(T,L,0,0,Times New Roman,15,700,0,1,2,0,0,32768)
+
+& "measure inline comment",(I,L,0,0,Times New Roman,11,700,0,0,0,0,0,0) 4_4 "symbol inline comment",(I,L,0,0,Times New Roman,11,700,0,0,0,0,0,0) ! "measure comment" LA_4 "symbol comment" !t
+`)
 			tokens = []*common.Token{
 				newToken(filestructure.BagpipePlayerVersion("Bagpipe Reader:1.0"), 0, 0),
 				newToken(filestructure.TuneTitle("Tune Title"), 2, 0),
@@ -134,46 +157,49 @@ var _ = Describe("TokenStructureConverter", func() {
 				BagpipePlayerVersion: "Bagpipe Reader:1.0",
 				TuneDefs: []filestructure.TuneDefinition{
 					{
-						Data: []byte(`Bagpipe Reader:1.0
-"Tune Title",(T,L,0,0,Times NewConverter Roman,11,700,0,0,0,0,0,0)
-& "measure inline comment",(I,L,0,0,Times NewConverter Roman,11,700,0,0,0,0,0,0) 4_4 "symbol inline comment",(I,L,0,0,Times NewConverter Roman,11,700,0,0,0,0,0,0)
-! "measure comment" LA_4 "symbol comment" !t
-`),
-						Tune: &filestructure.Tune{
-							Header: &filestructure.TuneHeader{
-								Title: "Tune Title",
-							},
-							Measures: []*filestructure.Measure{
-								{
-									InlineTexts: []filestructure.InlineText{
-										"measure inline comment",
-									},
-									Symbols: []*filestructure.MusicSymbol{
-										{
-											Pos:  filestructure.Position{Line: 4, Column: 2},
-											Text: "4_4",
-											InlineTexts: []filestructure.InlineText{
-												"symbol inline comment",
+						Data: data,
+						Tune: withStaves(
+							&filestructure.Tune{
+								Header: &filestructure.TuneHeader{
+									Title: "Tune Title",
+								},
+								Measures: []*filestructure.Measure{
+									{
+										InlineTexts: []filestructure.InlineText{
+											"measure inline comment",
+										},
+										InlineTextStyles: []*filestructure.TextStyle{nil},
+										Symbols: []*filestructure.MusicSymbol{
+											{
+												Pos:  filestructure.Position{Line: 4, Column: 2},
+												Text: "4_4",
+												InlineTexts: []filestructure.InlineText{
+													"symbol inline comment",
+												},
+												InlineTextStyles: []*filestructure.TextStyle{nil},
 											},
 										},
+										Span: lineSpan(4, 2, 5),
 									},
-								},
-								{
-									InlineComments: []filestructure.InlineComment{
-										"measure comment",
-									},
-									Symbols: []*filestructure.MusicSymbol{
-										{
-											Comments: []filestructure.InlineComment{
-												"symbol comment",
+									{
+										InlineComments: []filestructure.InlineComment{
+											"measure comment",
+										},
+										Symbols: []*filestructure.MusicSymbol{
+											{
+												Comments: []filestructure.InlineComment{
+													"symbol comment",
+												},
+												Pos:  filestructure.Position{Line: 4, Column: 2},
+												Text: "LA_4",
 											},
-											Pos:  filestructure.Position{Line: 4, Column: 2},
-											Text: "LA_4",
 										},
+										Span: lineSpan(4, 6, 27),
 									},
 								},
 							},
-						},
+							2,
+						),
 					},
 				},
 			}))
@@ -182,6 +208,24 @@ var _ = Describe("TokenStructureConverter", func() {
 
 	When("converting tune with staff comments (comments that appear right before a starting staff", func() {
 		BeforeEach(func() {
+			data = []byte(`Bagpipe Reader:1.0
+
+"just a comment"
+
+"Tune Title",(T,L,0,0,Times New Roman,15,700,0,1,2,0,0,32768)
+"tune inline text",(I,L,0,0,Times New Roman,11,700,0,0,0,0,0,0)
+"and another tune comment"
+
+"staff inline text",(I,L,0,0,Times New Roman,11,700,0,0,0,0,0,0)
+"staff comment"
+
+& LA_4 !t
+
+"staff inline comment in between",(I,L,0,0,Times New Roman,11,700,0,0,0,0,0,0)
+"staff comment in between"
+
+&  D_4 !t
+`)
 			tokens = []*common.Token{
 				newToken(filestructure.BagpipePlayerVersion("Bagpipe Reader:1.0"), 0, 0),
 				newToken(filestructure.TuneComment("just a comment"), 2, 0),
@@ -207,60 +251,57 @@ var _ = Describe("TokenStructureConverter", func() {
 				BagpipePlayerVersion: "Bagpipe Reader:1.0",
 				TuneDefs: []filestructure.TuneDefinition{
 					{
-						Data: []byte(`Bagpipe Reader:1.0
-"just a comment"
-"Tune Title",(T,L,0,0,Times NewConverter Roman,11,700,0,0,0,0,0,0)
-"tune inline text",(I,L,0,0,Times NewConverter Roman,11,700,0,0,0,0,0,0)
-"and another tune comment"
-"staff inline text",(I,L,0,0,Times NewConverter Roman,11,700,0,0,0,0,0,0)
-"staff comment"
-& LA_4 !t
-"staff inline comment in between",(I,L,0,0,Times NewConverter Roman,11,700,0,0,0,0,0,0)
-"staff comment in between"
-& D_4 !t
-`),
-						Tune: &filestructure.Tune{
-							Header: &filestructure.TuneHeader{
-								Title: "Tune Title",
-								Comments: []filestructure.TuneComment{
-									"just a comment",
-									"and another tune comment",
-								},
-								InlineTexts: []filestructure.TuneInline{
-									"tune inline text",
-								},
-							},
-							Measures: []*filestructure.Measure{
-								{
-									StaffComments: []filestructure.StaffComment{
-										"staff comment",
+						Data: data,
+						Tune: withStaves(
+							&filestructure.Tune{
+								Header: &filestructure.TuneHeader{
+									Title: "Tune Title",
+									Comments: []filestructure.TuneComment{
+										"just a comment",
+										"and another tune comment",
 									},
-									StaffInlineTexts: []filestructure.StaffInline{
-										"staff inline text",
-									},
-									Symbols: []*filestructure.MusicSymbol{
-										{
-											Pos:  filestructure.Position{Line: 11, Column: 2},
-											Text: "LA_4",
-										},
+									InlineTexts: []filestructure.TuneInline{
+										"tune inline text",
 									},
+									InlineTextStyles: []*filestructure.TextStyle{nil},
 								},
-								{
-									StaffComments: []filestructure.StaffComment{
-										"staff comment in between",
-									},
-									StaffInlineTexts: []filestructure.StaffInline{
-										"staff inline comment in between",
+								Measures: []*filestructure.Measure{
+									{
+										StaffComments: []filestructure.StaffComment{
+											"staff comment",
+										},
+										StaffInlineTexts: []filestructure.StaffInline{
+											"staff inline text",
+										},
+										StaffInlineTextStyles: []*filestructure.TextStyle{nil},
+										Symbols: []*filestructure.MusicSymbol{
+											{
+												Pos:  filestructure.Position{Line: 11, Column: 2},
+												Text: "LA_4",
+											},
+										},
+										Span: lineSpan(11, 2, 9),
 									},
-									Symbols: []*filestructure.MusicSymbol{
-										{
-											Pos:  filestructure.Position{Line: 16, Column: 3},
-											Text: "D_4",
+									{
+										StaffComments: []filestructure.StaffComment{
+											"staff comment in between",
 										},
+										StaffInlineTexts: []filestructure.StaffInline{
+											"staff inline comment in between",
+										},
+										StaffInlineTextStyles: []*filestructure.TextStyle{nil},
+										Symbols: []*filestructure.MusicSymbol{
+											{
+												Pos:  filestructure.Position{Line: 16, Column: 3},
+												Text: "D_4",
+											},
+										},
+										Span: lineSpan(16, 3, 9),
 									},
 								},
 							},
-						},
+							1, 1,
+						),
 					},
 				},
 			}))
@@ -269,6 +310,17 @@ var _ = Describe("TokenStructureConverter", func() {
 
 	When("converting file with two tunes", func() {
 		BeforeEach(func() {
+			data = []byte(`Bagpipe Reader:1.0
+
+"Tune 1 Title",(T,L,0,0,Times New Roman,15,700,0,1,2,0,0,32768)
+
+
+& LA_4 !t
+
+"Tune 2 Title",(T,L,0,0,Times New Roman,15,700,0,1,2,0,0,32768)
+
+& B_4 !t
+`)
 			tokens = []*common.Token{
 				newToken(filestructure.BagpipePlayerVersion("Bagpipe Reader:1.0"), 0, 0),
 				newToken(filestructure.TuneTitle("Tune 1 Title"), 2, 0),
@@ -289,45 +341,59 @@ var _ = Describe("TokenStructureConverter", func() {
 				TuneDefs: []filestructure.TuneDefinition{
 					{
 						Data: []byte(`Bagpipe Reader:1.0
-"Tune 1 Title",(T,L,0,0,Times NewConverter Roman,11,700,0,0,0,0,0,0)
+
+"Tune 1 Title",(T,L,0,0,Times New Roman,15,700,0,1,2,0,0,32768)
+
+
 & LA_4 !t
+
 `),
-						Tune: &filestructure.Tune{
-							Header: &filestructure.TuneHeader{
-								Title: "Tune 1 Title",
-							},
-							Measures: []*filestructure.Measure{
-								{
-									Symbols: []*filestructure.MusicSymbol{
-										{
-											Pos:  filestructure.Position{Line: 5, Column: 2},
-											Text: "LA_4",
+						Tune: withStaves(
+							&filestructure.Tune{
+								Header: &filestructure.TuneHeader{
+									Title: "Tune 1 Title",
+								},
+								Measures: []*filestructure.Measure{
+									{
+										Symbols: []*filestructure.MusicSymbol{
+											{
+												Pos:  filestructure.Position{Line: 5, Column: 2},
+												Text: "LA_4",
+											},
 										},
+										Span: lineSpan(5, 2, 9),
 									},
 								},
 							},
-						},
+							1,
+						),
 					},
 					{
 						Data: []byte(`Bagpipe Reader:1.0
-"Tune 2 Title",(T,L,0,0,Times NewConverter Roman,11,700,0,0,0,0,0,0)
+
+"Tune 2 Title",(T,L,0,0,Times New Roman,15,700,0,1,2,0,0,32768)
+
 & B_4 !t
 `),
-						Tune: &filestructure.Tune{
-							Header: &filestructure.TuneHeader{
-								Title: "Tune 2 Title",
-							},
-							Measures: []*filestructure.Measure{
-								{
-									Symbols: []*filestructure.MusicSymbol{
-										{
-											Pos:  filestructure.Position{Line: 9, Column: 2},
-											Text: "B_4",
+						Tune: withStaves(
+							&filestructure.Tune{
+								Header: &filestructure.TuneHeader{
+									Title: "Tune 2 Title",
+								},
+								Measures: []*filestructure.Measure{
+									{
+										Symbols: []*filestructure.MusicSymbol{
+											{
+												Pos:  filestructure.Position{Line: 9, Column: 2},
+												Text: "B_4",
+											},
 										},
+										Span: lineSpan(9, 2, 8),
 									},
 								},
 							},
-						},
+							1,
+						),
 					},
 				},
 			}))
@@ -336,6 +402,18 @@ var _ = Describe("TokenStructureConverter", func() {
 
 	When("converting file with a tune that has different barlines", func() {
 		BeforeEach(func() {
+			data = []byte(`Bagpipe Reader:1.0
+
+"Tune Title",(T,L,0,0,Times New Roman,15,700,0,1,2,0,0,32768)
+
+& 4_4
+I!'' LA_4
+! C_4 ''!I
+
+&
+I! B_4
+! E_4 !I
+`)
 			tokens = []*common.Token{
 				newToken(filestructure.BagpipePlayerVersion("Bagpipe Reader:1.0"), 0, 0),
 				newToken(filestructure.TuneTitle("Tune Title"), 2, 0),
@@ -361,75 +439,217 @@ var _ = Describe("TokenStructureConverter", func() {
 				BagpipePlayerVersion: "Bagpipe Reader:1.0",
 				TuneDefs: []filestructure.TuneDefinition{
 					{
-						Data: []byte(`Bagpipe Reader:1.0
-"Tune Title",(T,L,0,0,Times NewConverter Roman,11,700,0,0,0,0,0,0)
-& 4_4
-I!'' LA_4
-! C_4 ''!I
-&
-I! B_4
-! E_4 !I
-`),
-						Tune: &filestructure.Tune{
-							Header: &filestructure.TuneHeader{
-								Title: "Tune Title",
-							},
-							Measures: []*filestructure.Measure{
-								{
-									Symbols: []*filestructure.MusicSymbol{
-										{
-											Pos:  filestructure.Position{Line: 4, Column: 2},
-											Text: "4_4",
+						Data: data,
+						Tune: withStaves(
+							&filestructure.Tune{
+								Header: &filestructure.TuneHeader{
+									Title: "Tune Title",
+								},
+								Measures: []*filestructure.Measure{
+									{
+										Symbols: []*filestructure.MusicSymbol{
+											{
+												Pos:  filestructure.Position{Line: 4, Column: 2},
+												Text: "4_4",
+											},
 										},
+										Span: lineSpan(4, 2, 5),
 									},
-								},
-								{
-									LeftBarline: "I!''",
-									Symbols: []*filestructure.MusicSymbol{
-										{
-											Pos:  filestructure.Position{Line: 5, Column: 5},
-											Text: "LA_4",
+									{
+										LeftBarline: "I!''",
+										Symbols: []*filestructure.MusicSymbol{
+											{
+												Pos:  filestructure.Position{Line: 5, Column: 5},
+												Text: "LA_4",
+											},
 										},
+										Span: lineSpan(5, 0, 9),
 									},
-								},
-								{
-									RightBarline: "''!I",
-									Symbols: []*filestructure.MusicSymbol{
-										{
-											Pos:  filestructure.Position{Line: 6, Column: 2},
-											Text: "C_4",
+									{
+										RightBarline: "''!I",
+										Symbols: []*filestructure.MusicSymbol{
+											{
+												Pos:  filestructure.Position{Line: 6, Column: 2},
+												Text: "C_4",
+											},
 										},
+										Span: lineSpan(6, 0, 10),
 									},
-								},
-								{},
-								{
-									LeftBarline: "I!",
-									Symbols: []*filestructure.MusicSymbol{
-										{
-											Pos:  filestructure.Position{Line: 9, Column: 3},
-											Text: "B_4",
+									{},
+									{
+										LeftBarline: "I!",
+										Symbols: []*filestructure.MusicSymbol{
+											{
+												Pos:  filestructure.Position{Line: 9, Column: 3},
+												Text: "B_4",
+											},
 										},
+										Span: lineSpan(9, 0, 6),
 									},
-								},
-								{
-									RightBarline: "!I",
-									Symbols: []*filestructure.MusicSymbol{
-										{
-											Pos:  filestructure.Position{Line: 10, Column: 2},
-											Text: "E_4",
+									{
+										RightBarline: "!I",
+										Symbols: []*filestructure.MusicSymbol{
+											{
+												Pos:  filestructure.Position{Line: 10, Column: 2},
+												Text: "E_4",
+											},
 										},
+										Span: lineSpan(10, 0, 8),
 									},
 								},
 							},
+							3, 3,
+						),
+					},
+				},
+			}))
+		})
+	})
+
+	When("converting file with a tune that has navigation marks", func() {
+		BeforeEach(func() {
+			data = []byte(`Bagpipe Reader:1.0
+
+"Tune Title",(T,L,0,0,Times New Roman,15,700,0,1,2,0,0,32768)
+
+& segno LA_4 fine
+! B_4 !I dalsegno
+`)
+			tokens = []*common.Token{
+				newToken(filestructure.BagpipePlayerVersion("Bagpipe Reader:1.0"), 0, 0),
+				newToken(filestructure.TuneTitle("Tune Title"), 2, 0),
+				newToken(filestructure.StaffStart("&"), 4, 0),
+				newToken(filestructure.Segno("segno"), 4, 2),
+				newToken("LA_4", 4, 8),
+				newToken(filestructure.Fine("fine"), 4, 13),
+				newToken(filestructure.Barline("!"), 5, 0),
+				newToken("B_4", 5, 2),
+				newToken(filestructure.StaffEnd("!I"), 5, 6),
+				newToken(filestructure.DalSegno("dalsegno"), 5, 9),
+			}
+		})
+
+		It("should add the navigation marks to the measures", func() {
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(bwwFile.TuneDefs).Should(HaveLen(1))
+			Expect(bwwFile.TuneDefs[0].Tune.Measures).Should(BeComparableTo([]*filestructure.Measure{
+				{
+					LeftNavigation:  "segno",
+					RightNavigation: "fine",
+					Symbols: []*filestructure.MusicSymbol{
+						{
+							Pos:  filestructure.Position{Line: 4, Column: 8},
+							Text: "LA_4",
 						},
 					},
+					Span: lineSpan(4, 2, 17),
+				},
+				{
+					RightBarline:    "!I",
+					RightNavigation: "dalsegno",
+					Symbols: []*filestructure.MusicSymbol{
+						{
+							Pos:  filestructure.Position{Line: 5, Column: 2},
+							Text: "B_4",
+						},
+					},
+					Span: lineSpan(5, 0, 17),
 				},
 			}))
 		})
 	})
 
+	When("converting file with a tune that has a repaired staff end", func() {
+		BeforeEach(func() {
+			data = []byte(`Bagpipe Reader:1.0
+
+"Tune Title",(T,L,0,0,Times New Roman,15,700,0,1,2,0,0,32768)
+
+& LA_4 !t B_4 !t
+`)
+			tokens = []*common.Token{
+				newToken(filestructure.BagpipePlayerVersion("Bagpipe Reader:1.0"), 0, 0),
+				newToken(filestructure.TuneTitle("Tune Title"), 2, 0),
+				newToken(filestructure.StaffStart("&"), 4, 0),
+				newToken("LA_4", 4, 2),
+				newToken(filestructure.ParserMessage{Symbol: "!t", Text: "misplaced staff end"}, 4, 7),
+				newToken(filestructure.StaffEnd("!t"), 4, 7),
+				newToken("B_4", 4, 10),
+				newToken(filestructure.StaffEnd("!t"), 4, 14),
+			}
+		})
+
+		It("should add the parser message to the measure of the staff end", func() {
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(bwwFile.TuneDefs).Should(HaveLen(1))
+			Expect(bwwFile.TuneDefs[0].Tune.Measures).Should(BeComparableTo([]*filestructure.Measure{
+				{
+					Symbols: []*filestructure.MusicSymbol{
+						{
+							Pos:  filestructure.Position{Line: 4, Column: 2},
+							Text: "LA_4",
+						},
+					},
+					ParserMessages: []filestructure.ParserMessage{
+						{Symbol: "!t", Text: "misplaced staff end"},
+					},
+					Span: lineSpan(4, 2, 9),
+				},
+				{
+					Symbols: []*filestructure.MusicSymbol{
+						{
+							Pos:  filestructure.Position{Line: 4, Column: 10},
+							Text: "B_4",
+						},
+					},
+					Span: lineSpan(4, 10, 16),
+				},
+			}))
+		})
+	})
+
+	When("converting file with a tune that has a staff that isn't ended", func() {
+		BeforeEach(func() {
+			data = []byte(`Bagpipe Reader:1.0
+
+"Tune Title",(T,L,0,0,Times New Roman,15,700,0,1,2,0,0,32768)
+
+& LA_4 ! B_4 !t
+& C_4
+`)
+			tokens = []*common.Token{
+				newToken(filestructure.BagpipePlayerVersion("Bagpipe Reader:1.0"), 0, 0),
+				newToken(filestructure.TuneTitle("Tune Title"), 2, 0),
+				newToken(filestructure.StaffStart("&"), 4, 0),
+				newToken("LA_4", 4, 2),
+				newToken(filestructure.Barline("!"), 4, 7),
+				newToken("B_4", 4, 9),
+				newToken(filestructure.StaffEnd("!t"), 4, 13),
+				newToken(filestructure.StaffStart("&"), 5, 0),
+				newToken("C_4", 5, 2),
+			}
+		})
+
+		It("should group the measures by their staves", func() {
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(bwwFile.TuneDefs).Should(HaveLen(1))
+			t := bwwFile.TuneDefs[0].Tune
+			Expect(t.Measures).Should(HaveLen(3))
+			Expect(t.Staves).Should(HaveLen(2))
+			Expect(t.Staves[0].Measures).Should(Equal(t.Measures[:2]))
+			Expect(t.Staves[1].Measures).Should(Equal(t.Measures[2:]))
+		})
+	})
+
 	When("converting file with one tune which doesn't have a title", func() {
 		BeforeEach(func() {
+			data = []byte(`Bagpipe Reader:1.0
+
+
+
+
+& LA_4 !t
+`)
 			tokens = []*common.Token{
 				newToken(filestructure.BagpipePlayerVersion("Bagpipe Reader:1.0"), 0, 0),
 				newToken(filestructure.StaffStart("&"), 5, 0),
@@ -444,25 +664,26 @@ I! B_4
 				BagpipePlayerVersion: "Bagpipe Reader:1.0",
 				TuneDefs: []filestructure.TuneDefinition{
 					{
-						Data: []byte(`Bagpipe Reader:1.0
-"No Name",(T,L,0,0,Times NewConverter Roman,11,700,0,0,0,0,0,0)
-& LA_4 !t
-`),
-						Tune: &filestructure.Tune{
-							Header: &filestructure.TuneHeader{
-								Title: "No Name",
-							},
-							Measures: []*filestructure.Measure{
-								{
-									Symbols: []*filestructure.MusicSymbol{
-										{
-											Pos:  filestructure.Position{Line: 5, Column: 2},
-											Text: "LA_4",
+						Data: data,
+						Tune: withStaves(
+							&filestructure.Tune{
+								Header: &filestructure.TuneHeader{
+									Title: "No Name",
+								},
+								Measures: []*filestructure.Measure{
+									{
+										Symbols: []*filestructure.MusicSymbol{
+											{
+												Pos:  filestructure.Position{Line: 5, Column: 2},
+												Text: "LA_4",
+											},
 										},
+										Span: lineSpan(5, 2, 9),
 									},
 								},
 							},
-						},
+							1,
+						),
 					},
 				},
 			}))
@@ -471,6 +692,17 @@ I! B_4
 
 	When("converting file with two tunes where the first one doesn't have a title", func() {
 		BeforeEach(func() {
+			data = []byte(`Bagpipe Reader:1.0
+
+
+
+
+& LA_4 !t
+
+"Tune 2 Title",(T,L,0,0,Times New Roman,15,700,0,1,2,0,0,32768)
+
+& B_4 !t
+`)
 			tokens = []*common.Token{
 				newToken(filestructure.BagpipePlayerVersion("Bagpipe Reader:1.0"), 0, 0),
 				newToken(filestructure.StaffStart("&"), 5, 0),
@@ -490,45 +722,62 @@ I! B_4
 				TuneDefs: []filestructure.TuneDefinition{
 					{
 						Data: []byte(`Bagpipe Reader:1.0
-"No Name",(T,L,0,0,Times NewConverter Roman,11,700,0,0,0,0,0,0)
+
+
+
+
 & LA_4 !t
+
 `),
-						Tune: &filestructure.Tune{
-							Header: &filestructure.TuneHeader{
-								Title: "No Name",
-							},
-							Measures: []*filestructure.Measure{
-								{
-									Symbols: []*filestructure.MusicSymbol{
-										{
-											Pos:  filestructure.Position{Line: 5, Column: 2},
-											Text: "LA_4",
+						Tune: withStaves(
+							&filestructure.Tune{
+								Header: &filestructure.TuneHeader{
+									Title: "No Name",
+								},
+								Measures: []*filestructure.Measure{
+									{
+										Symbols: []*filestructure.MusicSymbol{
+											{
+												Pos:  filestructure.Position{Line: 5, Column: 2},
+												Text: "LA_4",
+											},
 										},
+										Span: lineSpan(5, 2, 9),
 									},
 								},
 							},
-						},
+							1,
+						),
 					},
 					{
 						Data: []byte(`Bagpipe Reader:1.0
-"Tune 2 Title",(T,L,0,0,Times NewConverter Roman,11,700,0,0,0,0,0,0)
+
+
+
+
+"Tune 2 Title",(T,L,0,0,Times New Roman,15,700,0,1,2,0,0,32768)
+
 & B_4 !t
 `),
-						Tune: &filestructure.Tune{
-							Header: &filestructure.TuneHeader{
-								Title: "Tune 2 Title",
-							},
-							Measures: []*filestructure.Measure{
-								{
-									Symbols: []*filestructure.MusicSymbol{
-										{
-											Pos:  filestructure.Position{Line: 9, Column: 2},
-											Text: "B_4",
+						Tune: withStaves(
+							&filestructure.Tune{
+								Header: &filestructure.TuneHeader{
+									Title: "Tune 2 Title",
+								},
+								Measures: []*filestructure.Measure{
+									{
+										Symbols: []*filestructure.MusicSymbol{
+											{
+												Pos:  filestructure.Position{Line: 9, Column: 2},
+												Text: "B_4",
+											},
 										},
+										Span: lineSpan(9, 2, 8),
 									},
 								},
 							},
-						},
+							1,
+						),
 					},
 				},
 			}))
@@ -537,6 +786,11 @@ I! B_4
 
 	When("converting file with tune tempo definitions", func() {
 		BeforeEach(func() {
+			data = []byte(`Bagpipe Reader:1.0
+"Tune Title",(T,L,0,0,Times New Roman,15,700,0,1,2,0,0,32768)
+TuneTempo,105
+& TuneTempo,80 C_4 !t
+`)
 			tokens = []*common.Token{
 				newToken(filestructure.BagpipePlayerVersion("Bagpipe Reader:1.0"), 0, 0),
 				newToken(filestructure.TuneTitle("Tune Title"), 1, 0),
@@ -554,34 +808,207 @@ I! B_4
 				BagpipePlayerVersion: "Bagpipe Reader:1.0",
 				TuneDefs: []filestructure.TuneDefinition{
 					{
-						Data: []byte(`Bagpipe Reader:1.0
-"Tune Title",(T,L,0,0,Times NewConverter Roman,11,700,0,0,0,0,0,0)
-TuneTempo,105
-& TuneTempo,80 C_4 !t
-`),
-						Tune: &filestructure.Tune{
-							Header: &filestructure.TuneHeader{
-								Title: "Tune Title",
-								Tempo: filestructure.TuneTempo(105),
-							},
-							Measures: []*filestructure.Measure{
-								{
-									Symbols: []*filestructure.MusicSymbol{
-										{
-											Pos:         filestructure.Position{Line: 3, Column: 2},
-											TempoChange: filestructure.TempoChange(80),
-										},
-										{
-											Pos:  filestructure.Position{Line: 3, Column: 15},
-											Text: "C_4",
+						Data: data,
+						Tune: withStaves(
+							&filestructure.Tune{
+								Header: &filestructure.TuneHeader{
+									Title: "Tune Title",
+									Tempo: filestructure.TuneTempo(105),
+								},
+								Measures: []*filestructure.Measure{
+									{
+										Symbols: []*filestructure.MusicSymbol{
+											{
+												Pos:         filestructure.Position{Line: 3, Column: 2},
+												TempoChange: filestructure.TempoChange(80),
+											},
+											{
+												Pos:  filestructure.Position{Line: 3, Column: 15},
+												Text: "C_4",
+											},
 										},
+										Span: lineSpan(3, 2, 22),
 									},
 								},
 							},
-						},
+							1,
+						),
+					},
+				},
+			}))
+		})
+	})
+
+	When("converting a file with styled texts", func() {
+		var footerStyle *filestructure.TextStyle
+		var inlineStyle *filestructure.TextStyle
+
+		BeforeEach(func() {
+			data = []byte(`Bagpipe Reader:1.0
+"Tune Title",(T,L,0,0,Times New Roman,15,700,0,1,2,0,0,32768)
+"Footer",(F,C,0,0,Arial,9,400,255,0,0,0,0,0)
+& "inline",(I,R,10,0,Courier New,12,700,0,0,0,0,0,0) !t
+`)
+			footerStyle = &filestructure.TextStyle{
+				Alignment:  filestructure.AlignCenter,
+				FontFace:   "Arial",
+				FontSize:   9,
+				FontWeight: 400,
+				Italic:     255,
+			}
+			inlineStyle = &filestructure.TextStyle{
+				Alignment:  filestructure.AlignRight,
+				OffsetX:    10,
+				FontFace:   "Courier New",
+				FontSize:   12,
+				FontWeight: 700,
+			}
+			footerTok := newToken(filestructure.TuneFooter("Footer"), 2, 0)
+			footerTok.Style = footerStyle
+			inlineTok := newToken(filestructure.InlineText("inline"), 3, 2)
+			inlineTok.Style = inlineStyle
+			tokens = []*common.Token{
+				newToken(filestructure.BagpipePlayerVersion("Bagpipe Reader:1.0"), 0, 0),
+				newToken(filestructure.TuneTitle("Tune Title"), 1, 0),
+				footerTok,
+				newToken(filestructure.StaffStart("&"), 3, 0),
+				inlineTok,
+				newToken(filestructure.StaffEnd("!t"), 3, 30),
+			}
+		})
+
+		It("should keep the styles of the texts", func() {
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(bwwFile).Should(BeComparableTo(&filestructure.BwwFile{
+				BagpipePlayerVersion: "Bagpipe Reader:1.0",
+				TuneDefs: []filestructure.TuneDefinition{
+					{
+						Data: data,
+						Tune: withStaves(
+							&filestructure.Tune{
+								Header: &filestructure.TuneHeader{
+									Title:        "Tune Title",
+									Footer:       []filestructure.TuneFooter{"Footer"},
+									FooterStyles: []*filestructure.TextStyle{footerStyle},
+								},
+								Measures: []*filestructure.Measure{
+									{
+										InlineTexts:      []filestructure.InlineText{"inline"},
+										InlineTextStyles: []*filestructure.TextStyle{inlineStyle},
+										Span:             lineSpan(3, 30, 32),
+									},
+								},
+							},
+							1,
+						),
 					},
 				},
 			}))
 		})
 	})
+
+	When("converting a file with meta data and two tunes", func() {
+		var fontSizes *filestructure.FontSizes
+		var tuneFormat *filestructure.TuneFormat
+
+		BeforeEach(func() {
+			data = []byte(`Bagpipe Reader:1.0
+FontSizes,(90,100,100,80,250)
+TuneFormat,(1,0,M,L,500,500,500,500,P,0,0)
+"Tune 1",(T,L,0,0,Times New Roman,15,700,0,1,2,0,0,32768)
+& !t
+"Tune 2",(T,L,0,0,Times New Roman,15,700,0,1,2,0,0,32768)
+& !t
+`)
+			fontSizes = &filestructure.FontSizes{90, 100, 100, 80, 250}
+			tuneFormat = &filestructure.TuneFormat{
+				LayoutFlags: [2]bool{true, false},
+				LayoutMode:  "M",
+				PageSize:    "L",
+				Margins:     [4]int{500, 500, 500, 500},
+				Orientation: filestructure.OrientationPortrait,
+			}
+			tokens = []*common.Token{
+				newToken(filestructure.BagpipePlayerVersion("Bagpipe Reader:1.0"), 0, 0),
+				newToken(fontSizes, 1, 0),
+				newToken(tuneFormat, 2, 0),
+				newToken(filestructure.TuneTitle("Tune 1"), 3, 0),
+				newToken(filestructure.StaffStart("&"), 4, 0),
+				newToken(filestructure.StaffEnd("!t"), 4, 2),
+				newToken(filestructure.TuneTitle("Tune 2"), 5, 0),
+				newToken(filestructure.StaffStart("&"), 6, 0),
+				newToken(filestructure.StaffEnd("!t"), 6, 2),
+			}
+		})
+
+		It("should set the meta data and write it into the data of every tune", func() {
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(bwwFile.MetaData).Should(BeComparableTo(filestructure.MetaData{
+				FontSizes:  fontSizes,
+				TuneFormat: tuneFormat,
+			}))
+			Expect(bwwFile.TuneDefs).Should(HaveLen(2))
+			for i, td := range bwwFile.TuneDefs {
+				Expect(string(td.Data)).Should(Equal(fmt.Sprintf(`Bagpipe Reader:1.0
+FontSizes,(90,100,100,80,250)
+TuneFormat,(1,0,M,L,500,500,500,500,P,0,0)
+"Tune %d",(T,L,0,0,Times New Roman,15,700,0,1,2,0,0,32768)
+& !t
+`, i+1)))
+			}
+		})
+	})
+
+	When("converting a file with a skipped meta data block", func() {
+		var warning *diagnostics.Diagnostic
+
+		BeforeEach(func() {
+			data = []byte(`Bagpipe Reader:1.0
+FontSizes,(90,100,100,80)
+"Tune",(T,L,0,0,Times New Roman,15,700,0,1,2,0,0,32768)
+& !t
+`)
+			warning = invalidMetaDataWarning("FontSizes,(90,100,100,80)",
+				"invalid FontSizes: expected 5 parameters but got 4", 1)
+			tokens = []*common.Token{
+				newToken(filestructure.BagpipePlayerVersion("Bagpipe Reader:1.0"), 0, 0),
+				newToken(warning, 1, 0),
+				newToken(filestructure.TuneTitle("Tune"), 2, 0),
+				newToken(filestructure.StaffStart("&"), 3, 0),
+				newToken(filestructure.StaffEnd("!t"), 3, 2),
+			}
+		})
+
+		It("should return the warning of the file and not add it to the tune", func() {
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(bwwFile.Warnings).Should(Equal(diagnostics.List{warning}))
+			Expect(bwwFile.TuneDefs).Should(HaveLen(1))
+			Expect(bwwFile.TuneDefs[0].Tune.Header.Title).Should(BeEquivalentTo("Tune"))
+		})
+	})
 })
+
+// lineSpan returns the span from the start to the end column in the line.
+func lineSpan(line, start, end int) filestructure.Span {
+	return filestructure.Span{
+		Start: filestructure.Position{Line: line, Column: start},
+		End:   filestructure.Position{Line: line, Column: end},
+	}
+}
+
+// withStaves groups the measures of the tune into staves with the given
+// number of measures.
+func withStaves(
+	t *filestructure.Tune,
+	measuresPerStaff ...int,
+) *filestructure.Tune {
+	i := 0
+	for _, n := range measuresPerStaff {
+		t.Staves = append(t.Staves, &filestructure.Staff{
+			Measures: t.Measures[i : i+n],
+		})
+		i += n
+	}
+
+	return t
+}
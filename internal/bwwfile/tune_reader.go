@@ -0,0 +1,228 @@
+package bwwfile
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"github.com/alecthomas/participle/v2/lexer"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/common"
+	"io"
+)
+
+var (
+	tokString     = bwwLexer.Symbols()["String"]
+	tokParams     = bwwLexer.Symbols()["Params"]
+	tokTempo      = bwwLexer.Symbols()["Tempo"]
+	tokVersion    = bwwLexer.Symbols()["Version"]
+	tokMeta       = bwwLexer.Symbols()["Meta"]
+	tokSpace      = bwwLexer.Symbols()["Whitespace"]
+	tokNewline    = bwwLexer.Symbols()["Newline"]
+	tokStaffStart = bwwLexer.Symbols()["StaffStart"]
+	tokStaffEnd   = bwwLexer.Symbols()["StaffEnd"]
+)
+
+// lineInfo tells what a line of a file contains outside of a staff.
+type lineInfo struct {
+	tune  bool // The line has elements of a tune, like texts or a staff
+	title bool // The line has a tune title
+	staff bool // The line has a staff start
+}
+
+// tuneReader reads a bww file line by line and returns it in chunks of
+// a single tune, so that only one tune at a time has to be kept in memory.
+// The lines are split up into tunes the same way as the token converter does,
+// i.e. a new tune starts with a title after a staff or after another title.
+type tuneReader struct {
+	r             *bufio.Reader
+	preamble      []byte
+	preambleLines int
+	line          int    // The line in the file of the next line to read
+	next          []byte // A line that was read ahead and starts the next tune
+	nextInfo      lineInfo
+	inStaff       bool
+	chunks        int
+	eof           bool
+}
+
+// nextTune returns the chunk of the next tune of the file. Every chunk starts
+// with the preamble of the file. At the end of the file, io.EOF is returned.
+// A file without any tune is returned as single chunk with the preamble.
+func (tr *tuneReader) nextTune() (*common.FileChunk, error) {
+	if tr.chunks == 0 {
+		if err := tr.readPreamble(); err != nil {
+			return nil, err
+		}
+	}
+
+	if tr.next == nil {
+		if tr.chunks > 0 {
+			return nil, io.EOF
+		}
+
+		tr.chunks++
+		return tr.newChunk(nil, tr.line), nil
+	}
+
+	tr.chunks++
+	return tr.readTune()
+}
+
+// readTune reads the lines of the tune that starts with the line that was read ahead.
+func (tr *tuneReader) readTune() (*common.FileChunk, error) {
+	firstLine := tr.line - 1
+	data := tr.next
+	hasTune := tr.nextInfo.title || tr.nextInfo.staff
+	tr.next = nil
+	for {
+		line, info, err := tr.readLine()
+		if err != nil {
+			return nil, err
+		}
+		if line == nil {
+			break
+		}
+
+		if info.title && hasTune {
+			tr.next, tr.nextInfo = line, info
+			break
+		}
+
+		data = append(data, line...)
+		hasTune = hasTune || info.title || info.staff
+	}
+
+	return tr.newChunk(data, firstLine), nil
+}
+
+// readPreamble reads the lines before the first tune.
+func (tr *tuneReader) readPreamble() error {
+	for {
+		line, info, err := tr.readLine()
+		if err != nil || line == nil {
+			return err
+		}
+
+		if info.tune {
+			tr.next, tr.nextInfo = line, info
+			return nil
+		}
+
+		tr.preamble = append(tr.preamble, line...)
+		tr.preambleLines++
+	}
+}
+
+// readLine returns the next line with its line break or nil at the end of the file.
+func (tr *tuneReader) readLine() ([]byte, lineInfo, error) {
+	if tr.eof {
+		return nil, lineInfo{}, nil
+	}
+
+	line, err := tr.r.ReadBytes('\n')
+	if errors.Is(err, io.EOF) {
+		tr.eof = true
+		if len(line) == 0 {
+			return nil, lineInfo{}, nil
+		}
+	} else if err != nil {
+		return nil, lineInfo{}, err
+	}
+
+	tr.line++
+	return line, tr.lineInfo(line), nil
+}
+
+// lineInfo returns what the line contains, following the bww grammar.
+// Inside of a staff, only the staff end is of interest.
+func (tr *tuneReader) lineInfo(line []byte) lineInfo {
+	info := lineInfo{}
+	toks := lexLine(line)
+	for i := 0; i < len(toks); i++ {
+		if !tr.inStaff {
+			n, ok := tr.addFileToken(&info, toks[i:])
+			if !ok {
+				return info
+			}
+			i += n
+			continue
+		}
+
+		if toks[i].Type == tokStaffEnd {
+			// the rest of the line belongs to the staff end
+			tr.inStaff = false
+			return info
+		}
+	}
+
+	return info
+}
+
+// addFileToken adds the first token outside of a staff to the line info and
+// returns the number of following tokens that belong to it. It returns false
+// if the token is unknown, which makes the rest of the line unknown.
+func (tr *tuneReader) addFileToken(
+	info *lineInfo,
+	toks []lexer.Token,
+) (int, bool) {
+	switch toks[0].Type {
+	case tokVersion, tokMeta:
+	case tokTempo:
+		info.tune = true
+	case tokString:
+		info.tune = true
+		if len(toks) > 1 && toks[1].Type == tokParams {
+			fieldType, _, ok := textParams(toks[1].Value)
+			info.title = info.title || (ok && fieldType == "T")
+			return 1, true
+		}
+	case tokStaffStart:
+		info.tune = true
+		info.staff = true
+		tr.inStaff = true
+	default:
+		return 0, false
+	}
+
+	return 0, true
+}
+
+func (tr *tuneReader) newChunk(
+	data []byte,
+	firstLine int,
+) *common.FileChunk {
+	return &common.FileChunk{
+		Data:          append(bytes.Clone(tr.preamble), data...),
+		PreambleLines: tr.preambleLines,
+		FirstLine:     firstLine,
+	}
+}
+
+func isStaffEnd(s string) bool {
+	return s == "!t" || s == "!I" || s == "''!I"
+}
+
+// lexLine returns the tokens of the line without whitespace and line breaks.
+func lexLine(line []byte) []lexer.Token {
+	lex, err := bwwLexer.LexString("", string(line))
+	if err != nil {
+		return nil
+	}
+
+	var toks []lexer.Token
+	for {
+		t, err := lex.Next()
+		if err != nil || t.EOF() {
+			return toks
+		}
+
+		if t.Type != tokSpace && t.Type != tokNewline {
+			toks = append(toks, t)
+		}
+	}
+}
+
+func newTuneReader(r io.Reader) *tuneReader {
+	return &tuneReader{
+		r: bufio.NewReader(r),
+	}
+}
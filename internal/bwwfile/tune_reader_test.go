@@ -0,0 +1,125 @@
+package bwwfile
+
+import (
+	"errors"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/common"
+	"io"
+	"strings"
+)
+
+func readChunks(data string) []*common.FileChunk {
+	tr := newTuneReader(strings.NewReader(data))
+	var chunks []*common.FileChunk
+	for {
+		c, err := tr.nextTune()
+		if errors.Is(err, io.EOF) {
+			return chunks
+		}
+		Expect(err).ShouldNot(HaveOccurred())
+		chunks = append(chunks, c)
+	}
+}
+
+var _ = Describe("TuneReader", func() {
+	var data string
+	var chunks []*common.FileChunk
+
+	JustBeforeEach(func() {
+		chunks = readChunks(data)
+	})
+
+	When("reading a file with several tunes", func() {
+		BeforeEach(func() {
+			data = `Bagpipe Reader:1.0
+FontSizes,90,100,88,80,250
+
+"Tune 1",(T,L,0,0,Times New Roman,16,700,0,0,18,0,0,0)
+& C_4 !t
+"comment between the tunes"
+"Tune 2",(T,L,0,0,Times New Roman,16,700,0,0,18,0,0,0)
+"March",(Y,C,0,0,Times New Roman,14,400,0,0,18,0,0,0)
+& D_4 !
+  E_4 !t
+`
+		})
+
+		It("should return a chunk with the preamble for every tune", func() {
+			Expect(chunks).Should(Equal([]*common.FileChunk{
+				{
+					Data: []byte(`Bagpipe Reader:1.0
+FontSizes,90,100,88,80,250
+
+"Tune 1",(T,L,0,0,Times New Roman,16,700,0,0,18,0,0,0)
+& C_4 !t
+"comment between the tunes"
+`),
+					PreambleLines: 3,
+					FirstLine:     3,
+				},
+				{
+					Data: []byte(`Bagpipe Reader:1.0
+FontSizes,90,100,88,80,250
+
+"Tune 2",(T,L,0,0,Times New Roman,16,700,0,0,18,0,0,0)
+"March",(Y,C,0,0,Times New Roman,14,400,0,0,18,0,0,0)
+& D_4 !
+  E_4 !t
+`),
+					PreambleLines: 3,
+					FirstLine:     6,
+				},
+			}))
+		})
+
+		It("should map the lines of the chunk data to the lines of the file", func() {
+			Expect(chunks[1].FileLine(1)).Should(Equal(1))
+			Expect(chunks[1].FileLine(3)).Should(Equal(6))
+			Expect(chunks[1].DataLine(8)).Should(Equal(5))
+		})
+	})
+
+	When("having a title inside of a staff that isn't ended", func() {
+		BeforeEach(func() {
+			data = `Bagpipe Reader:1.0
+"Tune 1",(T,L,0,0,Times New Roman,16,700,0,0,18,0,0,0)
+& C_4 !t D_4
+"Tune 2",(T,L,0,0,Times New Roman,16,700,0,0,18,0,0,0)
+E_4 !t
+`
+		})
+
+		It("should keep the title in the staff", func() {
+			Expect(chunks).Should(HaveLen(1))
+			Expect(chunks[0].FirstLine).Should(Equal(1))
+		})
+	})
+
+	When("reading a file without a tune", func() {
+		BeforeEach(func() {
+			data = "Bagpipe Reader:1.0\n"
+		})
+
+		It("should return the preamble as single chunk", func() {
+			Expect(chunks).Should(Equal([]*common.FileChunk{
+				{
+					Data:          []byte(data),
+					PreambleLines: 1,
+					FirstLine:     1,
+				},
+			}))
+		})
+	})
+
+	When("reading empty data", func() {
+		BeforeEach(func() {
+			data = ""
+		})
+
+		It("should return a single empty chunk", func() {
+			Expect(chunks).Should(HaveLen(1))
+			Expect(chunks[0].Data).Should(BeEmpty())
+		})
+	})
+})
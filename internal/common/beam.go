@@ -0,0 +1,45 @@
+package common
+
+// Beam is the position of a note in a group of notes that are beamed together.
+type Beam uint8
+
+const (
+	NoBeam       Beam = iota // The note isn't beamed to another note
+	BeamStart                // The first note of a beam group
+	BeamContinue             // A note inside of a beam group
+	BeamEnd                  // The last note of a beam group
+)
+
+// BeamGroups returns the beams of the symbols of a measure from the flags of
+// their melody notes with the same index. Two neighbouring notes with flags
+// are beamed together if the beam of the first one points to the right, like
+// LAr_8, or the beam of the second one points to the left, like Bl_8.
+// Symbols without a flag are never beamed.
+func BeamGroups(flags []NoteFlag) []Beam {
+	beams := make([]Beam, len(flags))
+	for i := 1; i < len(flags); i++ {
+		if !beamedTogether(flags[i-1], flags[i]) {
+			continue
+		}
+
+		if beams[i-1] == BeamEnd {
+			beams[i-1] = BeamContinue
+		} else {
+			beams[i-1] = BeamStart
+		}
+		beams[i] = BeamEnd
+	}
+
+	return beams
+}
+
+func beamedTogether(
+	left NoteFlag,
+	right NoteFlag,
+) bool {
+	if left == NoFlag || right == NoFlag {
+		return false
+	}
+
+	return left == FlagRight || right == FlagLeft
+}
@@ -0,0 +1,37 @@
+package common
+
+// FileChunk is a part of a file that is parsed on its own, like a single tune
+// of a file that is read tune by tune. Its data starts with the preamble of the
+// file, i.e. the Bagpipe Player version and the meta data blocks, followed by the
+// lines of the file starting at FirstLine. The positions of the parsed tokens
+// are the positions in the whole file.
+type FileChunk struct {
+	Data          []byte
+	PreambleLines int // The number of lines of the preamble at the start of Data
+	FirstLine     int // The line in the file of the first line after the preamble
+}
+
+// FileLine returns the line in the file of a line of the chunk data.
+func (c *FileChunk) FileLine(line int) int {
+	if line < c.PreambleLines {
+		return line
+	}
+
+	return line - c.PreambleLines + c.FirstLine
+}
+
+// DataLine returns the line of the chunk data of a line in the file.
+func (c *FileChunk) DataLine(line int) int {
+	if line < c.PreambleLines {
+		return line
+	}
+
+	return line - c.FirstLine + c.PreambleLines
+}
+
+// NewFileChunk returns a chunk with the data of a whole file.
+func NewFileChunk(data []byte) *FileChunk {
+	return &FileChunk{
+		Data: data,
+	}
+}
@@ -0,0 +1,31 @@
+package common
+
+import (
+	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/symbols"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/filestructure"
+)
+
+// KeySignature is the key signature of a staff, i.e. the accidentals that
+// follow the staff start before the first note, like sharpf sharpc.
+// As the music model has no key signatures, it is kept in the source of the
+// measure that starts the staff.
+type KeySignature struct {
+	Accidentals []*symbols.Note      // The accidentals with their pitches in the order of the file
+	Spans       []filestructure.Span // The span of the accidental with the same index
+}
+
+// HasAccidentals returns true if the key signature consists of the given
+// accidentals in the same order. A nil key signature has no accidentals.
+func (ks *KeySignature) HasAccidentals(accs []*symbols.Note) bool {
+	if ks == nil || len(ks.Accidentals) != len(accs) {
+		return false
+	}
+
+	for i, a := range ks.Accidentals {
+		if a.Pitch != accs[i].Pitch || a.Accidental != accs[i].Accidental {
+			return false
+		}
+	}
+
+	return true
+}
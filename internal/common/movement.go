@@ -0,0 +1,21 @@
+package common
+
+import (
+	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/symbols/movement"
+	"slices"
+)
+
+var trailingMovementTypes = []movement.Type{
+	movement.Type_Lemluath,
+	movement.Type_Taorluath,
+	movement.Type_Crunluath,
+}
+
+// IsTrailingMovement returns true for the abbreviated piobaireachd lemluaths,
+// taorluaths and crunluaths. Their abbreviations are written after the
+// melody note they belong to.
+func IsTrailingMovement(mv *movement.Movement) bool {
+	return mv != nil &&
+		mv.Abbreviate &&
+		slices.Contains(trailingMovementTypes, mv.Type)
+}
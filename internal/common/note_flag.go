@@ -0,0 +1,19 @@
+package common
+
+// NoteFlag is the flag of a melody note token that defines to which side
+// the beam of the note points, e.g. LAr_8 or LAl_8.
+type NoteFlag uint8
+
+const (
+	NoFlag NoteFlag = iota
+	FlagLeft
+	FlagRight
+)
+
+// BarlinePosition defines if a barline is placed at the start or the end of a measure.
+type BarlinePosition uint8
+
+const (
+	LeftBarline BarlinePosition = iota
+	RightBarline
+)
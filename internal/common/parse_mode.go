@@ -0,0 +1,26 @@
+package common
+
+// ParseMode defines how the parser handles problems in a file.
+type ParseMode uint8
+
+const (
+	// StrictParsing fails parsing with an error if a file has a problem. The problems
+	// of the whole file are collected first and returned together as diagnostics.
+	StrictParsing ParseMode = iota
+	// LenientParsing skips or repairs problematic symbols and adds a parser
+	// message to the affected measure, so that the rest of the file is still parsed.
+	LenientParsing
+	// LenientParsingWithSuggestions parses like LenientParsing but replaces an
+	// unknown symbol with a known one, if there is a confident suggestion for it.
+	LenientParsingWithSuggestions
+)
+
+// IsLenient returns true if problems are repaired instead of failing parsing.
+func (m ParseMode) IsLenient() bool {
+	return m == LenientParsing || m == LenientParsingWithSuggestions
+}
+
+// AppliesSuggestions returns true if unknown symbols are replaced with a confident suggestion.
+func (m ParseMode) AppliesSuggestions() bool {
+	return m == LenientParsingWithSuggestions
+}
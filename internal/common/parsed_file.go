@@ -0,0 +1,98 @@
+package common
+
+import (
+	"github.com/tomvodi/limepipes-plugin-api/plugin/v1/messages"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/diagnostics"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/filestructure"
+)
+
+// ParsedFile is the result of parsing a bww file with the file related
+// definitions that are shared by all tunes of the file.
+type ParsedFile struct {
+	BagpipePlayerVersion filestructure.BagpipePlayerVersion
+	MetaData             filestructure.MetaData
+	Warnings             diagnostics.List // Problems of the file that didn't stop parsing, like skipped meta data blocks
+	Tunes                []*messages.ParsedTune
+	Sources              []*TuneSource // The source spans of the tune with the same index
+}
+
+// MeasureSpan returns the span of a measure of a tune in the file.
+// It returns false if there is no such measure or it has no span.
+func (pf *ParsedFile) MeasureSpan(
+	tuneIdx int,
+	measureIdx int,
+) (filestructure.Span, bool) {
+	if tuneIdx < 0 || tuneIdx >= len(pf.Sources) {
+		return filestructure.Span{}, false
+	}
+
+	return pf.Sources[tuneIdx].MeasureSpan(measureIdx)
+}
+
+// LineBreak returns true if a measure of a tune in the file is the last one
+// of its staff.
+func (pf *ParsedFile) LineBreak(
+	tuneIdx int,
+	measureIdx int,
+) bool {
+	if tuneIdx < 0 || tuneIdx >= len(pf.Sources) {
+		return false
+	}
+
+	return pf.Sources[tuneIdx].LineBreak(measureIdx)
+}
+
+// KeySignature returns the key signature of the staff that starts with a
+// measure of a tune in the file. It returns nil if there is none.
+func (pf *ParsedFile) KeySignature(
+	tuneIdx int,
+	measureIdx int,
+) *KeySignature {
+	if tuneIdx < 0 || tuneIdx >= len(pf.Sources) {
+		return nil
+	}
+
+	return pf.Sources[tuneIdx].KeySignature(measureIdx)
+}
+
+// SymbolSpan returns the span of a symbol of a measure of a tune in the file.
+// It returns false if there is no such symbol.
+func (pf *ParsedFile) SymbolSpan(
+	tuneIdx int,
+	measureIdx int,
+	symbolIdx int,
+) (filestructure.Span, bool) {
+	if tuneIdx < 0 || tuneIdx >= len(pf.Sources) {
+		return filestructure.Span{}, false
+	}
+
+	return pf.Sources[tuneIdx].SymbolSpan(measureIdx, symbolIdx)
+}
+
+// NoteFlag returns the beam flag of the melody note of a symbol of a measure
+// of a tune in the file. It returns NoFlag if there is no such symbol.
+func (pf *ParsedFile) NoteFlag(
+	tuneIdx int,
+	measureIdx int,
+	symbolIdx int,
+) NoteFlag {
+	if tuneIdx < 0 || tuneIdx >= len(pf.Sources) {
+		return NoFlag
+	}
+
+	return pf.Sources[tuneIdx].NoteFlag(measureIdx, symbolIdx)
+}
+
+// Beam returns the beam of a symbol of a measure of a tune in the file.
+// It returns NoBeam if there is no such symbol.
+func (pf *ParsedFile) Beam(
+	tuneIdx int,
+	measureIdx int,
+	symbolIdx int,
+) Beam {
+	if tuneIdx < 0 || tuneIdx >= len(pf.Sources) {
+		return NoBeam
+	}
+
+	return pf.Sources[tuneIdx].Beam(measureIdx, symbolIdx)
+}
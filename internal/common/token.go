@@ -1,7 +1,10 @@
 package common
 
+import "github.com/tomvodi/limepipes-plugin-bww/internal/filestructure"
+
 type Token struct {
 	Value any
 	Line  int
 	Col   int
+	Style *filestructure.TextStyle // The style of text tokens like the title or inline texts
 }
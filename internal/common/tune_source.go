@@ -0,0 +1,132 @@
+package common
+
+import "github.com/tomvodi/limepipes-plugin-bww/internal/filestructure"
+
+// TuneSource holds the spans in the file of the measures and symbols of a
+// converted tune, as the music model has no fields for source positions.
+// The measures and symbols have the same index as in the tune.
+// The text styles of the tune are kept here as well, as the music model
+// has no typography.
+type TuneSource struct {
+	Header   HeaderStyles
+	Measures []*MeasureSource
+}
+
+// HeaderStyles holds the text styles of the tune header fields. A style is nil
+// if the field has no valid style in the file.
+type HeaderStyles struct {
+	Title       *filestructure.TextStyle
+	Type        *filestructure.TextStyle
+	Composer    *filestructure.TextStyle
+	Footer      []*filestructure.TextStyle // The style of the footer with the same index
+	InlineTexts []*filestructure.TextStyle // The style of the inline text with the same index
+}
+
+// MeasureSource holds the span of a measure and the spans of its symbols.
+// The span of a symbol that was merged from several tokens, like an
+// embellishment and its melody note, covers all of them. The beam flags
+// of the melody notes are kept, as the music model has no beams.
+type MeasureSource struct {
+	Span         filestructure.Span
+	Symbols      []filestructure.Span
+	LineBreak    bool          // The measure is the last one of its staff
+	LeftRepeat   bool          // The left barline is a repeat that its navigation mark replaced in the measure
+	RightRepeat  bool          // The right barline is a repeat that its navigation mark replaced in the measure
+	KeySignature *KeySignature // The key signature of the staff that the measure starts
+	Flags        []NoteFlag    // The beam flag of the melody note of the symbol with the same index
+	Beams        []Beam        // The beam of the symbol with the same index
+
+	InlineTextStyles []*filestructure.TextStyle   // The style of the inline text of the measure with the same index
+	SymbolTextStyles [][]*filestructure.TextStyle // The styles of the inline texts of the symbol with the same index
+}
+
+// MeasureSpan returns the span of the measure with the index.
+// It returns false if there is no such measure or it has no span.
+func (ts *TuneSource) MeasureSpan(
+	measureIdx int,
+) (filestructure.Span, bool) {
+	if measureIdx < 0 || measureIdx >= len(ts.Measures) {
+		return filestructure.Span{}, false
+	}
+
+	span := ts.Measures[measureIdx].Span
+	return span, !span.IsZero()
+}
+
+// LineBreak returns true if the measure with the index is the last one of
+// its staff, i.e. a new staff starts after it.
+func (ts *TuneSource) LineBreak(
+	measureIdx int,
+) bool {
+	if measureIdx < 0 || measureIdx >= len(ts.Measures) {
+		return false
+	}
+
+	return ts.Measures[measureIdx].LineBreak
+}
+
+// KeySignature returns the key signature of the staff that starts with the
+// measure with the index. It returns nil if the measure has no key signature.
+func (ts *TuneSource) KeySignature(
+	measureIdx int,
+) *KeySignature {
+	if measureIdx < 0 || measureIdx >= len(ts.Measures) {
+		return nil
+	}
+
+	return ts.Measures[measureIdx].KeySignature
+}
+
+// SymbolSpan returns the span of the symbol with the index in the measure.
+// It returns false if there is no such symbol.
+func (ts *TuneSource) SymbolSpan(
+	measureIdx int,
+	symbolIdx int,
+) (filestructure.Span, bool) {
+	if measureIdx < 0 || measureIdx >= len(ts.Measures) {
+		return filestructure.Span{}, false
+	}
+
+	symbols := ts.Measures[measureIdx].Symbols
+	if symbolIdx < 0 || symbolIdx >= len(symbols) {
+		return filestructure.Span{}, false
+	}
+
+	return symbols[symbolIdx], true
+}
+
+// NoteFlag returns the beam flag of the melody note of the symbol with the
+// index in the measure. It returns NoFlag if there is no such symbol.
+func (ts *TuneSource) NoteFlag(
+	measureIdx int,
+	symbolIdx int,
+) NoteFlag {
+	if measureIdx < 0 || measureIdx >= len(ts.Measures) {
+		return NoFlag
+	}
+
+	flags := ts.Measures[measureIdx].Flags
+	if symbolIdx < 0 || symbolIdx >= len(flags) {
+		return NoFlag
+	}
+
+	return flags[symbolIdx]
+}
+
+// Beam returns the beam of the symbol with the index in the measure.
+// It returns NoBeam if there is no such symbol.
+func (ts *TuneSource) Beam(
+	measureIdx int,
+	symbolIdx int,
+) Beam {
+	if measureIdx < 0 || measureIdx >= len(ts.Measures) {
+		return NoBeam
+	}
+
+	beams := ts.Measures[measureIdx].Beams
+	if symbolIdx < 0 || symbolIdx >= len(beams) {
+		return NoBeam
+	}
+
+	return beams[symbolIdx]
+}
@@ -0,0 +1,67 @@
+package cst
+
+import (
+	"github.com/tomvodi/limepipes-plugin-bww/internal/filestructure"
+	"slices"
+)
+
+// File is the syntax tree of a bww file.
+type File struct {
+	Root   *Node
+	leaves []*Node // The leaf nodes without trivia in the order of the file
+}
+
+// String returns the source of the file.
+func (f *File) String() string {
+	return f.Root.Source()
+}
+
+// NodeAt returns the leaf node that starts at the position, like the position
+// of a token or a music symbol of the file structure. Trivia are ignored.
+// It returns nil if no node starts at the position.
+func (f *File) NodeAt(pos filestructure.Position) *Node {
+	i, found := slices.BinarySearchFunc(f.leaves, pos, comparePosition)
+	if !found {
+		return nil
+	}
+
+	return f.leaves[i]
+}
+
+// NodesIn returns the leaf nodes without trivia that start inside of the span,
+// like the barlines and symbols of a measure.
+func (f *File) NodesIn(span filestructure.Span) []*Node {
+	start, _ := slices.BinarySearchFunc(f.leaves, span.Start, comparePosition)
+	end, _ := slices.BinarySearchFunc(f.leaves, span.End, comparePosition)
+
+	return f.leaves[start:end]
+}
+
+func comparePosition(
+	n *Node,
+	pos filestructure.Position,
+) int {
+	switch {
+	case n.Pos.Before(pos):
+		return -1
+	case pos.Before(n.Pos):
+		return 1
+	default:
+		return 0
+	}
+}
+
+// NewFile returns the file with the syntax tree of the root node.
+func NewFile(root *Node) *File {
+	f := &File{
+		Root: root,
+	}
+	Inspect(root, func(n *Node) bool {
+		if n != nil && n.IsLeaf() && !n.Kind.IsTrivia() {
+			f.leaves = append(f.leaves, n)
+		}
+		return true
+	})
+
+	return f
+}
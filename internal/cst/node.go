@@ -0,0 +1,112 @@
+// Package cst contains a lossless concrete syntax tree of a bww file for
+// tooling like editors and formatters. Every byte of the file is part of a
+// leaf node, including whitespace and line breaks, so the file can be
+// reproduced exactly from the tree.
+package cst
+
+import (
+	"github.com/tomvodi/limepipes-plugin-bww/internal/filestructure"
+	"strings"
+)
+
+// Kind is the kind of syntax tree node.
+type Kind string
+
+const (
+	KindFile        Kind = "File"
+	KindVersion     Kind = "Version"  // The Bagpipe Player version
+	KindMetaData    Kind = "MetaData" // A meta data line like TuneFormat,(...)
+	KindTempo       Kind = "Tempo"    // The tune tempo or a tempo change inside of a staff
+	KindText        Kind = "Text"     // A quoted text with its parameters, if it has some
+	KindString      Kind = "String"   // The quoted string of a text
+	KindParams      Kind = "Params"   // The parameters of a text like ,(T,L,0,0,...)
+	KindStaff       Kind = "Staff"    // A staff from the staff start to the staff end
+	KindStaffStart  Kind = "StaffStart"
+	KindStaffEnd    Kind = "StaffEnd"
+	KindBarline     Kind = "Barline"
+	KindNavigation  Kind = "Navigation"
+	KindSymbol      Kind = "Symbol"      // Any other symbol of a staff like a melody note
+	KindUnknownLine Kind = "UnknownLine" // A line that doesn't match the bww grammar
+	KindUnknown     Kind = "Unknown"     // A token of an unknown line
+	KindWhitespace  Kind = "Whitespace"
+	KindNewline     Kind = "Newline" // A line break \n or \r\n
+)
+
+// IsTrivia returns true for whitespace and line breaks.
+func (k Kind) IsTrivia() bool {
+	return k == KindWhitespace || k == KindNewline
+}
+
+// Node is a node of the syntax tree. Leaf nodes hold the text of a single
+// token, the other nodes group their children. The offsets and positions are
+// the ones of the parsed data, they aren't updated if the text of a node is changed.
+type Node struct {
+	Kind     Kind
+	Text     string                 // The text of a leaf node
+	Offset   int                    // The byte offset of the node in the file
+	Pos      filestructure.Position // The zero based line and byte column of the node in the file
+	Parent   *Node
+	Children []*Node
+}
+
+// IsLeaf returns true if the node has no children.
+func (n *Node) IsLeaf() bool {
+	return len(n.Children) == 0
+}
+
+// Len returns the number of bytes of the node in the file.
+func (n *Node) Len() int {
+	if n.IsLeaf() {
+		return len(n.Text)
+	}
+
+	last := n.Children[len(n.Children)-1]
+	return last.Offset + last.Len() - n.Offset
+}
+
+// End returns the byte offset after the last byte of the node.
+func (n *Node) End() int {
+	return n.Offset + n.Len()
+}
+
+// Span returns the range of the node in the file. A node that ends with a
+// line break ends at the start of the next line.
+func (n *Node) Span() filestructure.Span {
+	last := n
+	for !last.IsLeaf() {
+		last = last.Children[len(last.Children)-1]
+	}
+
+	end := filestructure.Position{
+		Line:   last.Pos.Line,
+		Column: last.Pos.Column + len(last.Text),
+	}
+	if last.Kind == KindNewline {
+		end = filestructure.Position{Line: last.Pos.Line + 1}
+	}
+
+	return filestructure.Span{
+		Start: n.Pos,
+		End:   end,
+	}
+}
+
+// Source returns the text of the node with the texts of all its children,
+// i.e. the part of the file that the node covers.
+func (n *Node) Source() string {
+	sb := &strings.Builder{}
+	Inspect(n, func(c *Node) bool {
+		if c != nil && c.IsLeaf() {
+			sb.WriteString(c.Text)
+		}
+		return true
+	})
+
+	return sb.String()
+}
+
+// AddChild appends the child to the children of the node.
+func (n *Node) AddChild(c *Node) {
+	c.Parent = n
+	n.Children = append(n.Children, c)
+}
@@ -0,0 +1,107 @@
+package cst_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/cst"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/filestructure"
+)
+
+func newLeaf(
+	kind cst.Kind,
+	text string,
+	offset int,
+	pos filestructure.Position,
+) *cst.Node {
+	return &cst.Node{
+		Kind:   kind,
+		Text:   text,
+		Offset: offset,
+		Pos:    pos,
+	}
+}
+
+// kindCounter counts the visited nodes by their kind and the
+// nodes whose children were visited.
+type kindCounter struct {
+	kinds map[cst.Kind]int
+	left  int
+}
+
+func (c *kindCounter) Visit(n *cst.Node) cst.Visitor {
+	if n == nil {
+		c.left++
+		return nil
+	}
+
+	c.kinds[n.Kind]++
+	if n.Kind == cst.KindText {
+		return nil
+	}
+
+	return c
+}
+
+var _ = Describe("Node", func() {
+	var root *cst.Node
+	var staff *cst.Node
+
+	BeforeEach(func() {
+		// & "comment" LA_4 !t\n
+		staff = &cst.Node{Kind: cst.KindStaff}
+		staff.AddChild(newLeaf(cst.KindStaffStart, "&", 0, filestructure.Position{}))
+		staff.AddChild(newLeaf(cst.KindWhitespace, " ", 1, filestructure.Position{Column: 1}))
+		text := &cst.Node{Kind: cst.KindText, Offset: 2, Pos: filestructure.Position{Column: 2}}
+		text.AddChild(newLeaf(cst.KindString, `"comment"`, 2, filestructure.Position{Column: 2}))
+		staff.AddChild(text)
+		staff.AddChild(newLeaf(cst.KindWhitespace, " ", 11, filestructure.Position{Column: 11}))
+		staff.AddChild(newLeaf(cst.KindSymbol, "LA_4", 12, filestructure.Position{Column: 12}))
+		staff.AddChild(newLeaf(cst.KindWhitespace, " ", 16, filestructure.Position{Column: 16}))
+		staff.AddChild(newLeaf(cst.KindStaffEnd, "!t", 17, filestructure.Position{Column: 17}))
+
+		root = &cst.Node{Kind: cst.KindFile}
+		root.AddChild(staff)
+		root.AddChild(newLeaf(cst.KindNewline, "\n", 19, filestructure.Position{Column: 19}))
+	})
+
+	It("should return the source and the range of a node", func() {
+		Expect(root.Source()).Should(Equal("& \"comment\" LA_4 !t\n"))
+		Expect(staff.Len()).Should(Equal(19))
+		Expect(staff.End()).Should(Equal(19))
+		Expect(staff.Span()).Should(Equal(filestructure.Span{
+			End: filestructure.Position{Column: 19},
+		}))
+		Expect(root.Span()).Should(Equal(filestructure.Span{
+			End: filestructure.Position{Line: 1},
+		}))
+		Expect(staff.Children[2].Parent).Should(Equal(staff))
+	})
+
+	It("should walk the nodes that the visitor wants to visit", func() {
+		c := &kindCounter{kinds: map[cst.Kind]int{}}
+		cst.Walk(c, root)
+		Expect(c.kinds).Should(Equal(map[cst.Kind]int{
+			cst.KindFile:       1,
+			cst.KindStaff:      1,
+			cst.KindStaffStart: 1,
+			cst.KindWhitespace: 3,
+			cst.KindText:       1,
+			cst.KindSymbol:     1,
+			cst.KindStaffEnd:   1,
+			cst.KindNewline:    1,
+		}))
+		// all visited nodes but the text, whose children are skipped
+		Expect(c.left).Should(Equal(9))
+	})
+
+	It("should find the leaf nodes of the file by their positions", func() {
+		f := cst.NewFile(root)
+		Expect(f.String()).Should(Equal(root.Source()))
+		Expect(f.NodeAt(filestructure.Position{Column: 12}).Text).Should(Equal("LA_4"))
+		Expect(f.NodeAt(filestructure.Position{Column: 1})).Should(BeNil())
+		Expect(f.NodesIn(filestructure.Span{
+			Start: filestructure.Position{Column: 2},
+			End:   filestructure.Position{Column: 17},
+		})).Should(HaveLen(2))
+	})
+})
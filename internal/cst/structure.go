@@ -0,0 +1,35 @@
+package cst
+
+import "github.com/tomvodi/limepipes-plugin-bww/internal/filestructure"
+
+// MeasureOf returns the measure of the tune whose span contains the node and
+// its index. As the span of a measure only covers its barlines, symbols
+// and navigation marks, texts of a staff don't belong to a measure.
+// It returns nil and -1 if no measure contains the node.
+func MeasureOf(
+	t *filestructure.Tune,
+	n *Node,
+) (*filestructure.Measure, int) {
+	for i, m := range t.Measures {
+		if m.Span.Contains(n.Pos) {
+			return m, i
+		}
+	}
+
+	return nil, -1
+}
+
+// SymbolOf returns the music symbol of the measure that starts at the node.
+// It returns nil if the node isn't a symbol of the measure.
+func SymbolOf(
+	m *filestructure.Measure,
+	n *Node,
+) *filestructure.MusicSymbol {
+	for _, sym := range m.Symbols {
+		if sym.Pos == n.Pos {
+			return sym
+		}
+	}
+
+	return nil
+}
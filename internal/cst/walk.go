@@ -0,0 +1,45 @@
+package cst
+
+// Visitor visits the nodes of a syntax tree with Walk. If Visit returns
+// a visitor w that isn't nil, the children of the node are visited with w,
+// followed by a call of w.Visit(nil).
+type Visitor interface {
+	Visit(n *Node) (w Visitor)
+}
+
+// Walk traverses the syntax tree in depth-first order. It starts with
+// v.Visit(n), which must not be nil.
+func Walk(
+	v Visitor,
+	n *Node,
+) {
+	if v = v.Visit(n); v == nil {
+		return
+	}
+
+	for _, c := range n.Children {
+		Walk(v, c)
+	}
+
+	v.Visit(nil)
+}
+
+type inspector func(*Node) bool
+
+func (f inspector) Visit(n *Node) Visitor {
+	if f(n) {
+		return f
+	}
+
+	return nil
+}
+
+// Inspect traverses the syntax tree in depth-first order and calls f for
+// every node. If f returns true, the children of the node are inspected,
+// followed by a call of f(nil).
+func Inspect(
+	n *Node,
+	f func(*Node) bool,
+) {
+	Walk(inspector(f), n)
+}
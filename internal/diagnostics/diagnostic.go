@@ -0,0 +1,85 @@
+// Package diagnostics contains the problems that are found while parsing a file.
+// Every problem is a Diagnostic with the position of the offending text in the
+// file, so that all problems of a file can be listed at once.
+package diagnostics
+
+import (
+	"errors"
+	"fmt"
+	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/measure"
+)
+
+// Code identifies the kind of problem of a diagnostic.
+type Code string
+
+const (
+	CodeEmptyData              Code = "empty-data"
+	CodeUnknownLine            Code = "unknown-line"
+	CodeMisplacedStaffEnd      Code = "misplaced-staff-end"
+	CodeInvalidTempo           Code = "invalid-tempo"
+	CodeNoTokens               Code = "no-tokens"
+	CodeNoBagpipePlayerVersion Code = "no-bagpipe-player-version"
+	CodeEmptySymbol            Code = "empty-symbol"
+	CodeUnknownSymbol          Code = "unknown-symbol"
+	CodeUnknownBarline         Code = "unknown-barline"
+	CodeUnknownNavigation      Code = "unknown-navigation"
+	CodeNavigationOnRepeat     Code = "navigation-on-repeat"
+	CodeUnknownTimeSignature   Code = "unknown-time-signature"
+	CodeInvalidMetaData        Code = "invalid-meta-data"
+	CodeInternal               Code = "internal"
+)
+
+// Position is a position in the file with zero based line and column,
+// the same as the positions of the tokens.
+type Position struct {
+	Line   int
+	Column int
+}
+
+type Diagnostic struct {
+	Code     Code
+	Severity measure.Severity
+	Message  string
+	Start    Position // The position of the first character of the offending text
+	End      Position // The position right after the offending text
+	Text     string   // The offending text
+	Fix      string   // A suggested fix for the problem, empty if there is none
+	Err      error    // The underlying error like common.ErrSymbolNotFound
+
+	Suggestions []string // Known tokens that are similar to the offending text, the best first
+	Replacement string   // A suggestion that is certain enough to replace the offending text
+}
+
+func (d *Diagnostic) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s", d.Start.Line, d.Start.Column, d.Message)
+}
+
+func (d *Diagnostic) Unwrap() error {
+	return d.Err
+}
+
+// At sets the start of the diagnostic to the given position and the end
+// to the end of the offending text in the same line.
+func (d *Diagnostic) At(
+	line int,
+	column int,
+) *Diagnostic {
+	d.Start = Position{Line: line, Column: column}
+	d.End = Position{Line: line, Column: column + len(d.Text)}
+	return d
+}
+
+// At sets the position of the error if it is a diagnostic and returns it.
+// Any other error is returned unchanged.
+func At(
+	err error,
+	line int,
+	column int,
+) error {
+	var d *Diagnostic
+	if errors.As(err, &d) {
+		d.At(line, column)
+	}
+
+	return err
+}
@@ -0,0 +1,73 @@
+package diagnostics
+
+import (
+	"errors"
+	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/measure"
+	"strings"
+)
+
+// List is a list of diagnostics that is returned as a single error,
+// so that all problems of a file are reported and not only the first one.
+type List []*Diagnostic
+
+func (l List) Error() string {
+	msgs := make([]string, len(l))
+	for i, d := range l {
+		msgs[i] = d.Error()
+	}
+
+	return strings.Join(msgs, "\n")
+}
+
+func (l List) Unwrap() []error {
+	errs := make([]error, len(l))
+	for i, d := range l {
+		errs[i] = d
+	}
+
+	return errs
+}
+
+// Add adds the error to the list. The diagnostics of a list are added
+// one by one and any other error is added as internal error.
+func (l *List) Add(err error) {
+	if err == nil {
+		return
+	}
+
+	var list List
+	if errors.As(err, &list) {
+		*l = append(*l, list...)
+		return
+	}
+
+	var d *Diagnostic
+	if errors.As(err, &d) {
+		*l = append(*l, d)
+		return
+	}
+
+	*l = append(*l, &Diagnostic{
+		Code:     CodeInternal,
+		Severity: measure.Severity_Error,
+		Message:  err.Error(),
+		Err:      err,
+	})
+}
+
+// Err returns the list as error or nil if it is empty.
+func (l List) Err() error {
+	if len(l) == 0 {
+		return nil
+	}
+
+	return l
+}
+
+// FromError returns the diagnostics of the error. An error that isn't a
+// diagnostic or a list of diagnostics is returned as internal error.
+func FromError(err error) List {
+	var l List
+	l.Add(err)
+	return l
+}
@@ -0,0 +1,70 @@
+package diagnostics
+
+import (
+	"errors"
+	"fmt"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/measure"
+)
+
+var _ = Describe("List", func() {
+	var errNotFound = errors.New("not found")
+	var l List
+	var d1 *Diagnostic
+	var d2 *Diagnostic
+
+	BeforeEach(func() {
+		l = nil
+		d1 = (&Diagnostic{
+			Code:     CodeUnknownSymbol,
+			Severity: measure.Severity_Error,
+			Message:  "symbol LA4 not found",
+			Text:     "LA4",
+			Err:      errNotFound,
+		}).At(3, 5)
+		d2 = (&Diagnostic{
+			Code:     CodeMisplacedStaffEnd,
+			Severity: measure.Severity_Error,
+			Message:  "staff end !t is not at the end of the line",
+			Text:     "!t",
+		}).At(7, 2)
+	})
+
+	It("should set the end of a diagnostic after its text", func() {
+		Expect(d1.Start).Should(Equal(Position{Line: 3, Column: 5}))
+		Expect(d1.End).Should(Equal(Position{Line: 3, Column: 8}))
+	})
+
+	It("should return no error if it is empty", func() {
+		l.Add(nil)
+		Expect(l.Err()).Should(BeNil())
+	})
+
+	It("should flatten added lists", func() {
+		l.Add(d1)
+		l.Add(fmt.Errorf("tune 2: %w", List{d2}))
+		Expect(l).Should(Equal(List{d1, d2}))
+		Expect(l.Error()).Should(Equal(
+			"line 3, column 5: symbol LA4 not found\n" +
+				"line 7, column 2: staff end !t is not at the end of the line"))
+	})
+
+	It("should keep the underlying errors", func() {
+		l.Add(d2)
+		l.Add(d1)
+		Expect(l.Err()).Should(MatchError(errNotFound))
+	})
+
+	It("should add other errors as internal errors", func() {
+		l.Add(errNotFound)
+		Expect(l).Should(HaveLen(1))
+		Expect(l[0].Code).Should(Equal(CodeInternal))
+		Expect(l[0].Message).Should(Equal("not found"))
+	})
+
+	It("should set the position of a diagnostic error", func() {
+		err := At(&Diagnostic{Text: "LA4"}, 2, 1)
+		Expect(FromError(err)[0].End).Should(Equal(Position{Line: 2, Column: 4}))
+	})
+})
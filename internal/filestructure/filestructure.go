@@ -1,5 +1,10 @@
 package filestructure
 
+import (
+	"fmt"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/diagnostics"
+)
+
 type BagpipePlayerVersion string
 type TimelineEnd string
 type TuneComment string
@@ -13,8 +18,11 @@ type TuneInline string
 type Barline string
 type StaffStart string
 type StaffEnd string
+type Segno string
+type Fine string
 type DalSegno string
 type DacapoAlFine string
+type Navigation string // A navigation mark like segno, fine, dalsegno or dacapoalfine
 type StaffComment string
 type StaffInline string
 type TuneTempo uint32
@@ -22,52 +30,167 @@ type TempoChange uint32
 
 type BwwFile struct {
 	BagpipePlayerVersion BagpipePlayerVersion
+	MetaData             MetaData
+	Warnings             diagnostics.List // Problems that didn't stop parsing, like skipped meta data blocks
 	TuneDefs             []TuneDefinition
 }
 
 type TuneDefinition struct {
-	Data []byte
+	Data []byte // The source data of the tune with the file preamble
 	Tune *Tune
 }
 
 type Tune struct {
 	Header   *TuneHeader
 	Measures []*Measure
+	Staves   []*Staff // The measures of Measures grouped by the staves they are written in
+}
+
+// Staff is a staff line of a tune from a staff start & to a staff end
+// like !t. The measures are the same as in the Measures of the tune.
+type Staff struct {
+	Measures []*Measure
 }
 
 type TuneHeader struct {
-	Title       TuneTitle
-	Type        TuneType
-	Composer    TuneComposer
-	Footer      []TuneFooter
-	InlineTexts []TuneInline
-	Comments    []TuneComment
-	Tempo       TuneTempo
+	Title            TuneTitle
+	TitleStyle       *TextStyle
+	Type             TuneType
+	TypeStyle        *TextStyle
+	Composer         TuneComposer
+	ComposerStyle    *TextStyle
+	Footer           []TuneFooter
+	FooterStyles     []*TextStyle // The style of the footer with the same index
+	InlineTexts      []TuneInline
+	InlineTextStyles []*TextStyle // The style of the inline text with the same index
+	Comments         []TuneComment
+	Tempo            TuneTempo
 }
 
 type Measure struct {
-	StaffComments    []StaffComment // A comment that is placed directly above a staff
-	StaffInlineTexts []StaffInline  // Text that is placed directly above a staff
-	InlineTexts      []InlineText
-	InlineComments   []InlineComment
-	LeftBarline      Barline
-	RightBarline     Barline
-	Symbols          []*MusicSymbol
+	StaffComments         []StaffComment // A comment that is placed directly above a staff
+	StaffInlineTexts      []StaffInline  // Text that is placed directly above a staff
+	StaffInlineTextStyles []*TextStyle   // The style of the staff inline text with the same index
+	InlineTexts           []InlineText
+	InlineTextStyles      []*TextStyle // The style of the inline text with the same index
+	InlineComments        []InlineComment
+	LeftBarline           Barline
+	RightBarline          Barline
+	LeftNavigation        Navigation // A segno at the beginning of the measure
+	RightNavigation       Navigation // A fine, dalsegno or dacapoalfine at the end of the measure
+	Symbols               []*MusicSymbol
+	ParserMessages        []ParserMessage // Problems of the measure that were repaired in lenient mode
+	Span                  Span            // The range of the barlines, symbols and navigation marks in the file
 }
 
 type MusicSymbol struct {
-	Pos         Position
-	Text        string
-	InlineTexts []InlineText
-	Comments    []InlineComment
-	TempoChange TempoChange
+	Pos              Position
+	Text             string
+	InlineTexts      []InlineText
+	InlineTextStyles []*TextStyle // The style of the inline text with the same index
+	Comments         []InlineComment
+	TempoChange      TempoChange
 }
 
 func (m *MusicSymbol) IsTempoChange() bool {
 	return m.TempoChange > 0
 }
 
+// Span returns the range of the symbol in the file.
+func (m *MusicSymbol) Span() Span {
+	text := m.Text
+	if m.IsTempoChange() {
+		text = fmt.Sprintf("TuneTempo,%d", m.TempoChange)
+	}
+
+	return Span{
+		Start: m.Pos,
+		End: Position{
+			Line:   m.Pos.Line,
+			Column: m.Pos.Column + len(text),
+		},
+	}
+}
+
+type TextAlignment string
+
+const (
+	AlignLeft   TextAlignment = "L"
+	AlignCenter TextAlignment = "C"
+	AlignRight  TextAlignment = "R"
+)
+
+// TextStyle is the style of a text field, i.e. the parameter list that follows
+// the text without the field type, e.g. L,0,0,Times New Roman,15,700,0,1,2,0,0,32768
+// for "Title",(T,L,0,0,Times New Roman,15,700,0,1,2,0,0,32768).
+// The font values follow the Windows font definition (LOGFONT) that is used
+// by Bagpipe Music Writer.
+type TextStyle struct {
+	Alignment      TextAlignment
+	OffsetX        int
+	OffsetY        int
+	FontFace       string
+	FontSize       int
+	FontWeight     int // 400 is a regular and 700 a bold font
+	Italic         int // 0 is a regular and 255 an italic font
+	CharSet        int
+	PitchAndFamily int
+	Underline      int
+	StrikeOut      int
+	Color          int // The color as RGB value in the order 0x00BBGGRR
+}
+
+// String returns the parameters of the text style as they are written in a
+// bww file after the field type, e.g. L,0,0,Times New Roman,15,700,0,1,2,0,0,32768
+func (s *TextStyle) String() string {
+	return fmt.Sprintf("%s,%d,%d,%s,%d,%d,%d,%d,%d,%d,%d,%d",
+		s.Alignment, s.OffsetX, s.OffsetY, s.FontFace, s.FontSize, s.FontWeight,
+		s.Italic, s.CharSet, s.PitchAndFamily, s.Underline, s.StrikeOut, s.Color,
+	)
+}
+
+func (s *TextStyle) IsItalic() bool {
+	return s.Italic != 0
+}
+
+func (s *TextStyle) IsBold() bool {
+	return s.FontWeight >= 700
+}
+
+// ParserMessage is a problem in the file structure that was repaired
+// while tokenizing in lenient mode.
+type ParserMessage struct {
+	Symbol string
+	Text   string
+}
+
 type Position struct {
 	Line   int
 	Column int
 }
+
+// Before returns true if the position is in front of the other position.
+func (p Position) Before(o Position) bool {
+	if p.Line != o.Line {
+		return p.Line < o.Line
+	}
+
+	return p.Column < o.Column
+}
+
+// Span is the range of a text in the file from the position of its first
+// character to the position after its last character.
+type Span struct {
+	Start Position
+	End   Position
+}
+
+// IsZero returns true if the span doesn't cover any text.
+func (s Span) IsZero() bool {
+	return s == Span{}
+}
+
+// Contains returns true if the position is inside of the span.
+func (s Span) Contains(p Position) bool {
+	return !p.Before(s.Start) && p.Before(s.End)
+}
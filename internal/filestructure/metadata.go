@@ -0,0 +1,126 @@
+package filestructure
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ChanterPitch is the index of a pitch of the chanter in a PitchValues list.
+type ChanterPitch uint8
+
+const (
+	LowG ChanterPitch = iota
+	LowA
+	B
+	C
+	D
+	E
+	F
+	HighG
+	HighA
+	ChanterPitchCount
+)
+
+const (
+	InstrumentMappingCount = 7
+	GracenoteDurationCount = 13
+	FontSizeCount          = 5
+	TuneFormatParamCount   = 11
+)
+
+// MetaData contains the file level settings that precede the tunes of a bww file.
+// A field is nil if the file doesn't contain the corresponding block.
+type MetaData struct {
+	MIDINoteMappings   *MIDINoteMappings
+	FrequencyMappings  *FrequencyMappings
+	InstrumentMappings *InstrumentMappings
+	GracenoteDurations *GracenoteDurations
+	FontSizes          *FontSizes
+	TuneFormat         *TuneFormat
+}
+
+// PitchValues holds a value for every pitch of the chanter from low G to high A.
+type PitchValues [ChanterPitchCount]int
+
+func (p PitchValues) Value(pitch ChanterPitch) int {
+	return p[pitch]
+}
+
+// PitchMappings holds a value for every pitch of the chanter in the order
+// they are written in a bww file, first the natural, then the sharp and
+// then the flat pitches.
+type PitchMappings struct {
+	Natural PitchValues
+	Sharp   PitchValues
+	Flat    PitchValues
+}
+
+// MIDINoteMappings maps the pitches to the MIDI note numbers that are used
+// for playback, e.g. MIDINoteMappings,(54,56,58,59,61,63,64,66,68,...)
+type MIDINoteMappings PitchMappings
+
+// FrequencyMappings maps the pitches to their frequency in Hz,
+// e.g. FrequencyMappings,(370,415,466,494,554,622,659,740,831,...)
+type FrequencyMappings PitchMappings
+
+// InstrumentMappings holds the MIDI program numbers and playback settings
+// of the instruments, e.g. InstrumentMappings,(71,71,45,33,1000,60,70)
+type InstrumentMappings [InstrumentMappingCount]int
+
+// GracenoteDurations holds the playback durations of the gracenotes,
+// e.g. GracenoteDurations,(20,40,30,50,100,200,800,1200,250,250,250,500,200)
+type GracenoteDurations [GracenoteDurationCount]int
+
+// FontSizes holds the font sizes of the score in percent,
+// e.g. FontSizes,(90,100,100,80,250)
+type FontSizes [FontSizeCount]int
+
+// Orientation is the page orientation of a TuneFormat.
+type Orientation string
+
+const (
+	OrientationPortrait  Orientation = "P"
+	OrientationLandscape Orientation = "L"
+)
+
+// TuneFormat holds the page setup of the score that is used for printing,
+// e.g. TuneFormat,(1,0,M,L,500,500,500,500,P,0,0)
+// The parameters are, in this order, two layout flags, the layout mode, the page size,
+// the four page margins, the orientation and two more layout flags.
+type TuneFormat struct {
+	LayoutFlags [2]bool     // The first two parameters, 1 is true and 0 is false
+	LayoutMode  string      // A single letter like M, kept as it is written in the file
+	PageSize    string      // A single letter like L for letter, kept as it is written in the file
+	Margins     [4]int      // The page margins, like 500
+	Orientation Orientation // P for portrait or L for landscape
+	ExtraFlags  [2]bool     // The last two parameters, 1 is true and 0 is false
+}
+
+// String returns the tune format as it is written in a bww file.
+func (tf *TuneFormat) String() string {
+	params := []string{
+		flagParam(tf.LayoutFlags[0]),
+		flagParam(tf.LayoutFlags[1]),
+		tf.LayoutMode,
+		tf.PageSize,
+	}
+	for _, m := range tf.Margins {
+		params = append(params, strconv.Itoa(m))
+	}
+	params = append(params,
+		string(tf.Orientation),
+		flagParam(tf.ExtraFlags[0]),
+		flagParam(tf.ExtraFlags[1]),
+	)
+
+	return fmt.Sprintf("TuneFormat,(%s)", strings.Join(params, ","))
+}
+
+func flagParam(f bool) string {
+	if f {
+		return "1"
+	}
+
+	return "0"
+}
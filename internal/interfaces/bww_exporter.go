@@ -0,0 +1,9 @@
+package interfaces
+
+import (
+	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/tune"
+)
+
+type BwwExporter interface {
+	ExportBwwData(tunes []*tune.Tune) ([]byte, error)
+}
@@ -2,8 +2,13 @@ package interfaces
 
 import (
 	"github.com/tomvodi/limepipes-plugin-api/plugin/v1/messages"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/common"
+	"io"
+	"iter"
 )
 
 type BwwParser interface {
 	ParseBwwData(data []byte) ([]*messages.ParsedTune, error)
+	ParseBwwFile(data []byte) (*common.ParsedFile, error)
+	ParseBwwReader(r io.Reader) iter.Seq2[*messages.ParsedTune, error]
 }
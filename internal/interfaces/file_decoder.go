@@ -0,0 +1,8 @@
+package interfaces
+
+import "io"
+
+type FileDecoder interface {
+	Decode(data []byte) ([]byte, error)
+	NewReader(r io.Reader) io.Reader
+}
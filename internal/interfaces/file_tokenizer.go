@@ -4,4 +4,5 @@ import "github.com/tomvodi/limepipes-plugin-bww/internal/common"
 
 type FileTokenizer interface {
 	Tokenize(data []byte) ([]*common.Token, error)
+	TokenizeChunk(chunk *common.FileChunk) ([]*common.Token, error)
 }
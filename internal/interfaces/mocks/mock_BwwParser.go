@@ -3,8 +3,15 @@
 package mocks
 
 import (
-	mock "github.com/stretchr/testify/mock"
+	common "github.com/tomvodi/limepipes-plugin-bww/internal/common"
+
+	io "io"
+
+	iter "iter"
+
 	messages "github.com/tomvodi/limepipes-plugin-api/plugin/v1/messages"
+
+	mock "github.com/stretchr/testify/mock"
 )
 
 // BwwParser is an autogenerated mock type for the BwwParser type
@@ -78,6 +85,112 @@ func (_c *BwwParser_ParseBwwData_Call) RunAndReturn(run func([]byte) ([]*message
 	return _c
 }
 
+// ParseBwwFile provides a mock function with given fields: data
+func (_m *BwwParser) ParseBwwFile(data []byte) (*common.ParsedFile, error) {
+	ret := _m.Called(data)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ParseBwwFile")
+	}
+
+	var r0 *common.ParsedFile
+	var r1 error
+	if rf, ok := ret.Get(0).(func([]byte) (*common.ParsedFile, error)); ok {
+		return rf(data)
+	}
+	if rf, ok := ret.Get(0).(func([]byte) *common.ParsedFile); ok {
+		r0 = rf(data)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*common.ParsedFile)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func([]byte) error); ok {
+		r1 = rf(data)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// BwwParser_ParseBwwFile_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ParseBwwFile'
+type BwwParser_ParseBwwFile_Call struct {
+	*mock.Call
+}
+
+// ParseBwwFile is a helper method to define mock.On call
+//   - data []byte
+func (_e *BwwParser_Expecter) ParseBwwFile(data interface{}) *BwwParser_ParseBwwFile_Call {
+	return &BwwParser_ParseBwwFile_Call{Call: _e.mock.On("ParseBwwFile", data)}
+}
+
+func (_c *BwwParser_ParseBwwFile_Call) Run(run func(data []byte)) *BwwParser_ParseBwwFile_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].([]byte))
+	})
+	return _c
+}
+
+func (_c *BwwParser_ParseBwwFile_Call) Return(_a0 *common.ParsedFile, _a1 error) *BwwParser_ParseBwwFile_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *BwwParser_ParseBwwFile_Call) RunAndReturn(run func([]byte) (*common.ParsedFile, error)) *BwwParser_ParseBwwFile_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ParseBwwReader provides a mock function with given fields: r
+func (_m *BwwParser) ParseBwwReader(r io.Reader) iter.Seq2[*messages.ParsedTune, error] {
+	ret := _m.Called(r)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ParseBwwReader")
+	}
+
+	var r0 iter.Seq2[*messages.ParsedTune, error]
+	if rf, ok := ret.Get(0).(func(io.Reader) iter.Seq2[*messages.ParsedTune, error]); ok {
+		r0 = rf(r)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(iter.Seq2[*messages.ParsedTune, error])
+		}
+	}
+
+	return r0
+}
+
+// BwwParser_ParseBwwReader_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ParseBwwReader'
+type BwwParser_ParseBwwReader_Call struct {
+	*mock.Call
+}
+
+// ParseBwwReader is a helper method to define mock.On call
+//   - r io.Reader
+func (_e *BwwParser_Expecter) ParseBwwReader(r interface{}) *BwwParser_ParseBwwReader_Call {
+	return &BwwParser_ParseBwwReader_Call{Call: _e.mock.On("ParseBwwReader", r)}
+}
+
+func (_c *BwwParser_ParseBwwReader_Call) Run(run func(r io.Reader)) *BwwParser_ParseBwwReader_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(io.Reader))
+	})
+	return _c
+}
+
+func (_c *BwwParser_ParseBwwReader_Call) Return(_a0 iter.Seq2[*messages.ParsedTune, error]) *BwwParser_ParseBwwReader_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *BwwParser_ParseBwwReader_Call) RunAndReturn(run func(io.Reader) iter.Seq2[*messages.ParsedTune, error]) *BwwParser_ParseBwwReader_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // NewBwwParser creates a new instance of BwwParser. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewBwwParser(t interface {
@@ -79,6 +79,64 @@ func (_c *FileTokenizer_Tokenize_Call) RunAndReturn(run func([]byte) ([]*common.
 	return _c
 }
 
+// TokenizeChunk provides a mock function with given fields: chunk
+func (_m *FileTokenizer) TokenizeChunk(chunk *common.FileChunk) ([]*common.Token, error) {
+	ret := _m.Called(chunk)
+
+	if len(ret) == 0 {
+		panic("no return value specified for TokenizeChunk")
+	}
+
+	var r0 []*common.Token
+	var r1 error
+	if rf, ok := ret.Get(0).(func(*common.FileChunk) ([]*common.Token, error)); ok {
+		return rf(chunk)
+	}
+	if rf, ok := ret.Get(0).(func(*common.FileChunk) []*common.Token); ok {
+		r0 = rf(chunk)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*common.Token)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(*common.FileChunk) error); ok {
+		r1 = rf(chunk)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FileTokenizer_TokenizeChunk_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'TokenizeChunk'
+type FileTokenizer_TokenizeChunk_Call struct {
+	*mock.Call
+}
+
+// TokenizeChunk is a helper method to define mock.On call
+//   - chunk *common.FileChunk
+func (_e *FileTokenizer_Expecter) TokenizeChunk(chunk interface{}) *FileTokenizer_TokenizeChunk_Call {
+	return &FileTokenizer_TokenizeChunk_Call{Call: _e.mock.On("TokenizeChunk", chunk)}
+}
+
+func (_c *FileTokenizer_TokenizeChunk_Call) Run(run func(chunk *common.FileChunk)) *FileTokenizer_TokenizeChunk_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(*common.FileChunk))
+	})
+	return _c
+}
+
+func (_c *FileTokenizer_TokenizeChunk_Call) Return(_a0 []*common.Token, _a1 error) *FileTokenizer_TokenizeChunk_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *FileTokenizer_TokenizeChunk_Call) RunAndReturn(run func(*common.FileChunk) ([]*common.Token, error)) *FileTokenizer_TokenizeChunk_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // NewFileTokenizer creates a new instance of FileTokenizer. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewFileTokenizer(t interface {
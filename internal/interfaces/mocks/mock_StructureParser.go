@@ -5,6 +5,10 @@ package mocks
 import (
 	filestructure "github.com/tomvodi/limepipes-plugin-bww/internal/filestructure"
 
+	io "io"
+
+	iter "iter"
+
 	mock "github.com/stretchr/testify/mock"
 )
 
@@ -79,6 +83,54 @@ func (_c *StructureParser_ParseDocumentStructure_Call) RunAndReturn(run func([]b
 	return _c
 }
 
+// ParseTunes provides a mock function with given fields: r
+func (_m *StructureParser) ParseTunes(r io.Reader) iter.Seq2[*filestructure.TuneDefinition, error] {
+	ret := _m.Called(r)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ParseTunes")
+	}
+
+	var r0 iter.Seq2[*filestructure.TuneDefinition, error]
+	if rf, ok := ret.Get(0).(func(io.Reader) iter.Seq2[*filestructure.TuneDefinition, error]); ok {
+		r0 = rf(r)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(iter.Seq2[*filestructure.TuneDefinition, error])
+		}
+	}
+
+	return r0
+}
+
+// StructureParser_ParseTunes_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ParseTunes'
+type StructureParser_ParseTunes_Call struct {
+	*mock.Call
+}
+
+// ParseTunes is a helper method to define mock.On call
+//   - r io.Reader
+func (_e *StructureParser_Expecter) ParseTunes(r interface{}) *StructureParser_ParseTunes_Call {
+	return &StructureParser_ParseTunes_Call{Call: _e.mock.On("ParseTunes", r)}
+}
+
+func (_c *StructureParser_ParseTunes_Call) Run(run func(r io.Reader)) *StructureParser_ParseTunes_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(io.Reader))
+	})
+	return _c
+}
+
+func (_c *StructureParser_ParseTunes_Call) Return(_a0 iter.Seq2[*filestructure.TuneDefinition, error]) *StructureParser_ParseTunes_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *StructureParser_ParseTunes_Call) RunAndReturn(run func(io.Reader) iter.Seq2[*filestructure.TuneDefinition, error]) *StructureParser_ParseTunes_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // NewStructureParser creates a new instance of StructureParser. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewStructureParser(t interface {
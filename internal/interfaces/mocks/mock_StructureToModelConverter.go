@@ -3,6 +3,7 @@
 package mocks
 
 import (
+	common "github.com/tomvodi/limepipes-plugin-bww/internal/common"
 	filestructure "github.com/tomvodi/limepipes-plugin-bww/internal/filestructure"
 
 	mock "github.com/stretchr/testify/mock"
@@ -24,7 +25,7 @@ func (_m *StructureToModelConverter) EXPECT() *StructureToModelConverter_Expecte
 }
 
 // Convert provides a mock function with given fields: t
-func (_m *StructureToModelConverter) Convert(t *filestructure.Tune) (*tune.Tune, error) {
+func (_m *StructureToModelConverter) Convert(t *filestructure.Tune) (*tune.Tune, *common.TuneSource, error) {
 	ret := _m.Called(t)
 
 	if len(ret) == 0 {
@@ -32,8 +33,9 @@ func (_m *StructureToModelConverter) Convert(t *filestructure.Tune) (*tune.Tune,
 	}
 
 	var r0 *tune.Tune
-	var r1 error
-	if rf, ok := ret.Get(0).(func(*filestructure.Tune) (*tune.Tune, error)); ok {
+	var r1 *common.TuneSource
+	var r2 error
+	if rf, ok := ret.Get(0).(func(*filestructure.Tune) (*tune.Tune, *common.TuneSource, error)); ok {
 		return rf(t)
 	}
 	if rf, ok := ret.Get(0).(func(*filestructure.Tune) *tune.Tune); ok {
@@ -44,13 +46,21 @@ func (_m *StructureToModelConverter) Convert(t *filestructure.Tune) (*tune.Tune,
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(*filestructure.Tune) error); ok {
+	if rf, ok := ret.Get(1).(func(*filestructure.Tune) *common.TuneSource); ok {
 		r1 = rf(t)
 	} else {
-		r1 = ret.Error(1)
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*common.TuneSource)
+		}
+	}
+
+	if rf, ok := ret.Get(2).(func(*filestructure.Tune) error); ok {
+		r2 = rf(t)
+	} else {
+		r2 = ret.Error(2)
 	}
 
-	return r0, r1
+	return r0, r1, r2
 }
 
 // StructureToModelConverter_Convert_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Convert'
@@ -71,12 +81,12 @@ func (_c *StructureToModelConverter_Convert_Call) Run(run func(t *filestructure.
 	return _c
 }
 
-func (_c *StructureToModelConverter_Convert_Call) Return(_a0 *tune.Tune, _a1 error) *StructureToModelConverter_Convert_Call {
-	_c.Call.Return(_a0, _a1)
+func (_c *StructureToModelConverter_Convert_Call) Return(_a0 *tune.Tune, _a1 *common.TuneSource, _a2 error) *StructureToModelConverter_Convert_Call {
+	_c.Call.Return(_a0, _a1, _a2)
 	return _c
 }
 
-func (_c *StructureToModelConverter_Convert_Call) RunAndReturn(run func(*filestructure.Tune) (*tune.Tune, error)) *StructureToModelConverter_Convert_Call {
+func (_c *StructureToModelConverter_Convert_Call) RunAndReturn(run func(*filestructure.Tune) (*tune.Tune, *common.TuneSource, error)) *StructureToModelConverter_Convert_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -4,6 +4,7 @@ package mocks
 
 import (
 	barline "github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/barline"
+	common "github.com/tomvodi/limepipes-plugin-bww/internal/common"
 
 	measure "github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/measure"
 
@@ -83,6 +84,52 @@ func (_c *SymbolMapper_BarlineForToken_Call) RunAndReturn(run func(string) (*bar
 	return _c
 }
 
+// IsOldTie provides a mock function with given fields: token
+func (_m *SymbolMapper) IsOldTie(token string) bool {
+	ret := _m.Called(token)
+
+	if len(ret) == 0 {
+		panic("no return value specified for IsOldTie")
+	}
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(string) bool); ok {
+		r0 = rf(token)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
+// SymbolMapper_IsOldTie_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'IsOldTie'
+type SymbolMapper_IsOldTie_Call struct {
+	*mock.Call
+}
+
+// IsOldTie is a helper method to define mock.On call
+//   - token string
+func (_e *SymbolMapper_Expecter) IsOldTie(token interface{}) *SymbolMapper_IsOldTie_Call {
+	return &SymbolMapper_IsOldTie_Call{Call: _e.mock.On("IsOldTie", token)}
+}
+
+func (_c *SymbolMapper_IsOldTie_Call) Run(run func(token string)) *SymbolMapper_IsOldTie_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *SymbolMapper_IsOldTie_Call) Return(_a0 bool) *SymbolMapper_IsOldTie_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *SymbolMapper_IsOldTie_Call) RunAndReturn(run func(string) bool) *SymbolMapper_IsOldTie_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // IsTimeSignature provides a mock function with given fields: token
 func (_m *SymbolMapper) IsTimeSignature(token string) bool {
 	ret := _m.Called(token)
@@ -129,6 +176,108 @@ func (_c *SymbolMapper_IsTimeSignature_Call) RunAndReturn(run func(string) bool)
 	return _c
 }
 
+// NavigationForToken provides a mock function with given fields: token
+func (_m *SymbolMapper) NavigationForToken(token string) (barline.Time, error) {
+	ret := _m.Called(token)
+
+	if len(ret) == 0 {
+		panic("no return value specified for NavigationForToken")
+	}
+
+	var r0 barline.Time
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (barline.Time, error)); ok {
+		return rf(token)
+	}
+	if rf, ok := ret.Get(0).(func(string) barline.Time); ok {
+		r0 = rf(token)
+	} else {
+		r0 = ret.Get(0).(barline.Time)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(token)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SymbolMapper_NavigationForToken_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'NavigationForToken'
+type SymbolMapper_NavigationForToken_Call struct {
+	*mock.Call
+}
+
+// NavigationForToken is a helper method to define mock.On call
+//   - token string
+func (_e *SymbolMapper_Expecter) NavigationForToken(token interface{}) *SymbolMapper_NavigationForToken_Call {
+	return &SymbolMapper_NavigationForToken_Call{Call: _e.mock.On("NavigationForToken", token)}
+}
+
+func (_c *SymbolMapper_NavigationForToken_Call) Run(run func(token string)) *SymbolMapper_NavigationForToken_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *SymbolMapper_NavigationForToken_Call) Return(_a0 barline.Time, _a1 error) *SymbolMapper_NavigationForToken_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *SymbolMapper_NavigationForToken_Call) RunAndReturn(run func(string) (barline.Time, error)) *SymbolMapper_NavigationForToken_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NoteFlagForToken provides a mock function with given fields: token
+func (_m *SymbolMapper) NoteFlagForToken(token string) common.NoteFlag {
+	ret := _m.Called(token)
+
+	if len(ret) == 0 {
+		panic("no return value specified for NoteFlagForToken")
+	}
+
+	var r0 common.NoteFlag
+	if rf, ok := ret.Get(0).(func(string) common.NoteFlag); ok {
+		r0 = rf(token)
+	} else {
+		r0 = ret.Get(0).(common.NoteFlag)
+	}
+
+	return r0
+}
+
+// SymbolMapper_NoteFlagForToken_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'NoteFlagForToken'
+type SymbolMapper_NoteFlagForToken_Call struct {
+	*mock.Call
+}
+
+// NoteFlagForToken is a helper method to define mock.On call
+//   - token string
+func (_e *SymbolMapper_Expecter) NoteFlagForToken(token interface{}) *SymbolMapper_NoteFlagForToken_Call {
+	return &SymbolMapper_NoteFlagForToken_Call{Call: _e.mock.On("NoteFlagForToken", token)}
+}
+
+func (_c *SymbolMapper_NoteFlagForToken_Call) Run(run func(token string)) *SymbolMapper_NoteFlagForToken_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *SymbolMapper_NoteFlagForToken_Call) Return(_a0 common.NoteFlag) *SymbolMapper_NoteFlagForToken_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *SymbolMapper_NoteFlagForToken_Call) RunAndReturn(run func(string) common.NoteFlag) *SymbolMapper_NoteFlagForToken_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // SymbolForToken provides a mock function with given fields: token
 func (_m *SymbolMapper) SymbolForToken(token string) (*symbols.Symbol, error) {
 	ret := _m.Called(token)
@@ -245,6 +394,234 @@ func (_c *SymbolMapper_TimeSigForToken_Call) RunAndReturn(run func(string) (*mea
 	return _c
 }
 
+// TokenForBarline provides a mock function with given fields: bl, pos
+func (_m *SymbolMapper) TokenForBarline(bl *barline.Barline, pos common.BarlinePosition) (string, error) {
+	ret := _m.Called(bl, pos)
+
+	if len(ret) == 0 {
+		panic("no return value specified for TokenForBarline")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(*barline.Barline, common.BarlinePosition) (string, error)); ok {
+		return rf(bl, pos)
+	}
+	if rf, ok := ret.Get(0).(func(*barline.Barline, common.BarlinePosition) string); ok {
+		r0 = rf(bl, pos)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(*barline.Barline, common.BarlinePosition) error); ok {
+		r1 = rf(bl, pos)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SymbolMapper_TokenForBarline_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'TokenForBarline'
+type SymbolMapper_TokenForBarline_Call struct {
+	*mock.Call
+}
+
+// TokenForBarline is a helper method to define mock.On call
+//   - bl *barline.Barline
+//   - pos common.BarlinePosition
+func (_e *SymbolMapper_Expecter) TokenForBarline(bl interface{}, pos interface{}) *SymbolMapper_TokenForBarline_Call {
+	return &SymbolMapper_TokenForBarline_Call{Call: _e.mock.On("TokenForBarline", bl, pos)}
+}
+
+func (_c *SymbolMapper_TokenForBarline_Call) Run(run func(bl *barline.Barline, pos common.BarlinePosition)) *SymbolMapper_TokenForBarline_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(*barline.Barline), args[1].(common.BarlinePosition))
+	})
+	return _c
+}
+
+func (_c *SymbolMapper_TokenForBarline_Call) Return(_a0 string, _a1 error) *SymbolMapper_TokenForBarline_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *SymbolMapper_TokenForBarline_Call) RunAndReturn(run func(*barline.Barline, common.BarlinePosition) (string, error)) *SymbolMapper_TokenForBarline_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// TokenForNavigation provides a mock function with given fields: t
+func (_m *SymbolMapper) TokenForNavigation(t barline.Time) (string, error) {
+	ret := _m.Called(t)
+
+	if len(ret) == 0 {
+		panic("no return value specified for TokenForNavigation")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(barline.Time) (string, error)); ok {
+		return rf(t)
+	}
+	if rf, ok := ret.Get(0).(func(barline.Time) string); ok {
+		r0 = rf(t)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(barline.Time) error); ok {
+		r1 = rf(t)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SymbolMapper_TokenForNavigation_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'TokenForNavigation'
+type SymbolMapper_TokenForNavigation_Call struct {
+	*mock.Call
+}
+
+// TokenForNavigation is a helper method to define mock.On call
+//   - t barline.Time
+func (_e *SymbolMapper_Expecter) TokenForNavigation(t interface{}) *SymbolMapper_TokenForNavigation_Call {
+	return &SymbolMapper_TokenForNavigation_Call{Call: _e.mock.On("TokenForNavigation", t)}
+}
+
+func (_c *SymbolMapper_TokenForNavigation_Call) Run(run func(t barline.Time)) *SymbolMapper_TokenForNavigation_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(barline.Time))
+	})
+	return _c
+}
+
+func (_c *SymbolMapper_TokenForNavigation_Call) Return(_a0 string, _a1 error) *SymbolMapper_TokenForNavigation_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *SymbolMapper_TokenForNavigation_Call) RunAndReturn(run func(barline.Time) (string, error)) *SymbolMapper_TokenForNavigation_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// TokenForTimeSignature provides a mock function with given fields: ts
+func (_m *SymbolMapper) TokenForTimeSignature(ts *measure.TimeSignature) (string, error) {
+	ret := _m.Called(ts)
+
+	if len(ret) == 0 {
+		panic("no return value specified for TokenForTimeSignature")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(*measure.TimeSignature) (string, error)); ok {
+		return rf(ts)
+	}
+	if rf, ok := ret.Get(0).(func(*measure.TimeSignature) string); ok {
+		r0 = rf(ts)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(*measure.TimeSignature) error); ok {
+		r1 = rf(ts)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SymbolMapper_TokenForTimeSignature_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'TokenForTimeSignature'
+type SymbolMapper_TokenForTimeSignature_Call struct {
+	*mock.Call
+}
+
+// TokenForTimeSignature is a helper method to define mock.On call
+//   - ts *measure.TimeSignature
+func (_e *SymbolMapper_Expecter) TokenForTimeSignature(ts interface{}) *SymbolMapper_TokenForTimeSignature_Call {
+	return &SymbolMapper_TokenForTimeSignature_Call{Call: _e.mock.On("TokenForTimeSignature", ts)}
+}
+
+func (_c *SymbolMapper_TokenForTimeSignature_Call) Run(run func(ts *measure.TimeSignature)) *SymbolMapper_TokenForTimeSignature_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(*measure.TimeSignature))
+	})
+	return _c
+}
+
+func (_c *SymbolMapper_TokenForTimeSignature_Call) Return(_a0 string, _a1 error) *SymbolMapper_TokenForTimeSignature_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *SymbolMapper_TokenForTimeSignature_Call) RunAndReturn(run func(*measure.TimeSignature) (string, error)) *SymbolMapper_TokenForTimeSignature_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// TokensForSymbol provides a mock function with given fields: sym, flag
+func (_m *SymbolMapper) TokensForSymbol(sym *symbols.Symbol, flag common.NoteFlag) ([]string, error) {
+	ret := _m.Called(sym, flag)
+
+	if len(ret) == 0 {
+		panic("no return value specified for TokensForSymbol")
+	}
+
+	var r0 []string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(*symbols.Symbol, common.NoteFlag) ([]string, error)); ok {
+		return rf(sym, flag)
+	}
+	if rf, ok := ret.Get(0).(func(*symbols.Symbol, common.NoteFlag) []string); ok {
+		r0 = rf(sym, flag)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(*symbols.Symbol, common.NoteFlag) error); ok {
+		r1 = rf(sym, flag)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SymbolMapper_TokensForSymbol_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'TokensForSymbol'
+type SymbolMapper_TokensForSymbol_Call struct {
+	*mock.Call
+}
+
+// TokensForSymbol is a helper method to define mock.On call
+//   - sym *symbols.Symbol
+//   - flag common.NoteFlag
+func (_e *SymbolMapper_Expecter) TokensForSymbol(sym interface{}, flag interface{}) *SymbolMapper_TokensForSymbol_Call {
+	return &SymbolMapper_TokensForSymbol_Call{Call: _e.mock.On("TokensForSymbol", sym, flag)}
+}
+
+func (_c *SymbolMapper_TokensForSymbol_Call) Run(run func(sym *symbols.Symbol, flag common.NoteFlag)) *SymbolMapper_TokensForSymbol_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(*symbols.Symbol), args[1].(common.NoteFlag))
+	})
+	return _c
+}
+
+func (_c *SymbolMapper_TokensForSymbol_Call) Return(_a0 []string, _a1 error) *SymbolMapper_TokensForSymbol_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *SymbolMapper_TokensForSymbol_Call) RunAndReturn(run func(*symbols.Symbol, common.NoteFlag) ([]string, error)) *SymbolMapper_TokensForSymbol_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // NewSymbolMapper creates a new instance of SymbolMapper. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewSymbolMapper(t interface {
@@ -22,9 +22,9 @@ func (_m *TokenStructureConverter) EXPECT() *TokenStructureConverter_Expecter {
 	return &TokenStructureConverter_Expecter{mock: &_m.Mock}
 }
 
-// Convert provides a mock function with given fields: tokens
-func (_m *TokenStructureConverter) Convert(tokens []*common.Token) (*filestructure.BwwFile, error) {
-	ret := _m.Called(tokens)
+// Convert provides a mock function with given fields: data, tokens
+func (_m *TokenStructureConverter) Convert(data []byte, tokens []*common.Token) (*filestructure.BwwFile, error) {
+	ret := _m.Called(data, tokens)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Convert")
@@ -32,19 +32,19 @@ func (_m *TokenStructureConverter) Convert(tokens []*common.Token) (*filestructu
 
 	var r0 *filestructure.BwwFile
 	var r1 error
-	if rf, ok := ret.Get(0).(func([]*common.Token) (*filestructure.BwwFile, error)); ok {
-		return rf(tokens)
+	if rf, ok := ret.Get(0).(func([]byte, []*common.Token) (*filestructure.BwwFile, error)); ok {
+		return rf(data, tokens)
 	}
-	if rf, ok := ret.Get(0).(func([]*common.Token) *filestructure.BwwFile); ok {
-		r0 = rf(tokens)
+	if rf, ok := ret.Get(0).(func([]byte, []*common.Token) *filestructure.BwwFile); ok {
+		r0 = rf(data, tokens)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*filestructure.BwwFile)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func([]*common.Token) error); ok {
-		r1 = rf(tokens)
+	if rf, ok := ret.Get(1).(func([]byte, []*common.Token) error); ok {
+		r1 = rf(data, tokens)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -58,14 +58,15 @@ type TokenStructureConverter_Convert_Call struct {
 }
 
 // Convert is a helper method to define mock.On call
+//   - data []byte
 //   - tokens []*common.Token
-func (_e *TokenStructureConverter_Expecter) Convert(tokens interface{}) *TokenStructureConverter_Convert_Call {
-	return &TokenStructureConverter_Convert_Call{Call: _e.mock.On("Convert", tokens)}
+func (_e *TokenStructureConverter_Expecter) Convert(data interface{}, tokens interface{}) *TokenStructureConverter_Convert_Call {
+	return &TokenStructureConverter_Convert_Call{Call: _e.mock.On("Convert", data, tokens)}
 }
 
-func (_c *TokenStructureConverter_Convert_Call) Run(run func(tokens []*common.Token)) *TokenStructureConverter_Convert_Call {
+func (_c *TokenStructureConverter_Convert_Call) Run(run func(data []byte, tokens []*common.Token)) *TokenStructureConverter_Convert_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].([]*common.Token))
+		run(args[0].([]byte), args[1].([]*common.Token))
 	})
 	return _c
 }
@@ -75,7 +76,66 @@ func (_c *TokenStructureConverter_Convert_Call) Return(_a0 *filestructure.BwwFil
 	return _c
 }
 
-func (_c *TokenStructureConverter_Convert_Call) RunAndReturn(run func([]*common.Token) (*filestructure.BwwFile, error)) *TokenStructureConverter_Convert_Call {
+func (_c *TokenStructureConverter_Convert_Call) RunAndReturn(run func([]byte, []*common.Token) (*filestructure.BwwFile, error)) *TokenStructureConverter_Convert_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ConvertChunk provides a mock function with given fields: chunk, tokens
+func (_m *TokenStructureConverter) ConvertChunk(chunk *common.FileChunk, tokens []*common.Token) (*filestructure.BwwFile, error) {
+	ret := _m.Called(chunk, tokens)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ConvertChunk")
+	}
+
+	var r0 *filestructure.BwwFile
+	var r1 error
+	if rf, ok := ret.Get(0).(func(*common.FileChunk, []*common.Token) (*filestructure.BwwFile, error)); ok {
+		return rf(chunk, tokens)
+	}
+	if rf, ok := ret.Get(0).(func(*common.FileChunk, []*common.Token) *filestructure.BwwFile); ok {
+		r0 = rf(chunk, tokens)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*filestructure.BwwFile)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(*common.FileChunk, []*common.Token) error); ok {
+		r1 = rf(chunk, tokens)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// TokenStructureConverter_ConvertChunk_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ConvertChunk'
+type TokenStructureConverter_ConvertChunk_Call struct {
+	*mock.Call
+}
+
+// ConvertChunk is a helper method to define mock.On call
+//   - chunk *common.FileChunk
+//   - tokens []*common.Token
+func (_e *TokenStructureConverter_Expecter) ConvertChunk(chunk interface{}, tokens interface{}) *TokenStructureConverter_ConvertChunk_Call {
+	return &TokenStructureConverter_ConvertChunk_Call{Call: _e.mock.On("ConvertChunk", chunk, tokens)}
+}
+
+func (_c *TokenStructureConverter_ConvertChunk_Call) Run(run func(chunk *common.FileChunk, tokens []*common.Token)) *TokenStructureConverter_ConvertChunk_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(*common.FileChunk), args[1].([]*common.Token))
+	})
+	return _c
+}
+
+func (_c *TokenStructureConverter_ConvertChunk_Call) Return(_a0 *filestructure.BwwFile, _a1 error) *TokenStructureConverter_ConvertChunk_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *TokenStructureConverter_ConvertChunk_Call) RunAndReturn(run func(*common.FileChunk, []*common.Token) (*filestructure.BwwFile, error)) *TokenStructureConverter_ConvertChunk_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -2,8 +2,11 @@ package interfaces
 
 import (
 	"github.com/tomvodi/limepipes-plugin-bww/internal/filestructure"
+	"io"
+	"iter"
 )
 
 type StructureParser interface {
 	ParseDocumentStructure(data []byte) (*filestructure.BwwFile, error)
+	ParseTunes(r io.Reader) iter.Seq2[*filestructure.TuneDefinition, error]
 }
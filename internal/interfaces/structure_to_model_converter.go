@@ -2,9 +2,13 @@ package interfaces
 
 import (
 	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/tune"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/common"
 	"github.com/tomvodi/limepipes-plugin-bww/internal/filestructure"
 )
 
 type StructureToModelConverter interface {
-	Convert(t *filestructure.Tune) (*tune.Tune, error)
+	// Convert converts the tune of the file structure into a music model tune.
+	// The returned source holds the spans in the file of the measures and
+	// symbols of the converted tune.
+	Convert(t *filestructure.Tune) (*tune.Tune, *common.TuneSource, error)
 }
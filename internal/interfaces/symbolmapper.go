@@ -4,11 +4,19 @@ import (
 	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/barline"
 	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/measure"
 	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/symbols"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/common"
 )
 
 type SymbolMapper interface {
 	IsTimeSignature(token string) bool
 	TimeSigForToken(token string) (*measure.TimeSignature, error)
 	BarlineForToken(token string) (*barline.Barline, error)
+	NavigationForToken(token string) (barline.Time, error)
+	IsOldTie(token string) bool
 	SymbolForToken(token string) (*symbols.Symbol, error)
+	NoteFlagForToken(token string) common.NoteFlag
+	TokensForSymbol(sym *symbols.Symbol, flag common.NoteFlag) ([]string, error)
+	TokenForBarline(bl *barline.Barline, pos common.BarlinePosition) (string, error)
+	TokenForNavigation(t barline.Time) (string, error)
+	TokenForTimeSignature(ts *measure.TimeSignature) (string, error)
 }
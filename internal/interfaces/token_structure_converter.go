@@ -6,5 +6,6 @@ import (
 )
 
 type TokenStructureConverter interface {
-	Convert(tokens []*common.Token) (*filestructure.BwwFile, error)
+	Convert(data []byte, tokens []*common.Token) (*filestructure.BwwFile, error)
+	ConvertChunk(chunk *common.FileChunk, tokens []*common.Token) (*filestructure.BwwFile, error)
 }
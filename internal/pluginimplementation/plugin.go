@@ -10,58 +10,94 @@ import (
 	"github.com/tomvodi/limepipes-plugin-bww/internal/interfaces"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"io"
+	"iter"
 )
 
 type Plugin struct {
 	afs       afero.Fs
 	parser    interfaces.BwwParser
 	tuneFixer interfaces.TuneFixer
+	exporter  interfaces.BwwExporter
 }
 
 func (p *Plugin) PluginInfo() (*messages.PluginInfoResponse, error) {
 	return &messages.PluginInfoResponse{
 		Name:           "BWW Plugin",
-		Description:    "Import Bagpipe Music Writer and Bagpipe Player files.",
+		Description:    "Import and export Bagpipe Music Writer and Bagpipe Player files.",
 		FileFormat:     fileformat.Format_BWW,
-		Type:           messages.PluginType_IN,
+		Type:           messages.PluginType_INOUT,
 		FileExtensions: []string{".bww", ".bmw"},
 	}, nil
 }
 
+// ExportToFile writes the tunes into a bww file, see Export.
 func (p *Plugin) ExportToFile(
-	[]*tune.Tune,
-	string,
-) error {
-	return status.Error(codes.Unimplemented, "ExportToFile not implemented")
+	tunes []*tune.Tune,
+	filePath string,
+) (err error) {
+	defer recoverInternal(&err, "exporting tunes to file %s", filePath)
+
+	if filePath == "" {
+		return fmt.Errorf("no file path given for export")
+	}
+
+	data, err := p.Export(tunes)
+	if err != nil {
+		return err
+	}
+
+	err = afero.WriteFile(p.afs, filePath, data, 0664)
+	if err != nil {
+		return fmt.Errorf("failed writing export file %s: %w", filePath, err)
+	}
+	log.Info().Msgf("exported %d tunes to file %s", len(tunes), filePath)
+
+	return nil
 }
 
+// Export writes the tunes into the data of a bww file. As the plugin API only
+// passes the music model tunes, the export is lossy: everything that is kept in
+// the sources of parsed tunes, like the staves, key signatures, beam flags and
+// text styles, and the meta data of the imported file is lost. The measures are
+// written into staves of four measures with the default text styles and meta data.
 func (p *Plugin) Export(
-	[]*tune.Tune,
-) ([]byte, error) {
-	return nil, status.Error(codes.Unimplemented, "Export not implemented")
+	tunes []*tune.Tune,
+) (data []byte, err error) {
+	defer recoverInternal(&err, "exporting %d tunes", len(tunes))
+
+	data, err = p.exporter.ExportBwwData(tunes)
+	if err != nil {
+		return nil, fmt.Errorf("failed exporting tunes: %w", err)
+	}
+
+	return data, nil
 }
 
 func (p *Plugin) ParseFromFile(
 	filePath string,
-) ([]*messages.ParsedTune, error) {
+) (tunes []*messages.ParsedTune, err error) {
+	defer recoverInternal(&err, "parsing file %s", filePath)
+
 	stat, err := p.afs.Stat(filePath)
 	if err != nil {
-		return nil, err
+		return nil, fileStatusError(err, filePath)
 	}
 
 	if stat.IsDir() {
-		return nil, fmt.Errorf("file %s is a directory", filePath)
+		return nil, status.Errorf(codes.FailedPrecondition, "file %s is a directory", filePath)
 	}
 
 	fileData, err := afero.ReadFile(p.afs, filePath)
 	if err != nil {
-		return nil, err
+		return nil, fileStatusError(err, filePath)
 	}
 	log.Info().Msgf("importing file %s", filePath)
 
 	msg, err := p.parseTunesFromData(fileData)
 	if err != nil {
-		return nil, fmt.Errorf("failed importing tune file %s: %v", filePath, err)
+		return nil, parseStatusError(err, "file_path",
+			fmt.Sprintf("failed importing tune file %s", filePath))
 	}
 
 	return msg, nil
@@ -69,14 +105,43 @@ func (p *Plugin) ParseFromFile(
 
 func (p *Plugin) Parse(
 	data []byte,
-) ([]*messages.ParsedTune, error) {
-	return p.parseTunesFromData(data)
+) (tunes []*messages.ParsedTune, err error) {
+	defer recoverInternal(&err, "parsing %d bytes of data", len(data))
+
+	msg, err := p.parseTunesFromData(data)
+	if err != nil {
+		return nil, parseStatusError(err, "data", "failed parsing tune data")
+	}
+
+	return msg, nil
+}
+
+// ParseReader reads a bww file from r and yields its tunes one at a time, so that
+// big collections can be parsed without keeping all tunes in memory. Every tune is
+// fixed with the tune fixer like the tunes of Parse, so both return the same tunes.
+// The problems of a tune are yielded as gRPC status and parsing continues with the next tune.
+func (p *Plugin) ParseReader(
+	r io.Reader,
+) iter.Seq2[*messages.ParsedTune, error] {
+	return func(yield func(*messages.ParsedTune, error) bool) {
+		for pt, err := range p.parser.ParseBwwReader(r) {
+			if err != nil {
+				err = parseStatusError(err, "data", "failed parsing tune data")
+			} else {
+				p.tuneFixer.Fix([]*messages.ParsedTune{pt})
+			}
+
+			if !yield(pt, err) {
+				return
+			}
+		}
+	}
 }
 
 func (p *Plugin) parseTunesFromData(tunesData []byte) ([]*messages.ParsedTune, error) {
 	parsedTunes, err := p.parser.ParseBwwData(tunesData)
 	if err != nil {
-		return nil, fmt.Errorf("failed parsing t data: %v", err)
+		return nil, err
 	}
 
 	p.tuneFixer.Fix(parsedTunes)
@@ -84,14 +149,21 @@ func (p *Plugin) parseTunesFromData(tunesData []byte) ([]*messages.ParsedTune, e
 	return parsedTunes, nil
 }
 
+// revive:disable:argument-limit the plugin needs the file system and
+// all the parts for importing and exporting
+
 func NewPluginImplementation(
 	afs afero.Fs,
 	parser interfaces.BwwParser,
 	tuneFixer interfaces.TuneFixer,
+	exporter interfaces.BwwExporter,
 ) *Plugin {
 	return &Plugin{
 		afs:       afs,
 		parser:    parser,
 		tuneFixer: tuneFixer,
+		exporter:  exporter,
 	}
 }
+
+// revive:enable:argument-limit
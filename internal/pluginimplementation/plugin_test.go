@@ -1,6 +1,7 @@
 package pluginimplementation
 
 import (
+	"bytes"
 	"fmt"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -11,9 +12,35 @@ import (
 	"github.com/tomvodi/limepipes-plugin-api/musicmodel/v1/tune"
 	"github.com/tomvodi/limepipes-plugin-api/plugin/v1/fileformat"
 	"github.com/tomvodi/limepipes-plugin-api/plugin/v1/messages"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/bww"
+	bwwparser "github.com/tomvodi/limepipes-plugin-bww/internal/bww/parser"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/bww/symbolmapper"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/bww/symbolmerger"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/bwwfile"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/common"
+	commonhelper "github.com/tomvodi/limepipes-plugin-bww/internal/common/helper"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/diagnostics"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/interfaces"
 	"github.com/tomvodi/limepipes-plugin-bww/internal/interfaces/mocks"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/testing/protocmp"
 )
 
+// newParser returns the parser with all its parts like the plugin uses it.
+func newParser() interfaces.BwwParser {
+	symmap, err := symbolmapper.New()
+	Expect(err).ShouldNot(HaveOccurred())
+	sp := bwwfile.NewStructureParser(
+		bwwfile.NewTokenizer(common.StrictParsing),
+		bwwfile.NewTokenConverter(),
+		bwwfile.NewDecoder(nil),
+	)
+	conv := bww.NewConverter(symmap, symbolmerger.NewCollectedMerger(), common.StrictParsing)
+	return bwwparser.New(sp, conv)
+}
+
 var _ = Describe("PluginInfo", func() {
 	var lpPlug *Plugin
 	var err error
@@ -31,9 +58,9 @@ var _ = Describe("PluginInfo", func() {
 		Expect(err).ShouldNot(HaveOccurred())
 		Expect(pluginInfo).Should(Equal(&messages.PluginInfoResponse{
 			Name:           "BWW Plugin",
-			Description:    "Import Bagpipe Music Writer and Bagpipe Player files.",
+			Description:    "Import and export Bagpipe Music Writer and Bagpipe Player files.",
 			FileFormat:     fileformat.Format_BWW,
-			Type:           messages.PluginType_IN,
+			Type:           messages.PluginType_INOUT,
 			FileExtensions: []string{".bww", ".bmw"},
 		}))
 	})
@@ -86,8 +113,20 @@ var _ = Describe("Parse", func() {
 				filePath = "test.bww"
 			})
 
-			It("should return an error", func() {
-				Expect(err).Should(HaveOccurred())
+			It("should return a not found status", func() {
+				Expect(status.Code(err)).Should(Equal(codes.NotFound))
+			})
+		})
+
+		When("file is a directory", func() {
+			BeforeEach(func() {
+				filePath = "tunes"
+				err = afs.Mkdir(filePath, 0755)
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+
+			It("should return a failed precondition status", func() {
+				Expect(status.Code(err)).Should(Equal(codes.FailedPrecondition))
 			})
 		})
 
@@ -105,8 +144,8 @@ var _ = Describe("Parse", func() {
 				})
 
 				When("importing a tune data", func() {
-					It("should return an error", func() {
-						Expect(err).Should(HaveOccurred())
+					It("should return an invalid argument status", func() {
+						Expect(status.Code(err)).Should(Equal(codes.InvalidArgument))
 					})
 				})
 			})
@@ -141,12 +180,72 @@ var _ = Describe("Parse", func() {
 			})
 
 			When("importing a tune data", func() {
-				It("should return an error", func() {
-					Expect(err).Should(HaveOccurred())
+				It("should return an invalid argument status", func() {
+					Expect(status.Code(err)).Should(Equal(codes.InvalidArgument))
 				})
 			})
 		})
 
+		Context("parser panics", func() {
+			BeforeEach(func() {
+				parser.EXPECT().ParseBwwData(mock.Anything).
+					Run(func(_ []byte) {
+						panic("unexpected nil symbol")
+					})
+			})
+
+			It("should return an internal status with the context", func() {
+				st := status.Convert(err)
+				Expect(st.Code()).Should(Equal(codes.Internal))
+				Expect(st.Message()).Should(Equal(
+					"unexpected error while parsing 9 bytes of data: unexpected nil symbol"))
+			})
+		})
+
+		Context("parser returns diagnostics", func() {
+			BeforeEach(func() {
+				d := &diagnostics.Diagnostic{
+					Code:        diagnostics.CodeUnknownSymbol,
+					Severity:    measure.Severity_Error,
+					Message:     "symbol LA4 not found",
+					Text:        "LA4",
+					Fix:         "did you mean LA_4 or HA_4?",
+					Suggestions: []string{"LA_4", "HA_4"},
+				}
+				parser.EXPECT().ParseBwwData(mock.Anything).
+					Return(nil, diagnostics.List{d.At(4, 6)})
+			})
+
+			It("should return the problems as status details", func() {
+				st := status.Convert(err)
+				Expect(st.Code()).Should(Equal(codes.InvalidArgument))
+				Expect(st.Details()).Should(HaveLen(2))
+				Expect(st.Details()[0]).Should(BeComparableTo(&errdetails.BadRequest{
+					FieldViolations: []*errdetails.BadRequest_FieldViolation{
+						{
+							Field:       "data",
+							Description: "line 4, column 6: symbol LA4 not found",
+						},
+					},
+				}, protocmp.Transform()))
+				Expect(st.Details()[1]).Should(BeComparableTo(&errdetails.ErrorInfo{
+					Reason: "UNKNOWN_SYMBOL",
+					Domain: "limepipes-plugin-bww",
+					Metadata: map[string]string{
+						"line":        "4",
+						"column":      "6",
+						"end_line":    "4",
+						"end_column":  "9",
+						"token":       "LA4",
+						"message":     "symbol LA4 not found",
+						"severity":    "Error",
+						"fix":         "did you mean LA_4 or HA_4?",
+						"suggestions": "LA_4,HA_4",
+					},
+				}, protocmp.Transform()))
+			})
+		})
+
 		Context("Having a tune returned by the parser", func() {
 			BeforeEach(func() {
 				parser.EXPECT().ParseBwwData(mock.Anything).
@@ -166,35 +265,137 @@ var _ = Describe("Parse", func() {
 	})
 })
 
+var _ = Describe("ParseReader", func() {
+	var lpPlug *Plugin
+	var tuneData []byte
+	var parsedTunes []*messages.ParsedTune
+	var errs []error
+
+	JustBeforeEach(func() {
+		parsedTunes = nil
+		errs = nil
+		for pt, err := range lpPlug.ParseReader(bytes.NewReader(tuneData)) {
+			parsedTunes = append(parsedTunes, pt)
+			errs = append(errs, err)
+		}
+	})
+
+	Context("having a tune and a problem returned by the parser", func() {
+		var testTune *messages.ParsedTune
+
+		BeforeEach(func() {
+			parser := mocks.NewBwwParser(GinkgoT())
+			tuneFixer := mocks.NewTuneFixer(GinkgoT())
+			lpPlug = &Plugin{
+				parser:    parser,
+				tuneFixer: tuneFixer,
+			}
+			tuneData = []byte("tune data")
+			testTune = &messages.ParsedTune{
+				Tune: &tune.Tune{Title: "test tune"},
+			}
+			parser.EXPECT().ParseBwwReader(mock.Anything).
+				Return(func(yield func(*messages.ParsedTune, error) bool) {
+					_ = yield(nil, fmt.Errorf("failed parsing")) &&
+						yield(testTune, nil)
+				})
+			tuneFixer.EXPECT().Fix([]*messages.ParsedTune{testTune})
+		})
+
+		It("should fix the tune and return the problem as invalid argument status", func() {
+			Expect(parsedTunes).Should(Equal([]*messages.ParsedTune{nil, testTune}))
+			Expect(status.Code(errs[0])).Should(Equal(codes.InvalidArgument))
+			Expect(errs[1]).ShouldNot(HaveOccurred())
+		})
+	})
+
+	Context("parsing a file with the parser and the tune fixer", func() {
+		var batchTunes []*messages.ParsedTune
+
+		BeforeEach(func() {
+			lpPlug = NewPluginImplementation(
+				afero.NewMemMapFs(),
+				newParser(),
+				commonhelper.NewTuneFixer(),
+				nil,
+			)
+			tuneData = []byte(`Bagpipe Reader:1.0
+"my_first_tune",(T,L,0,0,Times New Roman,16,700,0,0,18,0,0,0)
+"reel 2/4",(Y,C,0,0,Times New Roman,14,400,0,0,18,0,0,0)
+"trad.",(M,R,0,0,Times New Roman,14,400,0,0,18,0,0,0)
+& sharpf sharpc 2_4 LA_4 B_4 !t
+
+"my_second_tune",(T,L,0,0,Times New Roman,16,700,0,0,18,0,0,0)
+"march:",(Y,C,0,0,Times New Roman,14,400,0,0,18,0,0,0)
+"John Doe arr. Jane Doe",(M,R,0,0,Times New Roman,14,400,0,0,18,0,0,0)
+& sharpf sharpc 2_4 C_4 D_4 !t
+`)
+			var err error
+			batchTunes, err = lpPlug.Parse(tuneData)
+			Expect(err).ShouldNot(HaveOccurred())
+		})
+
+		It("should return the same fixed tunes as Parse", func() {
+			Expect(errs).Should(Equal([]error{nil, nil}))
+			Expect(parsedTunes).Should(BeComparableTo(batchTunes, protocmp.Transform()))
+			Expect(parsedTunes[0].Tune.Title).Should(Equal("My First Tune"))
+			Expect(parsedTunes[0].Tune.Type).Should(Equal("Reel"))
+			Expect(parsedTunes[0].Tune.Composer).Should(Equal("Traditional"))
+			Expect(parsedTunes[1].Tune.Composer).Should(Equal("John Doe"))
+			Expect(parsedTunes[1].Tune.Arranger).Should(Equal("Jane Doe"))
+		})
+	})
+})
+
 var _ = Describe("Export", func() {
 	var err error
 	var lpPlug *Plugin
+	var exporter *mocks.BwwExporter
 	var exportTunes []*tune.Tune
+	var exportedData []byte
 
 	BeforeEach(func() {
-		lpPlug = &Plugin{}
+		exporter = mocks.NewBwwExporter(GinkgoT())
+		lpPlug = &Plugin{
+			exporter: exporter,
+		}
+		exportTunes = []*tune.Tune{
+			{
+				Title: "test tune",
+				Measures: []*measure.Measure{
+					{
+						Comments: []string{"comment"},
+					},
+				},
+			},
+		}
 	})
 
 	JustBeforeEach(func() {
-		_, err = lpPlug.Export(exportTunes)
+		exportedData, err = lpPlug.Export(exportTunes)
 	})
 
-	When("exporting a tune", func() {
+	When("exporter returns an error", func() {
 		BeforeEach(func() {
-			exportTunes = []*tune.Tune{
-				{
-					Title: "test tune",
-					Measures: []*measure.Measure{
-						{
-							Comments: []string{"comment"},
-						},
-					},
-				},
-			}
+			exporter.EXPECT().ExportBwwData(exportTunes).
+				Return(nil, fmt.Errorf("failed exporting"))
 		})
 
 		It("should return an error", func() {
 			Expect(err).Should(HaveOccurred())
+			Expect(exportedData).Should(BeNil())
+		})
+	})
+
+	When("exporter succeeds", func() {
+		BeforeEach(func() {
+			exporter.EXPECT().ExportBwwData(exportTunes).
+				Return([]byte("bww data"), nil)
+		})
+
+		It("should return the exported data", func() {
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(exportedData).Should(Equal([]byte("bww data")))
 		})
 	})
 })
@@ -202,34 +403,70 @@ var _ = Describe("Export", func() {
 var _ = Describe("ExportToFile", func() {
 	var err error
 	var lpPlug *Plugin
+	var exporter *mocks.BwwExporter
+	var afs afero.Fs
 	var exportTunes []*tune.Tune
 	var exportPath string
 
 	BeforeEach(func() {
-		lpPlug = &Plugin{}
+		exporter = mocks.NewBwwExporter(GinkgoT())
+		afs = afero.NewMemMapFs()
+		lpPlug = &Plugin{
+			afs:      afs,
+			exporter: exporter,
+		}
+		exportTunes = []*tune.Tune{
+			{
+				Title: "test tune",
+				Measures: []*measure.Measure{
+					{
+						Comments: []string{"comment"},
+					},
+				},
+			},
+		}
+		exportPath = "test.bww"
 	})
 
 	JustBeforeEach(func() {
 		err = lpPlug.ExportToFile(exportTunes, exportPath)
 	})
 
-	When("exporting a tune", func() {
+	When("no export path is given", func() {
 		BeforeEach(func() {
-			exportTunes = []*tune.Tune{
-				{
-					Title: "test tune",
-					Measures: []*measure.Measure{
-						{
-							Comments: []string{"comment"},
-						},
-					},
-				},
-			}
-			exportPath = "test.bww"
+			exportPath = ""
 		})
 
 		It("should return an error", func() {
 			Expect(err).Should(HaveOccurred())
 		})
 	})
+
+	When("exporter returns an error", func() {
+		BeforeEach(func() {
+			exporter.EXPECT().ExportBwwData(exportTunes).
+				Return(nil, fmt.Errorf("failed exporting"))
+		})
+
+		It("should return an error and not write a file", func() {
+			Expect(err).Should(HaveOccurred())
+			exists, serr := afero.Exists(afs, exportPath)
+			Expect(serr).ShouldNot(HaveOccurred())
+			Expect(exists).Should(BeFalse())
+		})
+	})
+
+	When("exporter succeeds", func() {
+		BeforeEach(func() {
+			exporter.EXPECT().ExportBwwData(exportTunes).
+				Return([]byte("bww data"), nil)
+		})
+
+		It("should write the exported data to the file", func() {
+			Expect(err).ShouldNot(HaveOccurred())
+			data, rerr := afero.ReadFile(afs, exportPath)
+			Expect(rerr).ShouldNot(HaveOccurred())
+			Expect(data).Should(Equal([]byte("bww data")))
+		})
+	})
 })
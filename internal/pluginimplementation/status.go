@@ -0,0 +1,100 @@
+package pluginimplementation
+
+import (
+	"errors"
+	"fmt"
+	"github.com/rs/zerolog/log"
+	"github.com/tomvodi/limepipes-plugin-bww/internal/diagnostics"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+	"io/fs"
+	"runtime/debug"
+	"strconv"
+	"strings"
+)
+
+// errorDomain is the domain of the error infos of the status details.
+const errorDomain = "limepipes-plugin-bww"
+
+// parseStatusError returns the error of a failed parse as gRPC status with code
+// InvalidArgument. The status details contain a field violation of the given
+// request field and an error info with the position, token and message
+// for every problem in the parsed data.
+func parseStatusError(
+	err error,
+	field string,
+	msg string,
+) error {
+	diags := diagnostics.FromError(err)
+	br := &errdetails.BadRequest{}
+	details := []protoadapt.MessageV1{br}
+	for _, d := range diags {
+		br.FieldViolations = append(br.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       field,
+			Description: d.Error(),
+		})
+		details = append(details, errorInfo(d))
+	}
+
+	st := status.New(codes.InvalidArgument, fmt.Sprintf("%s: %v", msg, err))
+	stWithDetails, detErr := st.WithDetails(details...)
+	if detErr != nil {
+		log.Error().Err(detErr).Msg("failed adding details to parse status")
+		return st.Err()
+	}
+
+	return stWithDetails.Err()
+}
+
+// errorInfo returns the error info of a diagnostic. The lines and columns
+// of the meta data are zero based like the ones of the diagnostic.
+func errorInfo(d *diagnostics.Diagnostic) *errdetails.ErrorInfo {
+	return &errdetails.ErrorInfo{
+		Reason: strings.ToUpper(strings.ReplaceAll(string(d.Code), "-", "_")),
+		Domain: errorDomain,
+		Metadata: map[string]string{
+			"line":        strconv.Itoa(d.Start.Line),
+			"column":      strconv.Itoa(d.Start.Column),
+			"end_line":    strconv.Itoa(d.End.Line),
+			"end_column":  strconv.Itoa(d.End.Column),
+			"token":       d.Text,
+			"message":     d.Message,
+			"severity":    d.Severity.String(),
+			"fix":         d.Fix,
+			"suggestions": strings.Join(d.Suggestions, ","),
+		},
+	}
+}
+
+// fileStatusError returns the error for a file that can't be read as gRPC
+// status. A missing file is NotFound, all other errors are Internal.
+func fileStatusError(
+	err error,
+	filePath string,
+) error {
+	if errors.Is(err, fs.ErrNotExist) {
+		return status.Errorf(codes.NotFound, "file %s not found", filePath)
+	}
+
+	return status.Errorf(codes.Internal, "failed reading file %s: %v", filePath, err)
+}
+
+// recoverInternal has to be deferred by the plugin methods. It turns a panic into
+// an error with code Internal and the given context, so that an unexpected problem
+// only fails the current call and doesn't kill the plugin process for every user.
+func recoverInternal(
+	err *error,
+	format string,
+	args ...any,
+) {
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	action := fmt.Sprintf(format, args...)
+	log.Error().Msgf("recovered from panic while %s: %v\n%s", action, r, debug.Stack())
+	*err = status.Errorf(codes.Internal, "unexpected error while %s: %v", action, r)
+}